@@ -2,14 +2,21 @@ package config
 
 import (
 	"github.com/spf13/viper"
+	"time"
 )
 
 type Config struct {
-	DBUri               string `mapstructure:"MONGODB_LOCAL_URI"`
-	RedisUri            string `mapstructure:"REDIS_URL"`
-	Port                string `mapstructure:"PORT"`
-	JWTSecretKey        string `mapstructure:"JWT_SECRET_KEY"`
-	JWTRefreshSecretKey string `mapstructure:"JWT_REFRESH_SECRET_KEY"`
+	DBUri                   string        `mapstructure:"MONGODB_LOCAL_URI"`
+	RedisUri                string        `mapstructure:"REDIS_URL"`
+	Port                    string        `mapstructure:"PORT"`
+	JWTSecretKey            string        `mapstructure:"JWT_SECRET_KEY"`
+	JWTRefreshSecretKey     string        `mapstructure:"JWT_REFRESH_SECRET_KEY"`
+	TrashRetention          time.Duration `mapstructure:"TRASH_RETENTION"`
+	AttachmentStore         string        `mapstructure:"ATTACHMENT_STORE"`
+	AttachmentDir           string        `mapstructure:"ATTACHMENT_DIR"`
+	MaxAttachmentSize       int64         `mapstructure:"MAX_ATTACHMENT_SIZE"`
+	WebhookAllowedNetworks  []string      `mapstructure:"WEBHOOK_ALLOWED_NETWORKS"`
+	WebsocketAllowedOrigins []string      `mapstructure:"WEBSOCKET_ALLOWED_ORIGINS"`
 }
 
 var (
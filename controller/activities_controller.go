@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"todo/model"
+	"todo/service"
+)
+
+var activitySorts = map[string]string{
+	"created": "created_ts",
+}
+
+type activitiesController struct {
+	activityService service.ActivityServiceInterface
+	authorizer      *boardAuthorizer
+}
+
+func ActivitiesController(activityService service.ActivityServiceInterface, authorizer *boardAuthorizer) *activitiesController {
+	return &activitiesController{activityService, authorizer}
+}
+
+func (controller *activitiesController) RegisterActivitiesRoutes(e *echo.Echo) {
+	e.GET("/boards/:id/activity", controller.GetActivities, controller.authorizer.require(boardRoute, model.BoardRoleViewer))
+	fmt.Println("Registered /activity routes.")
+}
+
+func (controller *activitiesController) GetActivities(ctx echo.Context) error {
+	boardRecord := currentBoard(ctx)
+
+	page, err := bindPage(ctx, activitySorts, "-created")
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	results, nextCursor, err := controller.activityService.GetActivitiesPage(&boardRecord, page)
+	if err != nil {
+		return pageErrorResponse(ctx, err, "activity")
+	}
+
+	setNextPage(ctx, nextCursor)
+	return ctx.JSON(http.StatusOK, results)
+}
+
+// requestActor attributes the changes made by a request on a board route to the current
+// user, for the activity log kept by the services.
+func requestActor(ctx echo.Context) model.Actor {
+	return userActor(ctx, currentUser(ctx))
+}
+
+// userActor attributes the changes made by the request to the user, tagged with the id of
+// the request.
+func userActor(ctx echo.Context, user model.User) model.Actor {
+	return model.Actor{
+		UserID:    user.ID,
+		RequestID: ctx.Response().Header().Get(echo.HeaderXRequestID),
+	}
+}
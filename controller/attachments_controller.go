@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"todo/data"
+	"todo/model"
+	"todo/service"
+)
+
+type attachmentsController struct {
+	attachmentService service.AttachmentServiceInterface
+	authorizer        *boardAuthorizer
+}
+
+func AttachmentsController(attachmentService service.AttachmentServiceInterface, authorizer *boardAuthorizer) *attachmentsController {
+	return &attachmentsController{attachmentService, authorizer}
+}
+
+func (controller *attachmentsController) RegisterAttachmentsRoutes(e *echo.Echo) {
+	canView := controller.authorizer.require(taskRoute, model.BoardRoleViewer)
+	canEdit := controller.authorizer.require(taskRoute, model.BoardRoleEditor)
+
+	e.POST("/boards/:board_id/lists/:list_id/tasks/:id/attachments", controller.UploadAttachment, canEdit)
+	e.GET("/boards/:board_id/lists/:list_id/tasks/:id/attachments/:attachment_id", controller.DownloadAttachment, canView)
+	e.DELETE("/boards/:board_id/lists/:list_id/tasks/:id/attachments/:attachment_id", controller.DeleteAttachment, canEdit)
+	fmt.Println("Registered /attachments routes.")
+}
+
+// UploadAttachment stores the multipart form field named file.
+func (controller *attachmentsController) UploadAttachment(ctx echo.Context) error {
+	maxSize := controller.attachmentService.MaxAttachmentSize()
+
+	// Leave room for the multipart framing around the file itself.
+	ctx.Request().Body = http.MaxBytesReader(ctx.Response(), ctx.Request().Body, maxSize+1<<20)
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	if fileHeader.Size > maxSize {
+		return ctx.String(http.StatusRequestEntityTooLarge, "attachment is too large.")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+	defer file.Close()
+
+	taskRecord := currentTask(ctx)
+	if !ifMatchSatisfied(ctx, taskRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "task has been modified.")
+	}
+
+	resultTask, err := controller.attachmentService.AddAttachment(&taskRecord, fileHeader.Filename, file, requestActor(ctx))
+
+	if err == service.ErrAttachmentTooLarge {
+		return ctx.String(http.StatusRequestEntityTooLarge, err.Error()+".")
+	}
+
+	if isVersionConflict(err) {
+		return versionConflictResponse(ctx, "task")
+	}
+
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to upload attachment.")
+	}
+
+	setETag(ctx, resultTask.Version)
+	return ctx.JSON(http.StatusCreated, resultTask)
+}
+
+func (controller *attachmentsController) DownloadAttachment(ctx echo.Context) error {
+	attachmentID, err := data.StringToObjectID(ctx.Param("attachment_id"))
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	taskRecord := currentTask(ctx)
+	attachment, content, err := controller.attachmentService.OpenAttachment(&taskRecord, attachmentID)
+	if err == service.ErrAttachmentNotFound {
+		return ctx.String(http.StatusNotFound, err.Error()+".")
+	}
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to download attachment.")
+	}
+	defer content.Close()
+
+	header := ctx.Response().Header()
+	header.Set(echo.HeaderContentType, attachment.ContentType)
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	header.Set(echo.HeaderContentLength, strconv.FormatInt(attachment.Size, 10))
+	header.Set("X-Content-Type-Options", "nosniff")
+	ctx.Response().WriteHeader(http.StatusOK)
+
+	_, err = io.Copy(ctx.Response(), content)
+	return err
+}
+
+func (controller *attachmentsController) DeleteAttachment(ctx echo.Context) error {
+	attachmentID, err := data.StringToObjectID(ctx.Param("attachment_id"))
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	taskRecord := currentTask(ctx)
+	if !ifMatchSatisfied(ctx, taskRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "task has been modified.")
+	}
+
+	resultTask, err := controller.attachmentService.DeleteAttachment(&taskRecord, attachmentID, requestActor(ctx))
+
+	if err == service.ErrAttachmentNotFound {
+		return ctx.String(http.StatusNotFound, err.Error()+".")
+	}
+
+	if isVersionConflict(err) {
+		return versionConflictResponse(ctx, "task")
+	}
+
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to delete attachment.")
+	}
+
+	setETag(ctx, resultTask.Version)
+	return ctx.JSON(http.StatusOK, resultTask)
+}
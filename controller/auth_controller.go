@@ -2,11 +2,10 @@ package controller
 
 import (
 	"fmt"
-	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
-	"time"
+	"strings"
 	"todo/model"
 	"todo/service"
 )
@@ -26,6 +25,8 @@ func (controller *authController) JWTErrorChecker(err error, c echo.Context) err
 
 func (controller *authController) RegisterLoginRoutes(e *echo.Echo) {
 	e.POST("/login", controller.HandleLogin)
+	e.POST("/auth/refresh", controller.HandleRefresh)
+	e.POST("/auth/logout", controller.HandleLogout)
 	fmt.Println("Registered authentication routes.")
 }
 
@@ -56,40 +57,61 @@ func (controller *authController) HandleLogin(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, model.LoginResponse{Token: token, RefreshToken: refreshToken})
 }
 
-func (controller *authController) TokenRefresherMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
+func (controller *authController) HandleRefresh(ctx echo.Context) error {
+	refreshToken := controller.refreshTokenFromRequest(ctx)
+	if refreshToken == "" {
+		return ctx.String(http.StatusUnauthorized, "missing refresh token.")
+	}
+
+	token, newRefreshToken, err := controller.authService.RefreshTokensAndSetCookies(refreshToken, ctx)
+	if err != nil {
+		return ctx.String(http.StatusUnauthorized, "refresh token is invalid.")
+	}
 
-		if c.Get("user") == nil {
-			return next(c)
-		}
+	return ctx.JSON(http.StatusOK, model.LoginResponse{Token: token, RefreshToken: newRefreshToken})
+}
+
+// HandleLogout is authenticated by the refresh token rather than the access token, which
+// may have expired already.
+func (controller *authController) HandleLogout(ctx echo.Context) error {
+	refreshToken := controller.refreshTokenFromRequest(ctx)
+	if refreshToken == "" {
+		return ctx.String(http.StatusUnauthorized, "missing refresh token.")
+	}
 
-		u := c.Get("user").(*jwt.Token)
-		claims := u.Claims.(*model.Claims)
+	err := controller.authService.RevokeTokensAndClearCookies(refreshToken, controller.accessTokenFromRequest(ctx), ctx)
+	if err != nil {
+		return ctx.String(http.StatusUnauthorized, "refresh token is invalid.")
+	}
 
-		if time.Unix(claims.ExpiresAt, 0).Sub(time.Now()) < 15*time.Minute {
-			rc, err := c.Cookie(controller.authService.GetRefreshTokenCookieName())
+	return ctx.JSON(http.StatusNoContent, nil)
+}
 
-			if err == nil && rc != nil {
-				tkn, err := jwt.ParseWithClaims(rc.Value, claims, func(token *jwt.Token) (interface{}, error) {
-					return []byte(controller.authService.GetRefreshJWTSecret()), nil
-				})
+// accessTokenFromRequest reads the access token the way the JWT middleware does, from
+// the access token cookie or else the Authorization header.
+func (controller *authController) accessTokenFromRequest(ctx echo.Context) string {
+	cookie, err := ctx.Cookie(controller.authService.GetAccessTokenCookieName())
+	if err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
 
-				if err != nil {
-					if err == jwt.ErrSignatureInvalid {
-						c.Response().Writer.WriteHeader(http.StatusUnauthorized)
-					}
-				}
+	return strings.TrimPrefix(ctx.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+}
 
-				if tkn != nil && tkn.Valid {
-					var _, _, _ = controller.authService.GenerateTokensAndSetCookies(&model.User{
-						Username: claims.Username,
-					}, c)
-				}
-			}
-		}
+// refreshTokenFromRequest prefers the refresh token cookie and falls back to the
+// refresh_token field of the request body for clients that don't keep cookies.
+func (controller *authController) refreshTokenFromRequest(ctx echo.Context) string {
+	cookie, err := ctx.Cookie(controller.authService.GetRefreshTokenCookieName())
+	if err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
 
-		return next(c)
+	var req model.RefreshRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ""
 	}
+
+	return req.RefreshToken
 }
 
 func checkPasswordHash(password, hash string) bool {
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"todo/model"
+	"todo/service"
+)
+
+const (
+	currentUserContextKey      = "current_user"
+	currentBoardRoleContextKey = "current_board_role"
+	currentBoardContextKey     = "current_board"
+	currentListContextKey      = "current_list"
+	currentTaskContextKey      = "current_task"
+)
+
+// boardRouteParams names the path params that hold the board, list and task ids of a
+// route. Records whose param name is empty are not resolved. When trashed is set the
+// innermost record is looked up in the trash, while its parents must still be active.
+type boardRouteParams struct {
+	board   string
+	list    string
+	task    string
+	trashed bool
+}
+
+var (
+	boardRoute        = boardRouteParams{board: "id"}
+	listsRoute        = boardRouteParams{board: "board_id"}
+	listRoute         = boardRouteParams{board: "board_id", list: "id"}
+	tasksRoute        = boardRouteParams{board: "board_id", list: "list_id"}
+	taskRoute         = boardRouteParams{board: "board_id", list: "list_id", task: "id"}
+	commentsRoute     = boardRouteParams{board: "board_id", list: "list_id", task: "task_id"}
+	trashedBoardRoute = boardRouteParams{board: "id", trashed: true}
+	trashedListRoute  = boardRouteParams{board: "board_id", list: "id", trashed: true}
+	trashedTaskRoute  = boardRouteParams{board: "board_id", list: "list_id", task: "id", trashed: true}
+)
+
+type boardAuthorizer struct {
+	authService  service.AuthServiceInterface
+	boardService service.BoardServiceInterface
+	listService  service.ListServiceInterface
+	taskService  service.TaskServiceInterface
+}
+
+func BoardAuthorizer(authService service.AuthServiceInterface, boardService service.BoardServiceInterface,
+	listService service.ListServiceInterface, taskService service.TaskServiceInterface) *boardAuthorizer {
+	return &boardAuthorizer{authService, boardService, listService, taskService}
+}
+
+// require returns a middleware that loads the board, list and task named by the route
+// params, verifies that each record belongs to its parent and that the current user
+// holds at least the required role on the board. The resolved records are stored on
+// the context and read back by handlers through currentBoard, currentList and currentTask.
+func (authorizer *boardAuthorizer) require(params boardRouteParams, required string) echo.MiddlewareFunc {
+	return authorizer.requireFor(authorizer.authService.GetCurrentUser, params, required)
+}
+
+// requireFor works like require for routes that authenticate the user by other means than
+// the access token, with findUser resolving the user of the request.
+func (authorizer *boardAuthorizer) requireFor(findUser func(echo.Context) (model.User, error), params boardRouteParams, required string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			userResult, err := findUser(ctx)
+			if err != nil {
+				return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+			}
+
+			findBoard := authorizer.boardService.FindBoardById
+			findList := authorizer.listService.FindListById
+			findTask := authorizer.taskService.FindTaskById
+			if params.trashed {
+				switch {
+				case params.task != "":
+					findTask = authorizer.taskService.FindTrashedTaskById
+				case params.list != "":
+					findList = authorizer.listService.FindTrashedListById
+				default:
+					findBoard = authorizer.boardService.FindTrashedBoardById
+				}
+			}
+
+			boardRecord, err := findBoard(ctx.Param(params.board))
+			if err != nil {
+				return ctx.String(http.StatusBadRequest, "board not found.")
+			}
+
+			role := authorizer.boardService.GetBoardRole(&boardRecord, &userResult)
+			if ok, err := authorizeBoardRole(ctx, role, required); !ok {
+				return err
+			}
+
+			if params.list != "" {
+				listRecord, err := findList(ctx.Param(params.list))
+				if err != nil || listRecord.BoardID != boardRecord.ID {
+					return ctx.String(http.StatusBadRequest, "list not found.")
+				}
+				ctx.Set(currentListContextKey, listRecord)
+
+				if params.task != "" {
+					taskRecord, err := findTask(ctx.Param(params.task))
+					if err != nil || taskRecord.ListID != listRecord.ID {
+						return ctx.String(http.StatusBadRequest, "task not found.")
+					}
+					ctx.Set(currentTaskContextKey, taskRecord)
+				}
+			}
+
+			ctx.Set(currentUserContextKey, userResult)
+			ctx.Set(currentBoardRoleContextKey, role)
+			ctx.Set(currentBoardContextKey, boardRecord)
+
+			return next(ctx)
+		}
+	}
+}
+
+func currentUser(ctx echo.Context) model.User {
+	return ctx.Get(currentUserContextKey).(model.User)
+}
+
+func currentBoardRole(ctx echo.Context) string {
+	return ctx.Get(currentBoardRoleContextKey).(string)
+}
+
+func currentBoard(ctx echo.Context) model.Board {
+	return ctx.Get(currentBoardContextKey).(model.Board)
+}
+
+func currentList(ctx echo.Context) model.BoardList {
+	return ctx.Get(currentListContextKey).(model.BoardList)
+}
+
+func currentTask(ctx echo.Context) model.Task {
+	return ctx.Get(currentTaskContextKey).(model.Task)
+}
@@ -0,0 +1,319 @@
+package controller
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo/model"
+	"todo/service"
+)
+
+// Largest import body accepted.
+const maxBoardImportSize = 10 << 20
+
+type boardExportsController struct {
+	boardExportService  service.BoardExportServiceInterface
+	trelloImportService service.TrelloImportServiceInterface
+	authService         service.AuthServiceInterface
+	authorizer          *boardAuthorizer
+}
+
+func BoardExportsController(boardExportService service.BoardExportServiceInterface, trelloImportService service.TrelloImportServiceInterface,
+	authService service.AuthServiceInterface, authorizer *boardAuthorizer) *boardExportsController {
+	return &boardExportsController{boardExportService, trelloImportService, authService, authorizer}
+}
+
+func (controller *boardExportsController) RegisterBoardExportsRoutes(e *echo.Echo) {
+	e.GET("/boards/:id/export", controller.ExportBoard, controller.authorizer.require(boardRoute, model.BoardRoleViewer))
+	e.POST("/boards/import", controller.ImportBoard)
+	e.POST("/boards/import/trello", controller.ImportTrelloBoard)
+	e.POST("/boards/:id/duplicate", controller.DuplicateBoard, controller.authorizer.require(boardRoute, model.BoardRoleViewer))
+	fmt.Println("Registered /boards export routes.")
+}
+
+// ExportBoard writes the board as JSON, which can be imported again, or as CSV with one
+// row per task or as Markdown for reading.
+func (controller *boardExportsController) ExportBoard(ctx echo.Context) error {
+	format := ctx.QueryParam("format")
+	if format == "" {
+		format = "json"
+	}
+
+	var contentType string
+	var write func(io.Writer, *model.BoardExport) error
+	switch format {
+	case "json":
+		contentType, write = echo.MIMEApplicationJSONCharsetUTF8, writeBoardJSON
+	case "csv":
+		contentType, write = "text/csv; charset=utf-8", writeBoardCSV
+	case "md":
+		contentType, write = "text/markdown; charset=utf-8", writeBoardMarkdown
+	default:
+		return ctx.String(http.StatusBadRequest, "format must be json, csv or md.")
+	}
+
+	boardRecord := currentBoard(ctx)
+	export, err := controller.boardExportService.ExportBoard(&boardRecord)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to export board.")
+	}
+
+	filename := exportFilename(boardRecord.Name) + "." + format
+	header := ctx.Response().Header()
+	header.Set(echo.HeaderContentType, contentType)
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	ctx.Response().WriteHeader(http.StatusOK)
+
+	return write(ctx.Response(), export)
+}
+
+// ImportBoard creates a board owned by the caller from the JSON export format.
+func (controller *boardExportsController) ImportBoard(ctx echo.Context) error {
+	userResult, err := controller.authService.GetCurrentUser(ctx)
+	if err != nil {
+		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	}
+
+	var export model.BoardExport
+	body := http.MaxBytesReader(ctx.Response(), ctx.Request().Body, maxBoardImportSize)
+	if err := json.NewDecoder(body).Decode(&export); err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	resultBoard, err := controller.boardExportService.ImportBoard(&userResult, &export, userActor(ctx, userResult))
+	if errors.Is(err, service.ErrInvalidBoardImport) {
+		return ctx.String(http.StatusBadRequest, err.Error()+".")
+	}
+
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to import board.")
+	}
+
+	return ctx.JSON(http.StatusCreated, resultBoard)
+}
+
+// ImportTrelloBoard creates a board owned by the caller from a Trello board export and
+// responds with a report of what was imported and what was left out.
+func (controller *boardExportsController) ImportTrelloBoard(ctx echo.Context) error {
+	userResult, err := controller.authService.GetCurrentUser(ctx)
+	if err != nil {
+		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	}
+
+	var trello model.TrelloBoard
+	body := http.MaxBytesReader(ctx.Response(), ctx.Request().Body, maxBoardImportSize)
+	if err := json.NewDecoder(body).Decode(&trello); err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	report, err := controller.trelloImportService.ImportTrelloBoard(&userResult, &trello, userActor(ctx, userResult))
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to import board.")
+	}
+
+	return ctx.JSON(http.StatusCreated, report)
+}
+
+// DuplicateBoard copies the board with its lists, and optionally its tasks, into a new
+// board owned by the caller.
+func (controller *boardExportsController) DuplicateBoard(ctx echo.Context) error {
+	var req model.DuplicateBoardRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	boardRecord := currentBoard(ctx)
+	userResult := currentUser(ctx)
+	resultBoard, err := controller.boardExportService.DuplicateBoard(&userResult, &boardRecord, req.Name, req.IncludeTasks, requestActor(ctx))
+	if errors.Is(err, service.ErrInvalidBoardImport) {
+		return ctx.String(http.StatusBadRequest, err.Error()+".")
+	}
+
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to duplicate board.")
+	}
+
+	return ctx.JSON(http.StatusCreated, resultBoard)
+}
+
+// writeBoardJSON writes the export a list at a time rather than encoding the whole board
+// up front. The document is the same as encoding the export at once.
+func writeBoardJSON(w io.Writer, export *model.BoardExport) error {
+	buffered := bufio.NewWriter(w)
+	name, err := json.Marshal(export.Name)
+	if err != nil {
+		return err
+	}
+	labels, err := json.Marshal(export.Labels)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buffered, "{\"name\":%s,\"labels\":%s,\"lists\":[", name, labels)
+
+	for i, list := range export.Lists {
+		if i > 0 {
+			buffered.WriteString(",")
+		}
+		encoded, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		buffered.Write(encoded)
+	}
+
+	exportedTS, err := json.Marshal(export.ExportedTS)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buffered, "],\"exported_ts\":%s}\n", exportedTS)
+	return buffered.Flush()
+}
+
+// writeBoardCSV writes one row per task. Lists without tasks get a row with empty task
+// columns so that they survive the export.
+func writeBoardCSV(w io.Writer, export *model.BoardExport) error {
+	labelNames := exportLabelNames(export)
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"list", "task", "content", "labels", "start", "due", "checklist_done", "checklist_total"})
+
+	for _, list := range export.Lists {
+		if len(list.Tasks) == 0 {
+			writer.Write([]string{csvCell(list.Name), "", "", "", "", "", "", ""})
+		}
+
+		for _, task := range list.Tasks {
+			done, total := exportChecklistProgress(&task)
+			writer.Write([]string{
+				csvCell(list.Name),
+				csvCell(task.Name),
+				csvCell(task.Content),
+				csvCell(strings.Join(taskLabelNames(&task, labelNames), ";")),
+				exportTime(task.StartTS),
+				exportTime(task.DueTS),
+				strconv.Itoa(done),
+				strconv.Itoa(total),
+			})
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvCell keeps spreadsheets from evaluating user text as a formula by prefixing values
+// that start like one with a quote. Leading tabs and carriage returns count as well, as
+// some spreadsheets skip them before looking for a formula.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func writeBoardMarkdown(w io.Writer, export *model.BoardExport) error {
+	labelNames := exportLabelNames(export)
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "# %s\n", export.Name)
+
+	for _, list := range export.Lists {
+		fmt.Fprintf(b, "\n## %s\n\n", list.Name)
+		if len(list.Tasks) == 0 {
+			b.WriteString("_No tasks._\n")
+		}
+
+		for _, task := range list.Tasks {
+			fmt.Fprintf(b, "- **%s**", task.Name)
+			for _, name := range taskLabelNames(&task, labelNames) {
+				fmt.Fprintf(b, " `%s`", name)
+			}
+			if task.StartTS != nil {
+				fmt.Fprintf(b, " (start %s)", exportTime(task.StartTS))
+			}
+			if task.DueTS != nil {
+				fmt.Fprintf(b, " (due %s)", exportTime(task.DueTS))
+			}
+			b.WriteString("\n")
+
+			if content := strings.TrimSpace(task.Content); content != "" {
+				fmt.Fprintf(b, "\n  %s\n\n", strings.ReplaceAll(content, "\n", "\n  "))
+			}
+
+			for _, checklist := range task.Checklists {
+				fmt.Fprintf(b, "  - %s\n", checklist.Name)
+				for _, item := range checklist.Items {
+					check := " "
+					if item.Done {
+						check = "x"
+					}
+					fmt.Fprintf(b, "    - [%s] %s\n", check, item.Name)
+				}
+			}
+		}
+	}
+
+	return b.Flush()
+}
+
+func exportLabelNames(export *model.BoardExport) map[string]string {
+	names := map[string]string{}
+	for _, label := range export.Labels {
+		names[label.ID.Hex()] = label.Name
+	}
+	return names
+}
+
+func taskLabelNames(task *model.TaskExport, labelNames map[string]string) []string {
+	names := make([]string, 0, len(task.LabelIDs))
+	for _, labelId := range task.LabelIDs {
+		if name, ok := labelNames[labelId.Hex()]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func exportChecklistProgress(task *model.TaskExport) (done int, total int) {
+	for _, checklist := range task.Checklists {
+		for _, item := range checklist.Items {
+			total++
+			if item.Done {
+				done++
+			}
+		}
+	}
+	return
+}
+
+func exportTime(ts *time.Time) string {
+	if ts == nil {
+		return ""
+	}
+	return ts.UTC().Format(time.RFC3339)
+}
+
+// exportFilename keeps the letters, digits, dashes and underscores of the board name.
+func exportFilename(name string) string {
+	filename := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r == ' ':
+			return '-'
+		}
+		return -1
+	}, name)
+
+	if filename == "" {
+		return "board"
+	}
+	return filename
+}
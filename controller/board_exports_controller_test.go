@@ -0,0 +1,23 @@
+package controller
+
+import "testing"
+
+func TestCsvCellNeutralisesFormulas(t *testing.T) {
+	tests := map[string]string{
+		"":               "",
+		"plain":          "plain",
+		"a=b":            "a=b",
+		"=SUM(A1:A2)":    "'=SUM(A1:A2)",
+		"+1":             "'+1",
+		"-1":             "'-1",
+		"@cmd":           "'@cmd",
+		"\t=1+1":         "'\t=1+1",
+		"\r=HYPERLINK()": "'\r=HYPERLINK()",
+	}
+
+	for value, want := range tests {
+		if got := csvCell(value); got != want {
+			t.Errorf("csvCell(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"todo/model"
+)
+
+// authorizeBoardRole writes the error response for a user whose role on a board does not
+// satisfy the required role and reports whether the handler may continue. Users without
+// any access get a 401, members holding a weaker role get a 403.
+func authorizeBoardRole(ctx echo.Context, role string, required string) (bool, error) {
+	if role == "" {
+		return false, ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	}
+
+	if !model.BoardRoleSatisfies(role, required) {
+		return false, ctx.String(http.StatusForbidden, "user does not have permission to modify this board.")
+	}
+
+	return true, nil
+}
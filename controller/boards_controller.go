@@ -10,20 +10,38 @@ import (
 )
 
 type boardsController struct {
-	boardService service.BoardServiceInterface
-	authService  service.AuthServiceInterface
+	boardService    service.BoardServiceInterface
+	authService     service.AuthServiceInterface
+	userService     service.UserServiceInterface
+	templateService service.TemplateServiceInterface
+	authorizer      *boardAuthorizer
 }
 
-func BoardsController(boardService service.BoardServiceInterface, authService service.AuthServiceInterface) *boardsController {
-	return &boardsController{boardService, authService}
+func BoardsController(boardService service.BoardServiceInterface, authService service.AuthServiceInterface,
+	userService service.UserServiceInterface, templateService service.TemplateServiceInterface, authorizer *boardAuthorizer) *boardsController {
+	return &boardsController{boardService, authService, userService, templateService, authorizer}
 }
 
 func (controller *boardsController) RegisterBoardsRoutes(e *echo.Echo) {
+	canView := controller.authorizer.require(boardRoute, model.BoardRoleViewer)
+	canEdit := controller.authorizer.require(boardRoute, model.BoardRoleEditor)
+	canManage := controller.authorizer.require(boardRoute, model.BoardRoleOwner)
+
 	e.GET("/boards", controller.GetBoards)
-	e.GET("/boards/:id", controller.FindBoardsById)
+	e.GET("/boards/:id", controller.FindBoardsById, canView)
 	e.POST("/boards", controller.CreateBoard)
-	e.PUT("/boards/:id", controller.UpdateBoard)
-	e.DELETE("/boards/:id", controller.DeleteBoard)
+	e.PUT("/boards/:id", controller.UpdateBoard, canEdit)
+	e.PATCH("/boards/:id", controller.PatchBoard, canEdit)
+	e.DELETE("/boards/:id", controller.DeleteBoard, canManage)
+	e.POST("/boards/:id/restore", controller.RestoreBoard, controller.authorizer.require(trashedBoardRoute, model.BoardRoleOwner))
+	e.GET("/boards/:id/members", controller.GetBoardMembers, canView)
+	e.POST("/boards/:id/members", controller.AddBoardMember, canManage)
+	e.PUT("/boards/:id/members/:user_id", controller.UpdateBoardMember, canManage)
+	e.DELETE("/boards/:id/members/:user_id", controller.RemoveBoardMember, canView)
+	e.GET("/boards/:id/labels", controller.GetBoardLabels, canView)
+	e.POST("/boards/:id/labels", controller.AddBoardLabel, canEdit)
+	e.PUT("/boards/:id/labels/:label_id", controller.UpdateBoardLabel, canEdit)
+	e.DELETE("/boards/:id/labels/:label_id", controller.RemoveBoardLabel, canEdit)
 	fmt.Println("Registered /boards routes.")
 }
 
@@ -33,32 +51,30 @@ func (controller *boardsController) GetBoards(ctx echo.Context) error {
 		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
 	}
 
-	results, err := controller.boardService.GetBoards(userResult.ID.Hex())
-	if err != nil {
-		return ctx.String(http.StatusInternalServerError, "failed to get boards.")
-	}
-
-	return ctx.JSON(http.StatusOK, results)
-}
-
-func (controller *boardsController) FindBoardsById(ctx echo.Context) error {
-	var req, err = controller.bindBoardRequest(ctx)
+	page, err := bindPage(ctx, boardSorts, "created")
 	if err != nil {
 		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	boardResult, err := controller.boardService.FindBoardById(req.ID)
+	results, nextCursor, err := controller.boardService.GetBoardsPage(userResult.ID.Hex(), page)
 	if err != nil {
-		return ctx.String(http.StatusBadRequest, "board not found.")
+		return pageErrorResponse(ctx, err, "boards")
 	}
 
-	userResult, err := controller.authService.GetCurrentUser(ctx)
-
-	if err != nil || (boardResult.OwnerID.Hex() != userResult.ID.Hex() && !userResult.IsAdmin) {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	idVersions := make([]string, 0, len(results))
+	for _, board := range results {
+		idVersions = append(idVersions, idVersion(board.ID, board.Version))
 	}
+	setCollectionETag(ctx, idVersions)
+	setNextPage(ctx, nextCursor)
+
+	return ctx.JSON(http.StatusOK, results)
+}
 
-	return ctx.JSON(http.StatusOK, boardResult)
+func (controller *boardsController) FindBoardsById(ctx echo.Context) error {
+	boardRecord := currentBoard(ctx)
+	setETag(ctx, boardRecord.Version)
+	return ctx.JSON(http.StatusOK, boardRecord)
 }
 
 func (controller *boardsController) CreateBoard(ctx echo.Context) error {
@@ -74,14 +90,20 @@ func (controller *boardsController) CreateBoard(ctx echo.Context) error {
 	}
 
 	var boardRecord model.Board
-	if len(boardRecord.Name) > 100 {
-		boardRecord.Name = req.Name[0:100]
+	boardRecord.Name = service.TruncateName(req.Name)
+	boardRecord.OwnerID = userResult.ID
+
+	var resultBoard *model.Board
+	var insertErr error
+	if templateID := ctx.QueryParam("template_id"); templateID != "" {
+		resultBoard, insertErr = controller.templateService.InstantiateTemplate(&userResult, templateID, boardRecord.Name, userActor(ctx, userResult))
 	} else {
-		boardRecord.Name = req.Name
+		resultBoard, insertErr = controller.boardService.CreateBoard(&boardRecord, userActor(ctx, userResult))
 	}
-	boardRecord.OwnerID = userResult.ID
 
-	resultBoard, insertErr := controller.boardService.CreateBoard(&boardRecord)
+	if insertErr == service.ErrTemplateNotFound {
+		return ctx.String(http.StatusNotFound, insertErr.Error()+".")
+	}
 
 	if insertErr != nil {
 		return ctx.String(http.StatusInternalServerError, "Failed to create board.")
@@ -97,67 +119,251 @@ func (controller *boardsController) UpdateBoard(ctx echo.Context) error {
 		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	userResult, err := controller.authService.GetCurrentUser(ctx)
+	boardRecord := currentBoard(ctx)
+	if !ifMatchSatisfied(ctx, boardRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "board has been modified.")
+	}
+
+	boardRecord.Name = service.TruncateName(req.Name)
+
+	resultBoard, updateErr := controller.boardService.UpdateBoard(&boardRecord, requestActor(ctx))
+
+	if isVersionConflict(updateErr) {
+		return versionConflictResponse(ctx, "board")
+	}
+
+	if updateErr != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to update board.")
+	}
+
+	setETag(ctx, resultBoard.Version)
+	return ctx.JSON(http.StatusOK, resultBoard)
+}
+
+func (controller *boardsController) PatchBoard(ctx echo.Context) error {
+	members, err := bindMergePatch(ctx)
 	if err != nil {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	boardRecord, err := controller.boardService.FindBoardById(req.ID)
+	patch, err := boardPatch(members)
 	if err != nil {
-		return ctx.String(http.StatusBadRequest, "board not found.")
+		return ctx.String(http.StatusBadRequest, "bad request. "+err.Error()+".")
 	}
 
-	if boardRecord.OwnerID != userResult.ID && !userResult.IsAdmin {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	boardRecord := currentBoard(ctx)
+	if !ifMatchSatisfied(ctx, boardRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "board has been modified.")
 	}
 
-	if len(boardRecord.Name) > 100 {
-		boardRecord.Name = req.Name[0:100]
-	} else {
-		boardRecord.Name = req.Name
+	if patch.IsEmpty() {
+		setETag(ctx, boardRecord.Version)
+		return ctx.JSON(http.StatusOK, boardRecord)
 	}
 
-	resultBoard, updateErr := controller.boardService.UpdateBoard(&boardRecord)
+	resultBoard, patchErr := controller.boardService.PatchBoard(&boardRecord, patch, requestActor(ctx))
 
-	if updateErr != nil {
+	if isVersionConflict(patchErr) {
+		return versionConflictResponse(ctx, "board")
+	}
+
+	if patchErr != nil {
 		return ctx.String(http.StatusInternalServerError, "Failed to update board.")
 	}
 
+	setETag(ctx, resultBoard.Version)
 	return ctx.JSON(http.StatusOK, resultBoard)
 }
 
 func (controller *boardsController) DeleteBoard(ctx echo.Context) error {
-	req, err := controller.bindBoardRequest(ctx)
+	boardRecord := currentBoard(ctx)
+
+	deleteErr := controller.boardService.DeleteBoard(&boardRecord, requestActor(ctx))
+
+	if deleteErr != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to delete board.")
+	}
+
+	return ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (controller *boardsController) RestoreBoard(ctx echo.Context) error {
+	boardRecord := currentBoard(ctx)
+
+	restoreErr := controller.boardService.RestoreBoard(&boardRecord, requestActor(ctx))
+
+	if restoreErr != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to restore board.")
+	}
+
+	resultBoard, err := controller.boardService.FindBoardById(boardRecord.ID.Hex())
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to restore board.")
+	}
+
+	return ctx.JSON(http.StatusOK, resultBoard)
+}
+
+func (controller *boardsController) GetBoardMembers(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, currentBoard(ctx).Members)
+}
 
+func (controller *boardsController) AddBoardMember(ctx echo.Context) error {
+	var req, err = controller.bindBoardMemberRequest(ctx)
 	if err != nil {
 		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	boardResult, err := controller.boardService.FindBoardById(req.ID)
+	var memberResult model.User
+	if req.UserID != "" {
+		memberResult, err = controller.userService.FindUserById(req.UserID)
+	} else {
+		memberResult, err = controller.userService.FindUserByUsername(req.Username)
+	}
+
 	if err != nil {
-		return ctx.String(http.StatusBadRequest, "board not found.")
+		return ctx.String(http.StatusBadRequest, "user not found.")
 	}
 
-	userResult, err := controller.authService.GetCurrentUser(ctx)
+	if req.Role == "" {
+		req.Role = model.BoardRoleViewer
+	}
 
-	if err != nil || (boardResult.OwnerID.Hex() != userResult.ID.Hex() && !userResult.IsAdmin) {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	boardRecord := currentBoard(ctx)
+	resultBoard, err := controller.boardService.AddBoardMember(&boardRecord, memberResult.ID, req.Role, requestActor(ctx))
+	if err != nil {
+		return controller.memberErrorResponse(ctx, err)
 	}
 
-	var boardRecord model.Board
-	boardRecord.ID, err = data.StringToObjectID(req.ID)
+	return ctx.JSON(http.StatusOK, resultBoard.Members)
+}
 
+func (controller *boardsController) UpdateBoardMember(ctx echo.Context) error {
+	var req, err = controller.bindBoardMemberRequest(ctx)
 	if err != nil {
 		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	deleteErr := controller.boardService.DeleteBoard(&boardRecord)
+	memberID, err := data.StringToObjectID(req.UserID)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
 
-	if deleteErr != nil {
-		return ctx.String(http.StatusInternalServerError, "Failed to delete board.")
+	boardRecord := currentBoard(ctx)
+	resultBoard, err := controller.boardService.UpdateBoardMember(&boardRecord, memberID, req.Role, requestActor(ctx))
+	if err != nil {
+		return controller.memberErrorResponse(ctx, err)
 	}
 
-	return ctx.JSON(http.StatusNoContent, nil)
+	return ctx.JSON(http.StatusOK, resultBoard.Members)
+}
+
+func (controller *boardsController) RemoveBoardMember(ctx echo.Context) error {
+	var req, err = controller.bindBoardMemberRequest(ctx)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	memberID, err := data.StringToObjectID(req.UserID)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	// Members may always leave a board on their own.
+	if memberID != currentUser(ctx).ID {
+		if ok, err := authorizeBoardRole(ctx, currentBoardRole(ctx), model.BoardRoleOwner); !ok {
+			return err
+		}
+	}
+
+	boardRecord := currentBoard(ctx)
+	resultBoard, err := controller.boardService.RemoveBoardMember(&boardRecord, memberID, requestActor(ctx))
+	if err != nil {
+		return controller.memberErrorResponse(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, resultBoard.Members)
+}
+
+func (controller *boardsController) memberErrorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case service.ErrBoardMemberExists, service.ErrBoardMemberNotFound, service.ErrBoardOwnerImmutable, service.ErrInvalidBoardRole:
+		return ctx.String(http.StatusBadRequest, err.Error()+".")
+	case service.ErrVersionConflict:
+		return versionConflictResponse(ctx, "board")
+	}
+	return ctx.String(http.StatusInternalServerError, "Failed to update board members.")
+}
+
+func (controller *boardsController) GetBoardLabels(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, currentBoard(ctx).Labels)
+}
+
+func (controller *boardsController) AddBoardLabel(ctx echo.Context) error {
+	var req, err = controller.bindBoardLabelRequest(ctx)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	boardRecord := currentBoard(ctx)
+	resultBoard, err := controller.boardService.AddBoardLabel(&boardRecord, req.Name, req.Color, requestActor(ctx))
+	if err != nil {
+		return controller.labelErrorResponse(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, resultBoard.Labels)
+}
+
+func (controller *boardsController) UpdateBoardLabel(ctx echo.Context) error {
+	var req, err = controller.bindBoardLabelRequest(ctx)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	labelID, err := data.StringToObjectID(req.LabelID)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	boardRecord := currentBoard(ctx)
+	resultBoard, err := controller.boardService.UpdateBoardLabel(&boardRecord, labelID, req.Name, req.Color, requestActor(ctx))
+	if err != nil {
+		return controller.labelErrorResponse(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, resultBoard.Labels)
+}
+
+func (controller *boardsController) RemoveBoardLabel(ctx echo.Context) error {
+	var req, err = controller.bindBoardLabelRequest(ctx)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	labelID, err := data.StringToObjectID(req.LabelID)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	boardRecord := currentBoard(ctx)
+	resultBoard, err := controller.boardService.RemoveBoardLabel(&boardRecord, labelID, requestActor(ctx))
+	if err != nil {
+		return controller.labelErrorResponse(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, resultBoard.Labels)
+}
+
+func (controller *boardsController) labelErrorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case service.ErrBoardLabelExists, service.ErrInvalidBoardLabel:
+		return ctx.String(http.StatusBadRequest, err.Error()+".")
+	case service.ErrBoardLabelNotFound:
+		return ctx.String(http.StatusNotFound, err.Error()+".")
+	case service.ErrVersionConflict:
+		return versionConflictResponse(ctx, "board")
+	}
+	return ctx.String(http.StatusInternalServerError, "Failed to update board labels.")
 }
 
 func (controller *boardsController) bindBoardRequest(ctx echo.Context) (*model.BoardRequest, error) {
@@ -170,3 +376,25 @@ func (controller *boardsController) bindBoardRequest(ctx echo.Context) (*model.B
 
 	return &req, nil
 }
+
+func (controller *boardsController) bindBoardLabelRequest(ctx echo.Context) (*model.BoardLabelRequest, error) {
+	var req model.BoardLabelRequest
+
+	err := ctx.Bind(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+func (controller *boardsController) bindBoardMemberRequest(ctx echo.Context) (*model.BoardMemberRequest, error) {
+	var req model.BoardMemberRequest
+
+	err := ctx.Bind(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
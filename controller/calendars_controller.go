@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo/model"
+	"todo/service"
+	"unicode/utf8"
+)
+
+const (
+	calendarTokenParam = "token"
+	calendarTaskLimit  = 1000
+	// calendarHistory is how far back feeds go, so that old deadlines do not crowd out the
+	// upcoming ones.
+	calendarHistory = 90 * 24 * time.Hour
+	icsTimeFormat   = "20060102T150405Z"
+	icsLineLimit    = 75
+)
+
+type calendarsController struct {
+	agendaService service.AgendaServiceInterface
+	userService   service.UserServiceInterface
+	authService   service.AuthServiceInterface
+	authorizer    *boardAuthorizer
+}
+
+func CalendarsController(agendaService service.AgendaServiceInterface, userService service.UserServiceInterface,
+	authService service.AuthServiceInterface, authorizer *boardAuthorizer) *calendarsController {
+	return &calendarsController{agendaService, userService, authService, authorizer}
+}
+
+// RegisterCalendarsRoutes registers the iCalendar feeds and the management of the token
+// that opens them. Calendar clients can not send the access token cookie, so the feeds
+// are exempt from it and take the calendar token from the URL instead.
+func (controller *calendarsController) RegisterCalendarsRoutes(e *echo.Echo) {
+	e.POST("/me/calendar-token", controller.CreateCalendarToken)
+	e.DELETE("/me/calendar-token", controller.RevokeCalendarToken)
+	e.GET("/me/calendar.ics", controller.GetUserCalendar)
+	e.GET("/boards/:id/calendar.ics", controller.GetBoardCalendar, controller.authorizer.requireFor(controller.calendarUser, boardRoute, model.BoardRoleViewer))
+	fmt.Println("Registered /calendar routes.")
+}
+
+// IsCalendarFeed tells the access token middleware which requests are calendar feeds.
+func IsCalendarFeed(ctx echo.Context) bool {
+	path := ctx.Request().URL.Path
+	if path == "/me/calendar.ics" {
+		return true
+	}
+
+	segments := strings.Split(path, "/")
+	return len(segments) == 4 && segments[0] == "" && segments[1] == "boards" && segments[2] != "" && segments[3] == "calendar.ics"
+}
+
+// RedactCalendarToken keeps calendar tokens out of the request log. It only rewrites the
+// raw request URI the logger prints; the handlers read the token from the parsed URL.
+func RedactCalendarToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		req := ctx.Request()
+		query := req.URL.Query()
+		if query.Has(calendarTokenParam) {
+			query.Set(calendarTokenParam, "REDACTED")
+			redacted := *req.URL
+			redacted.RawQuery = query.Encode()
+			req.RequestURI = redacted.RequestURI()
+		}
+		return next(ctx)
+	}
+}
+
+// CreateCalendarToken issues a new calendar token for the caller, revoking the previous one.
+func (controller *calendarsController) CreateCalendarToken(ctx echo.Context) error {
+	userResult, err := controller.authService.GetCurrentUser(ctx)
+	if err != nil {
+		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	}
+
+	token, err := controller.userService.CreateCalendarToken(&userResult)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to create calendar token.")
+	}
+
+	return ctx.JSON(http.StatusCreated, model.CalendarToken{
+		Token: token,
+		URL:   "/me/calendar.ics?" + calendarTokenParam + "=" + token,
+	})
+}
+
+func (controller *calendarsController) RevokeCalendarToken(ctx echo.Context) error {
+	userResult, err := controller.authService.GetCurrentUser(ctx)
+	if err != nil {
+		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	}
+
+	if err := controller.userService.RevokeCalendarToken(&userResult); err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to revoke calendar token.")
+	}
+
+	return ctx.JSON(http.StatusNoContent, nil)
+}
+
+// GetUserCalendar renders the deadlines of every board of the token's user. component=todo
+// renders them as to-dos rather than events.
+func (controller *calendarsController) GetUserCalendar(ctx echo.Context) error {
+	userResult, err := controller.calendarUser(ctx)
+	if err != nil {
+		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	}
+
+	tasks, err := controller.agendaService.GetCalendarTasks(&userResult, time.Now().Add(-calendarHistory), calendarTaskLimit)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "failed to get tasks.")
+	}
+
+	return writeCalendar(ctx, userResult.Username, tasks)
+}
+
+func (controller *calendarsController) GetBoardCalendar(ctx echo.Context) error {
+	boardRecord := currentBoard(ctx)
+
+	tasks, err := controller.agendaService.GetBoardCalendarTasks(&boardRecord, time.Now().Add(-calendarHistory), calendarTaskLimit)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "failed to get tasks.")
+	}
+
+	return writeCalendar(ctx, boardRecord.Name, tasks)
+}
+
+func (controller *calendarsController) calendarUser(ctx echo.Context) (model.User, error) {
+	return controller.userService.FindUserByCalendarToken(ctx.QueryParam(calendarTokenParam))
+}
+
+// writeCalendar renders the tasks as an RFC 5545 calendar. Each task keeps the UID derived
+// from its id across requests, so clients update their copy rather than adding another.
+func writeCalendar(ctx echo.Context, name string, tasks []model.ScheduledTask) error {
+	component := "VEVENT"
+	switch ctx.QueryParam("component") {
+	case "", "event":
+	case "todo":
+		component = "VTODO"
+	default:
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN", "VCALENDAR")
+	writeICSLine(&b, "VERSION", "2.0")
+	writeICSLine(&b, "PRODID", "-//todo//calendar//EN")
+	writeICSLine(&b, "CALSCALE", "GREGORIAN")
+	writeICSLine(&b, "X-WR-CALNAME", escapeICSText(name))
+
+	for _, task := range tasks {
+		// Completed tasks stay in to-do lists as done, but are no longer events ahead.
+		if task.DueTS == nil || task.Completed && component == "VEVENT" {
+			continue
+		}
+
+		stamp := task.ModifiedTS
+		if stamp.IsZero() {
+			stamp = task.CreatedTS
+		}
+
+		writeICSLine(&b, "BEGIN", component)
+		writeICSLine(&b, "UID", task.ID.Hex()+"@todo")
+		writeICSLine(&b, "DTSTAMP", stamp.UTC().Format(icsTimeFormat))
+		writeICSLine(&b, "LAST-MODIFIED", stamp.UTC().Format(icsTimeFormat))
+		writeICSLine(&b, "SEQUENCE", strconv.FormatInt(task.Version, 10))
+		if component == "VTODO" {
+			if task.StartTS != nil {
+				writeICSLine(&b, "DTSTART", task.StartTS.UTC().Format(icsTimeFormat))
+			}
+			writeICSLine(&b, "DUE", task.DueTS.UTC().Format(icsTimeFormat))
+			if task.Completed && task.CompletedTS != nil {
+				writeICSLine(&b, "STATUS", "COMPLETED")
+				writeICSLine(&b, "COMPLETED", task.CompletedTS.UTC().Format(icsTimeFormat))
+			}
+		} else {
+			writeICSLine(&b, "DTSTART", task.DueTS.UTC().Format(icsTimeFormat))
+		}
+		writeICSLine(&b, "SUMMARY", escapeICSText(task.Name))
+		if task.Content != "" {
+			writeICSLine(&b, "DESCRIPTION", escapeICSText(task.Content))
+		}
+		writeICSLine(&b, "CATEGORIES", escapeICSText(task.BoardName)+","+escapeICSText(task.ListName))
+		writeICSLine(&b, "END", component)
+	}
+	writeICSLine(&b, "END", "VCALENDAR")
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", exportFilename(name)+".ics"))
+	return ctx.Blob(http.StatusOK, "text/calendar; charset=utf-8", []byte(b.String()))
+}
+
+// escapeICSText escapes a TEXT value: backslashes, semicolons and commas are escaped, line
+// breaks of any kind become \n and other control characters but tabs are dropped.
+func escapeICSText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	s = strings.Map(func(r rune) rune {
+		if r < ' ' && r != '\t' && r != '\n' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// writeICSLine writes a content line folded after 75 octets, never inside a UTF-8 sequence.
+func writeICSLine(b *strings.Builder, name string, value string) {
+	line := name + ":" + value
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with the folding space.
+		limit = icsLineLimit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
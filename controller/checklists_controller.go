@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"todo/data"
+	"todo/model"
+	"todo/service"
+)
+
+type checklistsController struct {
+	checklistService service.ChecklistServiceInterface
+	authorizer       *boardAuthorizer
+}
+
+func ChecklistsController(checklistService service.ChecklistServiceInterface, authorizer *boardAuthorizer) *checklistsController {
+	return &checklistsController{checklistService, authorizer}
+}
+
+func (controller *checklistsController) RegisterChecklistsRoutes(e *echo.Echo) {
+	canView := controller.authorizer.require(taskRoute, model.BoardRoleViewer)
+	canEdit := controller.authorizer.require(taskRoute, model.BoardRoleEditor)
+
+	checklists := "/boards/:board_id/lists/:list_id/tasks/:id/checklists"
+	e.GET(checklists, controller.GetChecklists, canView)
+	e.POST(checklists, controller.AddChecklist, canEdit)
+	e.PUT(checklists+"/:checklist_id", controller.RenameChecklist, canEdit)
+	e.POST(checklists+"/:checklist_id/move", controller.MoveChecklist, canEdit)
+	e.DELETE(checklists+"/:checklist_id", controller.DeleteChecklist, canEdit)
+	e.POST(checklists+"/:checklist_id/items", controller.AddChecklistItem, canEdit)
+	e.PATCH(checklists+"/:checklist_id/items/:item_id", controller.UpdateChecklistItem, canEdit)
+	e.POST(checklists+"/:checklist_id/items/:item_id/move", controller.MoveChecklistItem, canEdit)
+	e.DELETE(checklists+"/:checklist_id/items/:item_id", controller.DeleteChecklistItem, canEdit)
+	fmt.Println("Registered /checklists routes.")
+}
+
+func (controller *checklistsController) GetChecklists(ctx echo.Context) error {
+	taskRecord := currentTask(ctx)
+	setETag(ctx, taskRecord.Version)
+
+	checklists := taskRecord.Checklists
+	if checklists == nil {
+		checklists = []model.Checklist{}
+	}
+	return ctx.JSON(http.StatusOK, checklists)
+}
+
+func (controller *checklistsController) AddChecklist(ctx echo.Context) error {
+	req, taskRecord, ok, err := controller.bindChecklistRequest(ctx)
+	if !ok {
+		return err
+	}
+
+	if req.Name == nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	resultTask, err := controller.checklistService.AddChecklist(taskRecord, *req.Name, requestedPosition(req.Position), requestActor(ctx))
+	return controller.checklistResponse(ctx, resultTask, err)
+}
+
+func (controller *checklistsController) RenameChecklist(ctx echo.Context) error {
+	req, taskRecord, ok, err := controller.bindChecklistRequest(ctx)
+	if !ok {
+		return err
+	}
+
+	checklistID, err := data.StringToObjectID(req.ChecklistID)
+	if err != nil || req.Name == nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	resultTask, err := controller.checklistService.RenameChecklist(taskRecord, checklistID, *req.Name, requestActor(ctx))
+	return controller.checklistResponse(ctx, resultTask, err)
+}
+
+func (controller *checklistsController) MoveChecklist(ctx echo.Context) error {
+	req, taskRecord, ok, err := controller.bindChecklistRequest(ctx)
+	if !ok {
+		return err
+	}
+
+	checklistID, err := data.StringToObjectID(req.ChecklistID)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	resultTask, err := controller.checklistService.MoveChecklist(taskRecord, checklistID, requestedPosition(req.Position), requestActor(ctx))
+	return controller.checklistResponse(ctx, resultTask, err)
+}
+
+func (controller *checklistsController) DeleteChecklist(ctx echo.Context) error {
+	req, taskRecord, ok, err := controller.bindChecklistRequest(ctx)
+	if !ok {
+		return err
+	}
+
+	checklistID, err := data.StringToObjectID(req.ChecklistID)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	resultTask, err := controller.checklistService.DeleteChecklist(taskRecord, checklistID, requestActor(ctx))
+	return controller.checklistResponse(ctx, resultTask, err)
+}
+
+func (controller *checklistsController) AddChecklistItem(ctx echo.Context) error {
+	req, taskRecord, ok, err := controller.bindChecklistRequest(ctx)
+	if !ok {
+		return err
+	}
+
+	checklistID, err := data.StringToObjectID(req.ChecklistID)
+	if err != nil || req.Name == nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	resultTask, err := controller.checklistService.AddChecklistItem(taskRecord, checklistID, *req.Name, requestedPosition(req.Position), requestActor(ctx))
+	return controller.checklistResponse(ctx, resultTask, err)
+}
+
+func (controller *checklistsController) UpdateChecklistItem(ctx echo.Context) error {
+	req, taskRecord, ok, err := controller.bindChecklistRequest(ctx)
+	if !ok {
+		return err
+	}
+
+	checklistID, itemID, err := checklistItemIds(req)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	resultTask, err := controller.checklistService.UpdateChecklistItem(taskRecord, checklistID, itemID, req.Name, req.Done, requestActor(ctx))
+	return controller.checklistResponse(ctx, resultTask, err)
+}
+
+func (controller *checklistsController) MoveChecklistItem(ctx echo.Context) error {
+	req, taskRecord, ok, err := controller.bindChecklistRequest(ctx)
+	if !ok {
+		return err
+	}
+
+	checklistID, itemID, err := checklistItemIds(req)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	resultTask, err := controller.checklistService.MoveChecklistItem(taskRecord, checklistID, itemID, requestedPosition(req.Position), requestActor(ctx))
+	return controller.checklistResponse(ctx, resultTask, err)
+}
+
+func (controller *checklistsController) DeleteChecklistItem(ctx echo.Context) error {
+	req, taskRecord, ok, err := controller.bindChecklistRequest(ctx)
+	if !ok {
+		return err
+	}
+
+	checklistID, itemID, err := checklistItemIds(req)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	resultTask, err := controller.checklistService.DeleteChecklistItem(taskRecord, checklistID, itemID, requestActor(ctx))
+	return controller.checklistResponse(ctx, resultTask, err)
+}
+
+// bindChecklistRequest binds the request and checks If-Match against the task. When it
+// reports false the response has already been written, and the error is that of writing it.
+func (controller *checklistsController) bindChecklistRequest(ctx echo.Context) (*model.ChecklistRequest, *model.Task, bool, error) {
+	var req model.ChecklistRequest
+	if err := ctx.Bind(&req); err != nil {
+		return nil, nil, false, ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	taskRecord := currentTask(ctx)
+	if !ifMatchSatisfied(ctx, taskRecord.Version) {
+		return nil, nil, false, ctx.String(http.StatusPreconditionFailed, "task has been modified.")
+	}
+
+	return &req, &taskRecord, true, nil
+}
+
+func (controller *checklistsController) checklistResponse(ctx echo.Context, resultTask *model.Task, err error) error {
+	switch {
+	case err == service.ErrChecklistNotFound, err == service.ErrChecklistItemNotFound:
+		return ctx.String(http.StatusNotFound, err.Error()+".")
+	case err == service.ErrInvalidChecklistName:
+		return ctx.String(http.StatusBadRequest, err.Error()+".")
+	case isVersionConflict(err):
+		return versionConflictResponse(ctx, "task")
+	case err != nil:
+		return ctx.String(http.StatusInternalServerError, "Failed to update checklists.")
+	}
+
+	setETag(ctx, resultTask.Version)
+	return ctx.JSON(http.StatusOK, resultTask)
+}
+
+func checklistItemIds(req *model.ChecklistRequest) (checklistID, itemID primitive.ObjectID, err error) {
+	if checklistID, err = data.StringToObjectID(req.ChecklistID); err != nil {
+		return
+	}
+	itemID, err = data.StringToObjectID(req.ItemID)
+	return
+}
+
+// requestedPosition turns an optional zero based position into the one used by the services, where
+// -1 means the end.
+func requestedPosition(requested *int) int {
+	if requested == nil {
+		return -1
+	}
+	return *requested
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo/model"
+	"todo/service"
+)
+
+// failingChecklistService fails the test on every call, for requests that must be
+// answered before they reach the service.
+type failingChecklistService struct {
+	service.ChecklistServiceInterface
+	t *testing.T
+}
+
+func (srv *failingChecklistService) AddChecklist(task *model.Task, name string, position int, actor model.Actor) (*model.Task, error) {
+	srv.t.Error("AddChecklist reached the service")
+	return task, nil
+}
+
+func newChecklistContext(body string, ifMatch string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if ifMatch != "" {
+		req.Header.Set(headerIfMatch, ifMatch)
+	}
+	rec := httptest.NewRecorder()
+
+	ctx := echo.New().NewContext(req, rec)
+	ctx.Set(currentTaskContextKey, model.Task{Version: 2})
+	return ctx, rec
+}
+
+func TestChecklistRequestsAreAnsweredOnce(t *testing.T) {
+	controller := ChecklistsController(&failingChecklistService{t: t}, nil)
+	handlers := map[string]echo.HandlerFunc{
+		"AddChecklist":        controller.AddChecklist,
+		"RenameChecklist":     controller.RenameChecklist,
+		"MoveChecklist":       controller.MoveChecklist,
+		"DeleteChecklist":     controller.DeleteChecklist,
+		"AddChecklistItem":    controller.AddChecklistItem,
+		"UpdateChecklistItem": controller.UpdateChecklistItem,
+		"MoveChecklistItem":   controller.MoveChecklistItem,
+		"DeleteChecklistItem": controller.DeleteChecklistItem,
+	}
+	tests := []struct {
+		name    string
+		body    string
+		ifMatch string
+		want    int
+	}{
+		{"stale If-Match", `{"name":"todo"}`, versionETag(1), http.StatusPreconditionFailed},
+		{"bad body", `{"name":`, "", http.StatusBadRequest},
+	}
+
+	for handlerName, handler := range handlers {
+		for _, test := range tests {
+			ctx, rec := newChecklistContext(test.body, test.ifMatch)
+			if err := handler(ctx); err != nil {
+				t.Errorf("%s with %s: %v", handlerName, test.name, err)
+			}
+			if rec.Code != test.want {
+				t.Errorf("%s with %s: status %d, want %d", handlerName, test.name, rec.Code, test.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"todo/model"
+	"todo/service"
+)
+
+var errCommentNotOnTask = errors.New("comment does not belong to the task")
+
+type commentsController struct {
+	commentService service.CommentServiceInterface
+	authorizer     *boardAuthorizer
+}
+
+func CommentsController(commentService service.CommentServiceInterface, authorizer *boardAuthorizer) *commentsController {
+	return &commentsController{commentService, authorizer}
+}
+
+func (controller *commentsController) RegisterCommentsRoutes(e *echo.Echo) {
+	canView := controller.authorizer.require(commentsRoute, model.BoardRoleViewer)
+	canEdit := controller.authorizer.require(commentsRoute, model.BoardRoleEditor)
+
+	e.GET("/boards/:board_id/lists/:list_id/tasks/:task_id/comments", controller.GetComments, canView)
+	e.GET("/boards/:board_id/lists/:list_id/tasks/:task_id/comments/:id", controller.FindCommentById, canView)
+	e.POST("/boards/:board_id/lists/:list_id/tasks/:task_id/comments", controller.CreateComment, canEdit)
+	e.PUT("/boards/:board_id/lists/:list_id/tasks/:task_id/comments/:id", controller.UpdateComment, canView)
+	e.DELETE("/boards/:board_id/lists/:list_id/tasks/:task_id/comments/:id", controller.DeleteComment, canView)
+	fmt.Println("Registered /comments routes.")
+}
+
+func (controller *commentsController) GetComments(ctx echo.Context) error {
+	results, err := controller.commentService.GetComments(currentTask(ctx).ID)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "failed to get comments.")
+	}
+
+	idVersions := make([]string, 0, len(results))
+	for _, comment := range results {
+		idVersions = append(idVersions, idVersion(comment.ID, comment.Version))
+	}
+	setCollectionETag(ctx, idVersions)
+
+	return ctx.JSON(http.StatusOK, results)
+}
+
+func (controller *commentsController) FindCommentById(ctx echo.Context) error {
+	var req, err = controller.bindCommentRequest(ctx)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	commentRecord, err := controller.findComment(ctx, req.ID)
+	if err != nil {
+		return ctx.String(http.StatusNotFound, "comment not found.")
+	}
+
+	setETag(ctx, commentRecord.Version)
+	return ctx.JSON(http.StatusOK, commentRecord)
+}
+
+func (controller *commentsController) CreateComment(ctx echo.Context) error {
+	var req, err = controller.bindCommentRequest(ctx)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	boardRecord := currentBoard(ctx)
+	taskRecord := currentTask(ctx)
+	resultComment, err := controller.commentService.CreateComment(&boardRecord, &taskRecord, req.Content, requestActor(ctx))
+
+	if err == service.ErrInvalidComment {
+		return ctx.String(http.StatusBadRequest, err.Error()+".")
+	}
+
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to create comment.")
+	}
+
+	setETag(ctx, resultComment.Version)
+	return ctx.JSON(http.StatusCreated, resultComment)
+}
+
+func (controller *commentsController) UpdateComment(ctx echo.Context) error {
+	var req, err = controller.bindCommentRequest(ctx)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	commentRecord, err := controller.findComment(ctx, req.ID)
+	if err != nil {
+		return ctx.String(http.StatusNotFound, "comment not found.")
+	}
+
+	if commentRecord.AuthorID != currentUser(ctx).ID {
+		return ctx.String(http.StatusForbidden, "only the author can edit a comment.")
+	}
+
+	if !ifMatchSatisfied(ctx, commentRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "comment has been modified.")
+	}
+
+	boardRecord := currentBoard(ctx)
+	resultComment, err := controller.commentService.UpdateComment(&boardRecord, &commentRecord, req.Content, requestActor(ctx))
+
+	if err == service.ErrInvalidComment {
+		return ctx.String(http.StatusBadRequest, err.Error()+".")
+	}
+
+	if isVersionConflict(err) {
+		return versionConflictResponse(ctx, "comment")
+	}
+
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to update comment.")
+	}
+
+	setETag(ctx, resultComment.Version)
+	return ctx.JSON(http.StatusOK, resultComment)
+}
+
+func (controller *commentsController) DeleteComment(ctx echo.Context) error {
+	var req, err = controller.bindCommentRequest(ctx)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	commentRecord, err := controller.findComment(ctx, req.ID)
+	if err != nil {
+		return ctx.String(http.StatusNotFound, "comment not found.")
+	}
+
+	if commentRecord.AuthorID != currentUser(ctx).ID {
+		return ctx.String(http.StatusForbidden, "only the author can delete a comment.")
+	}
+
+	boardRecord := currentBoard(ctx)
+	if err := controller.commentService.DeleteComment(&boardRecord, &commentRecord, requestActor(ctx)); err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to delete comment.")
+	}
+
+	return ctx.JSON(http.StatusNoContent, nil)
+}
+
+// findComment loads a comment of the current task.
+func (controller *commentsController) findComment(ctx echo.Context, id string) (model.Comment, error) {
+	commentRecord, err := controller.commentService.FindCommentById(id)
+	if err != nil {
+		return commentRecord, err
+	}
+
+	if commentRecord.TaskID != currentTask(ctx).ID {
+		return commentRecord, errCommentNotOnTask
+	}
+
+	return commentRecord, nil
+}
+
+func (controller *commentsController) bindCommentRequest(ctx echo.Context) (*model.CommentRequest, error) {
+	var req model.CommentRequest
+
+	err := ctx.Bind(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
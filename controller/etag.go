@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"todo/service"
+)
+
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+func versionETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+func setETag(ctx echo.Context, version int64) {
+	ctx.Response().Header().Set(headerETag, versionETag(version))
+}
+
+// setCollectionETag sets a weak ETag derived from the id and version of every record in
+// a collection response, so that it changes whenever any of the records changes.
+func setCollectionETag(ctx echo.Context, idVersions []string) {
+	hash := fnv.New64a()
+	for _, idVersion := range idVersions {
+		hash.Write([]byte(idVersion))
+		hash.Write([]byte{0})
+	}
+	ctx.Response().Header().Set(headerETag, fmt.Sprintf(`W/"%x"`, hash.Sum64()))
+}
+
+func idVersion(id primitive.ObjectID, version int64) string {
+	return id.Hex() + ":" + strconv.FormatInt(version, 10)
+}
+
+// ifMatchSatisfied reports whether the request's If-Match header, if any, matches the
+// current version of the record. Entity tags are compared strongly, so weak tags never match.
+func ifMatchSatisfied(ctx echo.Context, version int64) bool {
+	header := strings.TrimSpace(ctx.Request().Header.Get(headerIfMatch))
+	if header == "" || header == "*" {
+		return true
+	}
+
+	current := versionETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == current {
+			return true
+		}
+	}
+
+	return false
+}
+
+// versionConflictResponse answers a write that lost a race against another write. Clients
+// that sent If-Match get 412 as their precondition no longer holds, others get 409.
+func versionConflictResponse(ctx echo.Context, entity string) error {
+	if ctx.Request().Header.Get(headerIfMatch) != "" {
+		return ctx.String(http.StatusPreconditionFailed, entity+" has been modified.")
+	}
+	return ctx.String(http.StatusConflict, entity+" has been modified.")
+}
+
+func isVersionConflict(err error) bool {
+	return err == service.ErrVersionConflict
+}
@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"todo/model"
+	"todo/service"
+)
+
+const (
+	// Interval of the comments sent on idle event streams so that proxies keep them open.
+	eventStreamKeepAlive = 30 * time.Second
+	// Interval at which open event streams check that their user may still see the board.
+	eventStreamAccessCheck = 15 * time.Second
+)
+
+type eventsController struct {
+	activityService service.ActivityServiceInterface
+	boardService    service.BoardServiceInterface
+	authService     service.AuthServiceInterface
+	allowedOrigins  []string
+	authorizer      *boardAuthorizer
+}
+
+func EventsController(activityService service.ActivityServiceInterface, boardService service.BoardServiceInterface,
+	authService service.AuthServiceInterface, allowedOrigins []string, authorizer *boardAuthorizer) *eventsController {
+	return &eventsController{activityService, boardService, authService, allowedOrigins, authorizer}
+}
+
+func (controller *eventsController) RegisterEventsRoutes(e *echo.Echo) {
+	canView := controller.authorizer.require(boardRoute, model.BoardRoleViewer)
+
+	e.GET("/boards/:id/events", controller.StreamEvents, canView)
+	e.GET("/boards/:id/events/ws", controller.StreamEventsWebSocket, canView)
+	fmt.Println("Registered /events routes.")
+}
+
+// StreamEvents sends the changes of lists and tasks on the board as Server-Sent Events
+// until the client goes away or loses access to the board. Events missed while
+// disconnected are not replayed, clients reload the board when they reconnect.
+func (controller *eventsController) StreamEvents(ctx echo.Context) error {
+	boardRecord := currentBoard(ctx)
+	events, unsubscribe := controller.activityService.SubscribeBoardEvents(&boardRecord)
+	defer unsubscribe()
+
+	accessLost, stopWatching := controller.watchAccess(ctx)
+	defer stopWatching()
+
+	response := ctx.Response()
+	header := response.Header()
+	header.Set(echo.HeaderContentType, "text/event-stream")
+	header.Set(echo.HeaderCacheControl, "no-cache")
+	header.Set(echo.HeaderConnection, "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+	response.Flush()
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Request().Context().Done():
+			return nil
+		case <-accessLost:
+			return nil
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(response, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			payload, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(response, "event: %s\ndata: %s\n\n", event.Type, payload); err != nil {
+				return nil
+			}
+		}
+		response.Flush()
+	}
+}
+
+// StreamEventsWebSocket sends the same events as StreamEvents as JSON text messages over a
+// WebSocket. Messages from the client are ignored.
+func (controller *eventsController) StreamEventsWebSocket(ctx echo.Context) error {
+	boardRecord := currentBoard(ctx)
+
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			if !controller.allowedOrigin(config.Origin, req) {
+				return fmt.Errorf("origin %s is not allowed", config.Origin)
+			}
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+
+			events, unsubscribe := controller.activityService.SubscribeBoardEvents(&boardRecord)
+			defer unsubscribe()
+
+			accessLost, stopWatching := controller.watchAccess(ctx)
+			defer stopWatching()
+
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var discarded []byte
+				for websocket.Message.Receive(conn, &discarded) == nil {
+				}
+			}()
+
+			for {
+				select {
+				case <-closed:
+					return
+				case <-accessLost:
+					return
+				case event, ok := <-events:
+					if !ok || websocket.JSON.Send(conn, event) != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+
+	server.ServeHTTP(ctx.Response(), ctx.Request())
+	return nil
+}
+
+// allowedOrigin accepts WebSocket handshakes from pages of the API's own host and of the
+// configured origins. Browsers send the cookies of the API along with handshakes from any
+// page, so other origins are refused. Clients other than browsers send no origin.
+func (controller *eventsController) allowedOrigin(origin *url.URL, req *http.Request) bool {
+	if origin == nil {
+		return true
+	}
+
+	if origin.Host == req.Host {
+		return true
+	}
+
+	for _, allowed := range controller.allowedOrigins {
+		if strings.TrimSuffix(allowed, "/") == origin.Scheme+"://"+origin.Host {
+			return true
+		}
+	}
+	return false
+}
+
+// watchAccess returns a channel that is closed once the caller may no longer see the
+// board's events: when the access token expires or is revoked, or when the user lost
+// their role on the board. Revocation and roles are checked every eventStreamAccessCheck.
+// stop ends the watch and waits for it, so that the request is not used after the handler
+// returned.
+func (controller *eventsController) watchAccess(ctx echo.Context) (accessLost <-chan struct{}, stop func()) {
+	lost := make(chan struct{})
+	done := make(chan struct{})
+	token := ctx.Get("user").(*jwt.Token)
+	boardId := currentBoard(ctx).ID.Hex()
+
+	go func() {
+		defer close(lost)
+
+		expiry := time.NewTimer(time.Until(time.Unix(token.Claims.(*model.Claims).ExpiresAt, 0)))
+		defer expiry.Stop()
+		check := time.NewTicker(eventStreamAccessCheck)
+		defer check.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-expiry.C:
+				return
+			case <-check.C:
+				if !controller.hasAccess(ctx, token, boardId) {
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return lost, func() {
+		once.Do(func() { close(done) })
+		<-lost
+	}
+}
+
+func (controller *eventsController) hasAccess(ctx echo.Context, token *jwt.Token, boardId string) bool {
+	if _, err := controller.authService.ParseAccessToken(token.Raw, ctx); err != nil {
+		return false
+	}
+
+	userResult, err := controller.authService.GetCurrentUser(ctx)
+	if err != nil {
+		return false
+	}
+
+	boardRecord, err := controller.boardService.FindBoardById(boardId)
+	if err != nil {
+		return false
+	}
+
+	role := controller.boardService.GetBoardRole(&boardRecord, &userResult)
+	return role != "" && model.BoardRoleSatisfies(role, model.BoardRoleViewer)
+}
@@ -4,214 +4,203 @@ import (
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
-	"todo/data"
 	"todo/model"
 	"todo/service"
 )
 
 type listsController struct {
-	listService  service.ListServiceInterface
-	authService  service.AuthServiceInterface
-	boardService service.BoardServiceInterface
+	listService service.ListServiceInterface
+	authorizer  *boardAuthorizer
 }
 
-func ListsController(listService service.ListServiceInterface, authService service.AuthServiceInterface, boardService service.BoardServiceInterface) *listsController {
-	return &listsController{listService, authService, boardService}
+func ListsController(listService service.ListServiceInterface, authorizer *boardAuthorizer) *listsController {
+	return &listsController{listService, authorizer}
 }
 
 func (controller *listsController) RegisterListsRoutes(e *echo.Echo) {
-	e.GET("/boards/:board_id/lists", controller.GetLists)
-	e.GET("/boards/:board_id/lists/:id", controller.FindListById)
-	e.POST("/boards/:board_id/lists", controller.CreateList)
-	e.PUT("/boards/:board_id/lists/:id", controller.UpdateList)
-	e.DELETE("/boards/:board_id/lists/:id", controller.DeleteList)
+	canView := controller.authorizer.require(listsRoute, model.BoardRoleViewer)
+	canEdit := controller.authorizer.require(listsRoute, model.BoardRoleEditor)
+	canViewList := controller.authorizer.require(listRoute, model.BoardRoleViewer)
+	canEditList := controller.authorizer.require(listRoute, model.BoardRoleEditor)
+
+	e.GET("/boards/:board_id/lists", controller.GetLists, canView)
+	e.GET("/boards/:board_id/lists/:id", controller.FindListById, canViewList)
+	e.POST("/boards/:board_id/lists", controller.CreateList, canEdit)
+	e.PUT("/boards/:board_id/lists/:id", controller.UpdateList, canEditList)
+	e.PATCH("/boards/:board_id/lists/:id", controller.PatchList, canEditList)
+	e.DELETE("/boards/:board_id/lists/:id", controller.DeleteList, canEditList)
+	e.POST("/boards/:board_id/lists/:id/move", controller.MoveList, canEditList)
+	e.POST("/boards/:board_id/lists/:id/restore", controller.RestoreList, controller.authorizer.require(trashedListRoute, model.BoardRoleEditor))
 	fmt.Println("Registered /lists routes.")
 }
 
 func (controller *listsController) GetLists(ctx echo.Context) error {
-	var req, err = controller.bindListRequest(ctx)
-	if err != nil {
-		return ctx.String(http.StatusBadRequest, "bad request")
-	}
+	boardRecord := currentBoard(ctx)
 
-	userResult, err := controller.authService.GetCurrentUser(ctx)
+	page, err := bindPage(ctx, listSorts, "order")
 	if err != nil {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	boardRecord, err := controller.boardService.FindBoardById(req.BoardID)
-
+	results, nextCursor, err := controller.listService.GetListsPage(boardRecord.ID.Hex(), page)
 	if err != nil {
-		return ctx.String(http.StatusBadRequest, "board not found.")
+		return pageErrorResponse(ctx, err, "lists")
 	}
 
-	if boardRecord.OwnerID != userResult.ID && !userResult.IsAdmin {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
-	}
-
-	results, err := controller.listService.GetLists(req.BoardID)
-	if err != nil {
-		return ctx.String(http.StatusInternalServerError, "failed to get lists.")
+	idVersions := make([]string, 0, len(results))
+	for _, list := range results {
+		idVersions = append(idVersions, idVersion(list.ID, list.Version))
 	}
+	setCollectionETag(ctx, idVersions)
+	setNextPage(ctx, nextCursor)
 
 	return ctx.JSON(http.StatusOK, results)
 }
 
 func (controller *listsController) FindListById(ctx echo.Context) error {
-	var req, err = controller.bindListRequest(ctx)
-	if err != nil {
-		return ctx.String(http.StatusBadRequest, "bad request")
-	}
-
-	userResult, err := controller.authService.GetCurrentUser(ctx)
-	if err != nil {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
-	}
-
-	listRecord, err := controller.listService.FindListById(req.ID)
-	if err != nil || listRecord.BoardID.Hex() != req.BoardID {
-		return ctx.String(http.StatusBadRequest, "list not found.")
-	}
+	listRecord := currentList(ctx)
+	setETag(ctx, listRecord.Version)
+	return ctx.JSON(http.StatusOK, listRecord)
+}
 
-	boardRecord, err := controller.boardService.FindBoardById(listRecord.BoardID.Hex())
+func (controller *listsController) CreateList(ctx echo.Context) error {
+	var req, err = controller.bindListRequest(ctx)
 
 	if err != nil {
-		return ctx.String(http.StatusBadRequest, "board not found.")
+		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	if boardRecord.OwnerID != userResult.ID && !userResult.IsAdmin {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
-	}
+	var listRecord model.BoardList
+	listRecord.Name = service.TruncateName(req.Name)
+	listRecord.BoardID = currentBoard(ctx).ID
+	listRecord.Order = req.Order
+	listRecord.CompletesTasks = req.CompletesTasks
 
-	result, err := controller.listService.FindListById(req.ID)
+	resultList, insertErr := controller.listService.CreateList(&listRecord, requestActor(ctx))
 
-	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, err)
+	if insertErr != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to create list.")
 	}
 
-	return ctx.JSON(http.StatusOK, result)
+	return ctx.JSON(http.StatusOK, resultList)
 }
 
-func (controller *listsController) CreateList(ctx echo.Context) error {
+func (controller *listsController) UpdateList(ctx echo.Context) error {
 	var req, err = controller.bindListRequest(ctx)
 
 	if err != nil {
 		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	userResult, err := controller.authService.GetCurrentUser(ctx)
-	if err != nil {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	listRecord := currentList(ctx)
+	if !ifMatchSatisfied(ctx, listRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "list has been modified.")
 	}
 
-	boardResult, err := controller.boardService.FindBoardById(req.BoardID)
-
-	if err != nil || boardResult.ID.Hex() != req.BoardID {
-		return ctx.String(http.StatusBadRequest, "board not found.")
-	}
+	listRecord.Name = service.TruncateName(req.Name)
+	listRecord.Order = req.Order
+	listRecord.CompletesTasks = req.CompletesTasks
 
-	if boardResult.OwnerID != userResult.ID && !userResult.IsAdmin {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
-	}
+	resultList, updateErr := controller.listService.UpdateList(&listRecord, requestActor(ctx))
 
-	var listRecord model.BoardList
-	if len(listRecord.Name) > 100 {
-		listRecord.Name = req.Name[0:100]
-	} else {
-		listRecord.Name = req.Name
+	if isVersionConflict(updateErr) {
+		return versionConflictResponse(ctx, "list")
 	}
-	listRecord.BoardID = boardResult.ID
-	listRecord.Order = req.Order
 
-	resultBoard, insertErr := controller.listService.CreateList(&listRecord)
-
-	if insertErr != nil {
-		return ctx.String(http.StatusInternalServerError, "Failed to create list.")
+	if updateErr != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to update list.")
 	}
 
-	return ctx.JSON(http.StatusOK, resultBoard)
+	setETag(ctx, resultList.Version)
+	return ctx.JSON(http.StatusOK, resultList)
 }
 
-func (controller *listsController) UpdateList(ctx echo.Context) error {
-	var req, err = controller.bindListRequest(ctx)
-
+func (controller *listsController) PatchList(ctx echo.Context) error {
+	members, err := bindMergePatch(ctx)
 	if err != nil {
 		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	userResult, err := controller.authService.GetCurrentUser(ctx)
+	patch, err := listPatch(members)
 	if err != nil {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+		return ctx.String(http.StatusBadRequest, "bad request. "+err.Error()+".")
 	}
 
-	listRecord, err := controller.listService.FindListById(req.ID)
-	if err != nil || listRecord.BoardID.Hex() != req.BoardID {
-		return ctx.String(http.StatusBadRequest, "list not found.")
+	listRecord := currentList(ctx)
+	if !ifMatchSatisfied(ctx, listRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "list has been modified.")
 	}
 
-	boardRecord, err := controller.boardService.FindBoardById(listRecord.BoardID.Hex())
-
-	if err != nil {
-		return ctx.String(http.StatusBadRequest, "board not found.")
+	if patch.IsEmpty() {
+		setETag(ctx, listRecord.Version)
+		return ctx.JSON(http.StatusOK, listRecord)
 	}
 
-	if boardRecord.OwnerID != userResult.ID && !userResult.IsAdmin {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
-	}
+	resultList, patchErr := controller.listService.PatchList(&listRecord, patch, requestActor(ctx))
 
-	if len(listRecord.Name) > 100 {
-		listRecord.Name = req.Name[0:100]
-	} else {
-		listRecord.Name = req.Name
+	if isVersionConflict(patchErr) {
+		return versionConflictResponse(ctx, "list")
 	}
-	listRecord.Order = req.Order
 
-	resultList, updateErr := controller.listService.UpdateList(&listRecord)
-
-	if updateErr != nil {
+	if patchErr != nil {
 		return ctx.String(http.StatusInternalServerError, "Failed to update list.")
 	}
 
+	setETag(ctx, resultList.Version)
 	return ctx.JSON(http.StatusOK, resultList)
 }
 
 func (controller *listsController) DeleteList(ctx echo.Context) error {
-	req, err := controller.bindListRequest(ctx)
+	listRecord := currentList(ctx)
 
-	if err != nil {
-		return ctx.String(http.StatusBadRequest, "bad request")
-	}
+	deleteErr := controller.listService.DeleteList(&listRecord, requestActor(ctx))
 
-	listResult, err := controller.listService.FindListById(req.ID)
-	if err != nil || listResult.BoardID.Hex() != req.BoardID {
-		return ctx.String(http.StatusBadRequest, "list not found.")
+	if deleteErr != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to delete list.")
 	}
 
-	userResult, err := controller.authService.GetCurrentUser(ctx)
-	boardRecord, err := controller.boardService.FindBoardById(listResult.BoardID.Hex())
+	return ctx.JSON(http.StatusNoContent, nil)
+}
 
-	if err != nil {
-		return ctx.String(http.StatusBadRequest, "board not found.")
+func (controller *listsController) MoveList(ctx echo.Context) error {
+	var req model.MoveRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	if boardRecord.OwnerID != userResult.ID && !userResult.IsAdmin {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	listRecord := currentList(ctx)
+	if (req.BoardID != "" && req.BoardID != listRecord.BoardID.Hex()) || req.ListID != "" {
+		return ctx.String(http.StatusBadRequest, "lists can only be moved within their board.")
 	}
 
-	var listRecord model.BoardList
-	listRecord.ID, err = data.StringToObjectID(req.ID)
+	position := -1
+	if req.Position != nil {
+		position = *req.Position
+	}
 
+	resultList, err := controller.listService.MoveList(&listRecord, position, requestActor(ctx))
 	if err != nil {
-		return ctx.String(http.StatusBadRequest, "bad request")
+		return ctx.String(http.StatusInternalServerError, "Failed to move list.")
 	}
 
-	deleteErr := controller.listService.DeleteList(&listRecord)
+	return ctx.JSON(http.StatusOK, resultList)
+}
 
-	if deleteErr != nil {
-		return ctx.String(http.StatusInternalServerError, "Failed to delete list.")
+func (controller *listsController) RestoreList(ctx echo.Context) error {
+	listRecord := currentList(ctx)
+
+	restoreErr := controller.listService.RestoreList(&listRecord, requestActor(ctx))
+
+	if restoreErr != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to restore list.")
 	}
 
-	return ctx.JSON(http.StatusNoContent, nil)
+	resultList, err := controller.listService.FindListById(listRecord.ID.Hex())
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to restore list.")
+	}
+
+	return ctx.JSON(http.StatusOK, resultList)
 }
 
 func (controller *listsController) bindListRequest(ctx echo.Context) (*model.ListRequest, error) {
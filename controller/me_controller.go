@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"time"
+	"todo/model"
+	"todo/service"
+)
+
+type meController struct {
+	agendaService service.AgendaServiceInterface
+	authService   service.AuthServiceInterface
+}
+
+func MeController(agendaService service.AgendaServiceInterface, authService service.AuthServiceInterface) *meController {
+	return &meController{agendaService, authService}
+}
+
+func (controller *meController) RegisterMeRoutes(e *echo.Echo) {
+	e.GET("/me/tasks", controller.GetDueTasks)
+	e.GET("/me/assigned", controller.GetAssignedTasks)
+	fmt.Println("Registered /me routes.")
+}
+
+// GetDueTasks lists the caller's tasks that have a due date. overdue=true only keeps the
+// tasks due before now, due_before those due before the given RFC 3339 time.
+func (controller *meController) GetDueTasks(ctx echo.Context) error {
+	userResult, err := controller.authService.GetCurrentUser(ctx)
+	if err != nil {
+		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	}
+
+	req := model.DueTasksRequest{}
+	if err := (&echo.DefaultBinder{}).BindQueryParams(ctx, &req); err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	var dueBefore *time.Time
+	if req.DueBefore != "" {
+		t, err := time.Parse(time.RFC3339, req.DueBefore)
+		if err != nil {
+			return ctx.String(http.StatusBadRequest, "bad request")
+		}
+		dueBefore = &t
+	}
+
+	if req.Overdue {
+		now := time.Now()
+		if dueBefore == nil || now.Before(*dueBefore) {
+			dueBefore = &now
+		}
+	}
+
+	results, err := controller.agendaService.GetDueTasks(&userResult, dueBefore, pageLimit(req.Limit))
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "failed to get tasks.")
+	}
+
+	return ctx.JSON(http.StatusOK, results)
+}
+
+func (controller *meController) GetAssignedTasks(ctx echo.Context) error {
+	userResult, err := controller.authService.GetCurrentUser(ctx)
+	if err != nil {
+		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	}
+
+	limit, err := strconv.Atoi(ctx.QueryParam("limit"))
+	if err != nil && ctx.QueryParam("limit") != "" {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	results, err := controller.agendaService.GetAssignedTasks(&userResult, pageLimit(limit))
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "failed to get tasks.")
+	}
+
+	return ctx.JSON(http.StatusOK, results)
+}
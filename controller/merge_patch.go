@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"time"
+	"todo/model"
+	"todo/service"
+)
+
+var errPatchNotObject = errors.New("merge patch must be a JSON object")
+
+// bindMergePatch decodes the top level members of a JSON merge patch (RFC 7396) body. A
+// member holding null asks for the field to be removed.
+func bindMergePatch(ctx echo.Context) (map[string]json.RawMessage, error) {
+	body, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return nil, errPatchNotObject
+	}
+
+	return members, nil
+}
+
+func isNullPatchValue(value json.RawMessage) bool {
+	return string(value) == "null"
+}
+
+func unsupportedPatchField(name string) error {
+	return fmt.Errorf("field %s cannot be patched", name)
+}
+
+func invalidPatchField(name string) error {
+	return fmt.Errorf("invalid value for %s", name)
+}
+
+// patchName applies the length rule used when boards, lists and tasks are created.
+func patchName(patch *model.Patch, name string, value json.RawMessage) error {
+	if isNullPatchValue(value) {
+		patch.Unset = append(patch.Unset, name)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return invalidPatchField(name)
+	}
+
+	patch.Set[name] = service.TruncateName(s)
+	return nil
+}
+
+func patchString(patch *model.Patch, name string, value json.RawMessage) error {
+	if isNullPatchValue(value) {
+		patch.Unset = append(patch.Unset, name)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return invalidPatchField(name)
+	}
+
+	patch.Set[name] = s
+	return nil
+}
+
+func patchBool(patch *model.Patch, name string, value json.RawMessage) error {
+	if isNullPatchValue(value) {
+		patch.Unset = append(patch.Unset, name)
+		return nil
+	}
+
+	var b bool
+	if err := json.Unmarshal(value, &b); err != nil {
+		return invalidPatchField(name)
+	}
+
+	patch.Set[name] = b
+	return nil
+}
+
+func patchTime(patch *model.Patch, name string, value json.RawMessage) error {
+	if isNullPatchValue(value) {
+		patch.Unset = append(patch.Unset, name)
+		return nil
+	}
+
+	var t time.Time
+	if err := json.Unmarshal(value, &t); err != nil {
+		return invalidPatchField(name)
+	}
+
+	patch.Set[name] = t
+	return nil
+}
+
+func patchOrder(patch *model.Patch, name string, value json.RawMessage) error {
+	if isNullPatchValue(value) {
+		patch.Unset = append(patch.Unset, name)
+		return nil
+	}
+
+	var order int32
+	if err := json.Unmarshal(value, &order); err != nil {
+		return invalidPatchField(name)
+	}
+
+	patch.Set[name] = order
+	return nil
+}
+
+// patchRecurrence merges the members of a recurrence object into the recurrence of a task.
+// Its rule cannot be removed other than with the whole recurrence.
+func patchRecurrence(patch *model.Patch, name string, value json.RawMessage) error {
+	if isNullPatchValue(value) {
+		patch.Unset = append(patch.Unset, name)
+		return nil
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(value, &members); err != nil || members == nil {
+		return invalidPatchField(name)
+	}
+
+	for member, memberValue := range members {
+		field := name + "." + member
+		switch member {
+		case "rule":
+			if isNullPatchValue(memberValue) {
+				return invalidPatchField(field)
+			}
+			if err := patchString(patch, field, memberValue); err != nil {
+				return err
+			}
+		case "list_id":
+			if err := patchObjectID(patch, field, memberValue); err != nil {
+				return err
+			}
+		default:
+			return unsupportedPatchField(field)
+		}
+	}
+	return nil
+}
+
+func patchObjectID(patch *model.Patch, name string, value json.RawMessage) error {
+	if isNullPatchValue(value) {
+		patch.Unset = append(patch.Unset, name)
+		return nil
+	}
+
+	var id primitive.ObjectID
+	if err := json.Unmarshal(value, &id); err != nil || id.IsZero() {
+		return invalidPatchField(name)
+	}
+
+	patch.Set[name] = id
+	return nil
+}
+
+func boardPatch(members map[string]json.RawMessage) (*model.Patch, error) {
+	patch := model.NewPatch()
+	for name, value := range members {
+		var err error
+		switch name {
+		case "name":
+			err = patchName(patch, name, value)
+		case "is_template":
+			err = patchBool(patch, name, value)
+		default:
+			err = unsupportedPatchField(name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return patch, nil
+}
+
+func listPatch(members map[string]json.RawMessage) (*model.Patch, error) {
+	patch := model.NewPatch()
+	for name, value := range members {
+		var err error
+		switch name {
+		case "name":
+			err = patchName(patch, name, value)
+		case "order":
+			err = patchOrder(patch, name, value)
+		case "completes_tasks":
+			err = patchBool(patch, name, value)
+		default:
+			err = unsupportedPatchField(name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return patch, nil
+}
+
+func taskPatch(members map[string]json.RawMessage) (*model.Patch, error) {
+	patch := model.NewPatch()
+	for name, value := range members {
+		var err error
+		switch name {
+		case "name":
+			err = patchName(patch, name, value)
+		case "content":
+			err = patchString(patch, name, value)
+		case "order":
+			err = patchOrder(patch, name, value)
+		case "start_ts", "due_ts":
+			err = patchTime(patch, name, value)
+		case "recurrence":
+			err = patchRecurrence(patch, name, value)
+		default:
+			err = unsupportedPatchField(name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return patch, nil
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"todo/model"
+	"todo/service"
+)
+
+const headerNextCursor = "X-Next-Cursor"
+
+var errInvalidPage = errors.New("invalid page parameters")
+
+// Sort keys accepted in the sort query parameter, mapped to the bson field they order by.
+var (
+	boardSorts = map[string]string{
+		"created":  "created_ts",
+		"modified": "modified_ts",
+		"name":     "name",
+	}
+	listSorts = map[string]string{
+		"created":  "created_ts",
+		"modified": "modified_ts",
+		"name":     "name",
+		"order":    "order",
+	}
+	taskSorts = listSorts
+	userSorts = map[string]string{
+		"created":  "created_ts",
+		"name":     "name",
+		"username": "username",
+	}
+)
+
+// bindPage reads the limit, cursor, sort and filter query parameters. A sort key prefixed
+// with "-" sorts descending, an empty one falls back to defaultSort.
+func bindPage(ctx echo.Context, sorts map[string]string, defaultSort string) (*model.Page, error) {
+	req := model.PageRequest{}
+	if err := (&echo.DefaultBinder{}).BindQueryParams(ctx, &req); err != nil {
+		return nil, errInvalidPage
+	}
+
+	page := &model.Page{
+		Limit:        pageLimit(req.Limit),
+		Cursor:       req.Cursor,
+		NameContains: req.Name,
+	}
+
+	sort := req.Sort
+	if sort == "" {
+		sort = defaultSort
+	}
+	if strings.HasPrefix(sort, "-") {
+		page.Descending = true
+		sort = sort[1:]
+	}
+	field, ok := sorts[sort]
+	if !ok {
+		return nil, errInvalidPage
+	}
+	page.SortField = field
+
+	if req.ModifiedSince != "" {
+		modifiedSince, err := time.Parse(time.RFC3339, req.ModifiedSince)
+		if err != nil {
+			return nil, errInvalidPage
+		}
+		page.ModifiedSince = modifiedSince
+	}
+
+	return page, nil
+}
+
+// pageLimit applies the default and maximum page size to a requested limit.
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return model.DefaultPageLimit
+	}
+	if limit > model.MaxPageLimit {
+		return model.MaxPageLimit
+	}
+	return limit
+}
+
+func pageErrorResponse(ctx echo.Context, err error, entity string) error {
+	if err == service.ErrInvalidCursor {
+		return ctx.String(http.StatusBadRequest, "invalid cursor.")
+	}
+	return ctx.String(http.StatusInternalServerError, fmt.Sprintf("failed to get %s.", entity))
+}
+
+// setNextPage points the client at the next page through a Link header and the raw cursor,
+// keeping every other query parameter of the request. Nothing is set on the last page.
+func setNextPage(ctx echo.Context, nextCursor string) {
+	if nextCursor == "" {
+		return
+	}
+
+	query := ctx.Request().URL.Query()
+	query.Set("cursor", nextCursor)
+	next := url.URL{Path: ctx.Request().URL.Path, RawQuery: query.Encode()}
+
+	header := ctx.Response().Header()
+	header.Set("Link", "<"+next.String()+`>; rel="next"`)
+	header.Set(headerNextCursor, nextCursor)
+}
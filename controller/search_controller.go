@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+	"todo/model"
+	"todo/service"
+)
+
+type searchController struct {
+	searchService service.SearchServiceInterface
+	authService   service.AuthServiceInterface
+}
+
+func SearchController(searchService service.SearchServiceInterface, authService service.AuthServiceInterface) *searchController {
+	return &searchController{searchService, authService}
+}
+
+func (controller *searchController) RegisterSearchRoutes(e *echo.Echo) {
+	e.GET("/search", controller.Search)
+	fmt.Println("Registered /search routes.")
+}
+
+func (controller *searchController) Search(ctx echo.Context) error {
+	userResult, err := controller.authService.GetCurrentUser(ctx)
+	if err != nil {
+		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	}
+
+	req := model.SearchRequest{}
+	if err := (&echo.DefaultBinder{}).BindQueryParams(ctx, &req); err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Query == "" {
+		return ctx.String(http.StatusBadRequest, "search query is required.")
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = model.DefaultSearchLimit
+	}
+	if req.Limit > model.MaxSearchLimit {
+		req.Limit = model.MaxSearchLimit
+	}
+
+	results, err := controller.searchService.Search(&userResult, req.Query, req.Limit)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "failed to search.")
+	}
+
+	return ctx.JSON(http.StatusOK, results)
+}
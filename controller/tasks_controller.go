@@ -3,6 +3,7 @@ package controller
 import (
 	"fmt"
 	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"net/http"
 	"todo/data"
 	"todo/model"
@@ -11,230 +12,432 @@ import (
 
 type tasksController struct {
 	taskService  service.TaskServiceInterface
-	authService  service.AuthServiceInterface
 	boardService service.BoardServiceInterface
 	listService  service.ListServiceInterface
+	authorizer   *boardAuthorizer
 }
 
-func TasksController(taskService service.TaskServiceInterface, authService service.AuthServiceInterface,
-	boardService service.BoardServiceInterface, listService service.ListServiceInterface) *tasksController {
-	return &tasksController{taskService, authService, boardService, listService}
+func TasksController(taskService service.TaskServiceInterface, boardService service.BoardServiceInterface,
+	listService service.ListServiceInterface, authorizer *boardAuthorizer) *tasksController {
+	return &tasksController{taskService, boardService, listService, authorizer}
 }
 
 func (controller *tasksController) RegisterTasksRoutes(e *echo.Echo) {
-	e.GET("/boards/:board_id/lists/:list_id/tasks", controller.GetTasks)
-	e.GET("/boards/:board_id/lists/:list_id/tasks/:id", controller.FindTaskById)
-	e.POST("/boards/:board_id/lists/:list_id/tasks", controller.CreateTask)
-	e.PUT("/boards/:board_id/lists/:list_id/tasks/:id", controller.UpdateTask)
-	e.DELETE("/boards/:board_id/lists/:list_id/tasks/:id", controller.DeleteTask)
+	canView := controller.authorizer.require(tasksRoute, model.BoardRoleViewer)
+	canEdit := controller.authorizer.require(tasksRoute, model.BoardRoleEditor)
+	canViewTask := controller.authorizer.require(taskRoute, model.BoardRoleViewer)
+	canEditTask := controller.authorizer.require(taskRoute, model.BoardRoleEditor)
+
+	e.GET("/boards/:board_id/lists/:list_id/tasks", controller.GetTasks, canView)
+	e.GET("/boards/:board_id/lists/:list_id/tasks/:id", controller.FindTaskById, canViewTask)
+	e.POST("/boards/:board_id/lists/:list_id/tasks", controller.CreateTask, canEdit)
+	e.PUT("/boards/:board_id/lists/:list_id/tasks/:id", controller.UpdateTask, canEditTask)
+	e.PATCH("/boards/:board_id/lists/:list_id/tasks/:id", controller.PatchTask, canEditTask)
+	e.DELETE("/boards/:board_id/lists/:list_id/tasks/:id", controller.DeleteTask, canEditTask)
+	e.POST("/boards/:board_id/lists/:list_id/tasks/:id/move", controller.MoveTask, canEditTask)
+	e.PUT("/boards/:board_id/lists/:list_id/tasks/:id/completion", controller.SetTaskCompletion, canEditTask)
+	e.PUT("/boards/:board_id/lists/:list_id/tasks/:id/labels/:label_id", controller.AddTaskLabel, canEditTask)
+	e.DELETE("/boards/:board_id/lists/:list_id/tasks/:id/labels/:label_id", controller.RemoveTaskLabel, canEditTask)
+	e.PUT("/boards/:board_id/lists/:list_id/tasks/:id/assignees/:user_id", controller.AddTaskAssignee, canEditTask)
+	e.DELETE("/boards/:board_id/lists/:list_id/tasks/:id/assignees/:user_id", controller.RemoveTaskAssignee, canEditTask)
+	e.POST("/boards/:board_id/lists/:list_id/tasks/:id/restore", controller.RestoreTask, controller.authorizer.require(trashedTaskRoute, model.BoardRoleEditor))
 	fmt.Println("Registered /tasks routes.")
 }
 
 func (controller *tasksController) GetTasks(ctx echo.Context) error {
-	var req, err = controller.bindTaskRequest(ctx)
+	listRecord := currentList(ctx)
+
+	page, err := bindPage(ctx, taskSorts, "order")
 	if err != nil {
 		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	userResult, err := controller.authService.GetCurrentUser(ctx)
-	if err != nil {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	// Repeated label parameters only match tasks carrying all of the labels.
+	for _, label := range ctx.QueryParams()["label"] {
+		labelID, err := data.StringToObjectID(label)
+		if err != nil {
+			return ctx.String(http.StatusBadRequest, "bad request")
+		}
+		page.LabelIDs = append(page.LabelIDs, labelID)
 	}
 
-	boardRecord, err := controller.boardService.FindBoardById(req.BoardID)
-
-	if err != nil {
-		return ctx.String(http.StatusBadRequest, "board not found.")
+	for _, assignee := range ctx.QueryParams()["assignee"] {
+		assigneeID, err := data.StringToObjectID(assignee)
+		if err != nil {
+			return ctx.String(http.StatusBadRequest, "bad request")
+		}
+		page.AssigneeIDs = append(page.AssigneeIDs, assigneeID)
 	}
 
-	if boardRecord.OwnerID != userResult.ID && !userResult.IsAdmin {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	switch status := ctx.QueryParam("status"); status {
+	case "":
+	case "open", "completed":
+		completed := status == "completed"
+		page.Completed = &completed
+	default:
+		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	results, err := controller.taskService.GetTasks(req.ListID)
+	results, nextCursor, err := controller.taskService.GetTasksPage(listRecord.ID.Hex(), page)
 	if err != nil {
-		return ctx.String(http.StatusInternalServerError, "failed to get tasks.")
+		return pageErrorResponse(ctx, err, "tasks")
+	}
+
+	idVersions := make([]string, 0, len(results))
+	for _, task := range results {
+		idVersions = append(idVersions, idVersion(task.ID, task.Version))
 	}
+	setCollectionETag(ctx, idVersions)
+	setNextPage(ctx, nextCursor)
 
 	return ctx.JSON(http.StatusOK, results)
 }
 
 func (controller *tasksController) FindTaskById(ctx echo.Context) error {
+	taskRecord := currentTask(ctx)
+	setETag(ctx, taskRecord.Version)
+	return ctx.JSON(http.StatusOK, taskRecord)
+}
+
+func (controller *tasksController) CreateTask(ctx echo.Context) error {
 	var req, err = controller.bindTaskRequest(ctx)
+
 	if err != nil {
 		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	userResult, err := controller.authService.GetCurrentUser(ctx)
-	if err != nil {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	var taskRecord model.Task
+	taskRecord.Name = service.TruncateName(req.Name)
+	taskRecord.Content = req.Content
+	taskRecord.ListID = currentList(ctx).ID
+	taskRecord.Order = req.Order
+	taskRecord.StartTS = req.StartTS
+	taskRecord.DueTS = req.DueTS
+	taskRecord.Recurrence = requestedRecurrence(req.Recurrence, nil)
+
+	if taskRecord.Recurrence != nil && !controller.recurrenceListOnBoard(ctx, taskRecord.Recurrence.ListID) {
+		return ctx.String(http.StatusBadRequest, "recurrence list must be on the board of the task.")
 	}
 
-	listRecord, err := controller.listService.FindListById(req.ListID)
-	if err != nil || listRecord.BoardID.Hex() != req.BoardID {
-		return ctx.String(http.StatusBadRequest, "list not found.")
+	listRecord := currentList(ctx)
+	resultTask, insertErr := controller.taskService.CreateTask(&taskRecord, &listRecord, requestActor(ctx))
+
+	if insertErr == service.ErrInvalidTaskDates || insertErr == service.ErrInvalidRecurrence {
+		return ctx.String(http.StatusBadRequest, insertErr.Error()+".")
 	}
 
-	boardRecord, err := controller.boardService.FindBoardById(listRecord.BoardID.Hex())
+	if insertErr != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to create task.")
+	}
+
+	return ctx.JSON(http.StatusOK, resultTask)
+}
+
+func (controller *tasksController) UpdateTask(ctx echo.Context) error {
+	var req, err = controller.bindTaskRequest(ctx)
 
 	if err != nil {
-		return ctx.String(http.StatusBadRequest, "board not found.")
+		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	if boardRecord.OwnerID != userResult.ID && !userResult.IsAdmin {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	taskRecord := currentTask(ctx)
+	if !ifMatchSatisfied(ctx, taskRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "task has been modified.")
 	}
 
-	result, err := controller.taskService.FindTaskById(req.ID)
+	taskRecord.Name = service.TruncateName(req.Name)
+	taskRecord.Content = req.Content
+	taskRecord.Order = req.Order
+	taskRecord.StartTS = req.StartTS
+	taskRecord.DueTS = req.DueTS
+	taskRecord.Recurrence = requestedRecurrence(req.Recurrence, taskRecord.Recurrence)
 
-	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, err)
+	if taskRecord.Recurrence != nil && !controller.recurrenceListOnBoard(ctx, taskRecord.Recurrence.ListID) {
+		return ctx.String(http.StatusBadRequest, "recurrence list must be on the board of the task.")
 	}
 
-	return ctx.JSON(http.StatusOK, result)
-}
+	resultTask, updateErr := controller.taskService.UpdateTask(&taskRecord, requestActor(ctx))
 
-func (controller *tasksController) CreateTask(ctx echo.Context) error {
-	var req, err = controller.bindTaskRequest(ctx)
+	if updateErr == service.ErrInvalidTaskDates || updateErr == service.ErrInvalidRecurrence {
+		return ctx.String(http.StatusBadRequest, updateErr.Error()+".")
+	}
 
+	if isVersionConflict(updateErr) {
+		return versionConflictResponse(ctx, "task")
+	}
+
+	if updateErr != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to update task.")
+	}
+
+	setETag(ctx, resultTask.Version)
+	return ctx.JSON(http.StatusOK, resultTask)
+}
+
+func (controller *tasksController) PatchTask(ctx echo.Context) error {
+	members, err := bindMergePatch(ctx)
 	if err != nil {
 		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	userResult, err := controller.authService.GetCurrentUser(ctx)
+	patch, err := taskPatch(members)
 	if err != nil {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+		return ctx.String(http.StatusBadRequest, "bad request. "+err.Error()+".")
 	}
 
-	boardResult, err := controller.boardService.FindBoardById(req.BoardID)
-
-	if err != nil || boardResult.ID.Hex() != req.BoardID {
-		return ctx.String(http.StatusBadRequest, "board not found.")
+	taskRecord := currentTask(ctx)
+	if !ifMatchSatisfied(ctx, taskRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "task has been modified.")
 	}
 
-	if boardResult.OwnerID != userResult.ID && !userResult.IsAdmin {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	if listId, ok := patch.Set["recurrence.list_id"].(primitive.ObjectID); ok && !controller.recurrenceListOnBoard(ctx, listId) {
+		return ctx.String(http.StatusBadRequest, "recurrence list must be on the board of the task.")
 	}
 
-	listResult, err := controller.listService.FindListById(req.ListID)
-	if err != nil {
-		return ctx.String(http.StatusBadRequest, "list not found.")
+	if patch.IsEmpty() {
+		setETag(ctx, taskRecord.Version)
+		return ctx.JSON(http.StatusOK, taskRecord)
 	}
 
-	var taskRecord model.Task
-	if len(taskRecord.Name) > 100 {
-		taskRecord.Name = req.Name[0:100]
-	} else {
-		taskRecord.Name = req.Name
+	resultTask, patchErr := controller.taskService.PatchTask(&taskRecord, patch, requestActor(ctx))
+
+	if patchErr == service.ErrInvalidTaskDates || patchErr == service.ErrInvalidRecurrence {
+		return ctx.String(http.StatusBadRequest, patchErr.Error()+".")
 	}
-	taskRecord.Content = req.Content
-	taskRecord.ListID = listResult.ID
-	taskRecord.Order = req.Order
 
-	resultBoard, insertErr := controller.taskService.CreateTask(&taskRecord)
+	if isVersionConflict(patchErr) {
+		return versionConflictResponse(ctx, "task")
+	}
 
-	if insertErr != nil {
-		return ctx.String(http.StatusInternalServerError, "Failed to create list.")
+	if patchErr != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to update task.")
 	}
 
-	return ctx.JSON(http.StatusOK, resultBoard)
+	setETag(ctx, resultTask.Version)
+	return ctx.JSON(http.StatusOK, resultTask)
 }
 
-func (controller *tasksController) UpdateTask(ctx echo.Context) error {
-	var req, err = controller.bindTaskRequest(ctx)
+func (controller *tasksController) DeleteTask(ctx echo.Context) error {
+	taskRecord := currentTask(ctx)
 
-	if err != nil {
+	deleteErr := controller.taskService.DeleteTask(&taskRecord, requestActor(ctx))
+
+	if deleteErr != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to delete task.")
+	}
+
+	return ctx.JSON(http.StatusNoContent, nil)
+}
+
+// MoveTask moves a task to a position of another list, which defaults to the task's
+// current list and may belong to any board the user can edit.
+func (controller *tasksController) MoveTask(ctx echo.Context) error {
+	var req model.MoveRequest
+	if err := ctx.Bind(&req); err != nil {
 		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	userResult, err := controller.authService.GetCurrentUser(ctx)
-	if err != nil {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	boardRecord := currentBoard(ctx)
+	if req.BoardID != "" && req.BoardID != boardRecord.ID.Hex() {
+		var err error
+		boardRecord, err = controller.boardService.FindBoardById(req.BoardID)
+		if err != nil {
+			return ctx.String(http.StatusBadRequest, "board not found.")
+		}
+
+		userResult := currentUser(ctx)
+		if ok, err := authorizeBoardRole(ctx, controller.boardService.GetBoardRole(&boardRecord, &userResult), model.BoardRoleEditor); !ok {
+			return err
+		}
 	}
 
-	listRecord, err := controller.listService.FindListById(req.ListID)
-	if err != nil || listRecord.BoardID.Hex() != req.BoardID {
+	listRecord := currentList(ctx)
+	if req.ListID != "" && req.ListID != listRecord.ID.Hex() {
+		var err error
+		listRecord, err = controller.listService.FindListById(req.ListID)
+		if err != nil {
+			return ctx.String(http.StatusBadRequest, "list not found.")
+		}
+	}
+
+	if listRecord.BoardID != boardRecord.ID {
 		return ctx.String(http.StatusBadRequest, "list not found.")
 	}
 
-	boardRecord, err := controller.boardService.FindBoardById(listRecord.BoardID.Hex())
+	position := -1
+	if req.Position != nil {
+		position = *req.Position
+	}
 
+	taskRecord := currentTask(ctx)
+	resultTask, err := controller.taskService.MoveTask(&taskRecord, &boardRecord, &listRecord, position, requestActor(ctx))
 	if err != nil {
-		return ctx.String(http.StatusBadRequest, "board not found.")
+		return ctx.String(http.StatusInternalServerError, "Failed to move task.")
 	}
 
-	if boardRecord.OwnerID != userResult.ID && !userResult.IsAdmin {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
-	}
+	return ctx.JSON(http.StatusOK, resultTask)
+}
 
-	taskRecord, err := controller.taskService.FindTaskById(req.ID)
-	if err != nil {
-		return ctx.String(http.StatusBadRequest, "task not found.")
+// SetTaskCompletion marks the task completed by the caller, or open again.
+func (controller *tasksController) SetTaskCompletion(ctx echo.Context) error {
+	var req model.CompletionRequest
+	if err := ctx.Bind(&req); err != nil || req.Completed == nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	if len(taskRecord.Name) > 100 {
-		taskRecord.Name = req.Name[0:100]
-	} else {
-		taskRecord.Name = req.Name
+	taskRecord := currentTask(ctx)
+	if !ifMatchSatisfied(ctx, taskRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "task has been modified.")
 	}
-	taskRecord.Content = req.Content
-	taskRecord.Order = req.Order
 
-	resultTask, updateErr := controller.taskService.UpdateTask(&taskRecord)
+	resultTask, err := controller.taskService.SetTaskCompletion(&taskRecord, *req.Completed, requestActor(ctx))
 
-	if updateErr != nil {
+	if isVersionConflict(err) {
+		return versionConflictResponse(ctx, "task")
+	}
+
+	if err != nil {
 		return ctx.String(http.StatusInternalServerError, "Failed to update task.")
 	}
 
+	setETag(ctx, resultTask.Version)
 	return ctx.JSON(http.StatusOK, resultTask)
 }
 
-func (controller *tasksController) DeleteTask(ctx echo.Context) error {
-	req, err := controller.bindTaskRequest(ctx)
+func (controller *tasksController) RestoreTask(ctx echo.Context) error {
+	taskRecord := currentTask(ctx)
 
+	restoreErr := controller.taskService.RestoreTask(&taskRecord, requestActor(ctx))
+
+	if restoreErr != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to restore task.")
+	}
+
+	resultTask, err := controller.taskService.FindTaskById(taskRecord.ID.Hex())
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to restore task.")
+	}
+
+	return ctx.JSON(http.StatusOK, resultTask)
+}
+
+// recurrenceListOnBoard checks that the list a recurrence creates its occurrences in is on
+// the board of the task. A zero id stands for the task's own list.
+func (controller *tasksController) recurrenceListOnBoard(ctx echo.Context, listId primitive.ObjectID) bool {
+	if listId.IsZero() {
+		return true
+	}
+
+	listRecord, err := controller.listService.FindListById(listId.Hex())
+	return err == nil && listRecord.BoardID == currentBoard(ctx).ID
+}
+
+// requestedRecurrence takes the rule and list of the requested recurrence, keeping how far
+// the series of the task has come.
+func requestedRecurrence(req *model.Recurrence, current *model.Recurrence) *model.Recurrence {
+	if req == nil {
+		return nil
+	}
+
+	recurrence := model.Recurrence{Rule: req.Rule, ListID: req.ListID}
+	if current != nil {
+		recurrence.Occurrence = current.Occurrence
+		recurrence.SpawnedTS = current.SpawnedTS
+		recurrence.NextTaskID = current.NextTaskID
+	}
+	return &recurrence
+}
+
+func (controller *tasksController) bindTaskRequest(ctx echo.Context) (*model.TaskRequest, error) {
+	var req model.TaskRequest
+
+	err := ctx.Bind(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+func (controller *tasksController) AddTaskLabel(ctx echo.Context) error {
+	labelID, err := data.StringToObjectID(ctx.Param("label_id"))
 	if err != nil {
 		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	listResult, err := controller.listService.FindListById(req.ListID)
-	if err != nil || listResult.BoardID.Hex() != req.BoardID {
-		return ctx.String(http.StatusBadRequest, "list not found.")
+	boardRecord := currentBoard(ctx)
+	if service.FindBoardLabel(&boardRecord, labelID) < 0 {
+		return ctx.String(http.StatusNotFound, "label not found on this board.")
 	}
 
-	userResult, err := controller.authService.GetCurrentUser(ctx)
-	boardRecord, err := controller.boardService.FindBoardById(listResult.BoardID.Hex())
+	taskRecord := currentTask(ctx)
+	if !ifMatchSatisfied(ctx, taskRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "task has been modified.")
+	}
+
+	resultTask, err := controller.taskService.AddTaskLabel(&taskRecord, labelID, requestActor(ctx))
+	return controller.taskUpdateResponse(ctx, resultTask, err, "Failed to update task labels.")
+}
 
+func (controller *tasksController) RemoveTaskLabel(ctx echo.Context) error {
+	labelID, err := data.StringToObjectID(ctx.Param("label_id"))
 	if err != nil {
-		return ctx.String(http.StatusBadRequest, "board not found.")
+		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	if boardRecord.OwnerID != userResult.ID && !userResult.IsAdmin {
-		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	taskRecord := currentTask(ctx)
+	if !ifMatchSatisfied(ctx, taskRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "task has been modified.")
 	}
 
-	var taskRecord model.Task
-	taskRecord.ID, err = data.StringToObjectID(req.ID)
+	resultTask, err := controller.taskService.RemoveTaskLabel(&taskRecord, labelID, requestActor(ctx))
+	return controller.taskUpdateResponse(ctx, resultTask, err, "Failed to update task labels.")
+}
 
+func (controller *tasksController) AddTaskAssignee(ctx echo.Context) error {
+	userID, err := data.StringToObjectID(ctx.Param("user_id"))
 	if err != nil {
 		return ctx.String(http.StatusBadRequest, "bad request")
 	}
 
-	deleteErr := controller.taskService.DeleteTask(&taskRecord)
+	boardRecord := currentBoard(ctx)
+	if !service.IsBoardMember(&boardRecord, userID) {
+		return ctx.String(http.StatusBadRequest, "user is not a member of this board.")
+	}
 
-	if deleteErr != nil {
-		return ctx.String(http.StatusInternalServerError, "Failed to delete task.")
+	taskRecord := currentTask(ctx)
+	if !ifMatchSatisfied(ctx, taskRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "task has been modified.")
 	}
 
-	return ctx.JSON(http.StatusNoContent, nil)
+	resultTask, err := controller.taskService.AddTaskAssignee(&taskRecord, userID, requestActor(ctx))
+	return controller.taskUpdateResponse(ctx, resultTask, err, "Failed to update task assignees.")
 }
 
-func (controller *tasksController) bindTaskRequest(ctx echo.Context) (*model.TaskRequest, error) {
-	var req model.TaskRequest
+func (controller *tasksController) RemoveTaskAssignee(ctx echo.Context) error {
+	userID, err := data.StringToObjectID(ctx.Param("user_id"))
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	taskRecord := currentTask(ctx)
+	if !ifMatchSatisfied(ctx, taskRecord.Version) {
+		return ctx.String(http.StatusPreconditionFailed, "task has been modified.")
+	}
+
+	resultTask, err := controller.taskService.RemoveTaskAssignee(&taskRecord, userID, requestActor(ctx))
+	return controller.taskUpdateResponse(ctx, resultTask, err, "Failed to update task assignees.")
+}
+
+func (controller *tasksController) taskUpdateResponse(ctx echo.Context, resultTask *model.Task, err error, failure string) error {
+	if isVersionConflict(err) {
+		return versionConflictResponse(ctx, "task")
+	}
 
-	err := ctx.Bind(&req)
 	if err != nil {
-		return nil, err
+		return ctx.String(http.StatusInternalServerError, failure)
 	}
 
-	return &req, nil
+	setETag(ctx, resultTask.Version)
+	return ctx.JSON(http.StatusOK, resultTask)
 }
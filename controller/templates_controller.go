@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"todo/service"
+)
+
+type templatesController struct {
+	templateService service.TemplateServiceInterface
+	authService     service.AuthServiceInterface
+}
+
+func TemplatesController(templateService service.TemplateServiceInterface, authService service.AuthServiceInterface) *templatesController {
+	return &templatesController{templateService, authService}
+}
+
+func (controller *templatesController) RegisterTemplatesRoutes(e *echo.Echo) {
+	e.GET("/templates", controller.GetTemplates)
+	fmt.Println("Registered /templates routes.")
+}
+
+// GetTemplates lists the templates boards can be created from with POST /boards?template_id=.
+func (controller *templatesController) GetTemplates(ctx echo.Context) error {
+	userResult, err := controller.authService.GetCurrentUser(ctx)
+	if err != nil {
+		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	}
+
+	results, err := controller.templateService.GetTemplates(&userResult)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "failed to get templates.")
+	}
+
+	return ctx.JSON(http.StatusOK, results)
+}
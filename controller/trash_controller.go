@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"todo/service"
+)
+
+type trashController struct {
+	trashService service.TrashServiceInterface
+	authService  service.AuthServiceInterface
+}
+
+func TrashController(trashService service.TrashServiceInterface, authService service.AuthServiceInterface) *trashController {
+	return &trashController{trashService, authService}
+}
+
+func (controller *trashController) RegisterTrashRoutes(e *echo.Echo) {
+	e.GET("/trash", controller.GetTrash)
+	fmt.Println("Registered /trash routes.")
+}
+
+func (controller *trashController) GetTrash(ctx echo.Context) error {
+	userResult, err := controller.authService.GetCurrentUser(ctx)
+	if err != nil {
+		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	}
+
+	result, err := controller.trashService.GetTrash(&userResult)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "failed to get trash.")
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"golang.org/x/crypto/bcrypt"
@@ -12,6 +14,8 @@ import (
 	"todo/service"
 )
 
+var errAdminOnlyField = errors.New("only admins can change is_admin")
+
 type usersController struct {
 	userService service.UserServiceInterface
 	authService service.AuthServiceInterface
@@ -25,6 +29,7 @@ func (controller *usersController) RegisterUserRoutes(e *echo.Echo) {
 	e.GET("/users", controller.GetUsers)
 	e.GET("/users/:id", controller.FindUserById)
 	e.POST("/users", controller.CreateUser)
+	e.PATCH("/users/:id", controller.PatchUser)
 	e.DELETE("/users/:id", controller.DeleteUser)
 	fmt.Println("Registered /users routes.")
 }
@@ -35,10 +40,16 @@ func (controller *usersController) GetUsers(ctx echo.Context) error {
 		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
 	}
 
-	results, err := controller.userService.GetUsers()
+	page, err := bindPage(ctx, userSorts, "created")
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	results, nextCursor, err := controller.userService.GetUsersPage(page)
 	if err != nil {
-		return ctx.String(http.StatusInternalServerError, "failed to get users.")
+		return pageErrorResponse(ctx, err, "users")
 	}
+	setNextPage(ctx, nextCursor)
 
 	return ctx.JSON(http.StatusOK, results)
 }
@@ -138,6 +149,96 @@ func (controller *usersController) CreateUser(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, resultUser)
 }
 
+func (controller *usersController) PatchUser(ctx echo.Context) error {
+	reqObjectID, err := data.StringToObjectID(ctx.Param("id"))
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	userResult, err := controller.authService.GetCurrentUser(ctx)
+	if err != nil || (reqObjectID != userResult.ID && !userResult.IsAdmin) {
+		return ctx.String(http.StatusUnauthorized, "user is not authorized.")
+	}
+
+	members, err := bindMergePatch(ctx)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	userRecord, err := controller.userService.FindUserById(reqObjectID.Hex())
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "user not found.")
+	}
+
+	patch, err := controller.userPatch(members, &userRecord, userResult.IsAdmin)
+	if err == errAdminOnlyField {
+		return ctx.String(http.StatusForbidden, err.Error()+".")
+	}
+
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request. "+err.Error()+".")
+	}
+
+	resultUser := &userRecord
+	if !patch.IsEmpty() {
+		resultUser, err = controller.userService.PatchUser(&userRecord, patch)
+		if err != nil {
+			return ctx.String(http.StatusInternalServerError, "Failed to update user.")
+		}
+	}
+
+	controller.userService.ScrubUserForAPI(resultUser)
+	return ctx.JSON(http.StatusOK, resultUser)
+}
+
+// userPatch validates a merge patch of a user with the same rules CreateUser applies.
+func (controller *usersController) userPatch(members map[string]json.RawMessage, user *model.User, isAdmin bool) (*model.Patch, error) {
+	patch := model.NewPatch()
+	for name, value := range members {
+		switch name {
+		case "name":
+			var userName string
+			if !isNullPatchValue(value) && json.Unmarshal(value, &userName) != nil {
+				return nil, invalidPatchField(name)
+			}
+
+			userName = strings.TrimSpace(strings.ToLower(userName))
+			if userName == "" {
+				userName = user.Username
+			}
+
+			if len(userName) > 40 {
+				userName = userName[0:40]
+			}
+			patch.Set["name"] = userName
+		case "email":
+			var email string
+			if isNullPatchValue(value) || json.Unmarshal(value, &email) != nil {
+				return nil, invalidPatchField(name)
+			}
+
+			email = strings.TrimSpace(strings.ToLower(email))
+			if _, err := mail.ParseAddress(email); err != nil {
+				return nil, invalidPatchField(name)
+			}
+			patch.Set["email"] = email
+		case "is_admin":
+			if !isAdmin {
+				return nil, errAdminOnlyField
+			}
+
+			var admin bool
+			if !isNullPatchValue(value) && json.Unmarshal(value, &admin) != nil {
+				return nil, invalidPatchField(name)
+			}
+			patch.Set["is_admin"] = admin
+		default:
+			return nil, unsupportedPatchField(name)
+		}
+	}
+	return patch, nil
+}
+
 func (controller *usersController) DeleteUser(ctx echo.Context) error {
 	userResult, err := controller.authService.GetCurrentUser(ctx)
 	if err != nil || !userResult.IsAdmin {
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"todo/model"
+	"todo/service"
+)
+
+var errWebhookNotOnBoard = errors.New("webhook does not belong to the board")
+
+var deliverySorts = map[string]string{
+	"created": "created_ts",
+}
+
+type webhooksController struct {
+	webhookService service.WebhookServiceInterface
+	authorizer     *boardAuthorizer
+}
+
+func WebhooksController(webhookService service.WebhookServiceInterface, authorizer *boardAuthorizer) *webhooksController {
+	return &webhooksController{webhookService, authorizer}
+}
+
+func (controller *webhooksController) RegisterWebhooksRoutes(e *echo.Echo) {
+	canManage := controller.authorizer.require(boardRoute, model.BoardRoleOwner)
+
+	e.GET("/boards/:id/webhooks", controller.GetWebhooks, canManage)
+	e.POST("/boards/:id/webhooks", controller.CreateWebhook, canManage)
+	e.GET("/boards/:id/webhooks/:webhook_id", controller.FindWebhookById, canManage)
+	e.PUT("/boards/:id/webhooks/:webhook_id", controller.UpdateWebhook, canManage)
+	e.DELETE("/boards/:id/webhooks/:webhook_id", controller.DeleteWebhook, canManage)
+	e.GET("/boards/:id/webhooks/:webhook_id/deliveries", controller.GetDeliveries, canManage)
+	fmt.Println("Registered /webhooks routes.")
+}
+
+func (controller *webhooksController) GetWebhooks(ctx echo.Context) error {
+	boardRecord := currentBoard(ctx)
+	results, err := controller.webhookService.GetWebhooks(&boardRecord)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "failed to get webhooks.")
+	}
+
+	if results == nil {
+		results = []model.Webhook{}
+	}
+	return ctx.JSON(http.StatusOK, results)
+}
+
+func (controller *webhooksController) FindWebhookById(ctx echo.Context) error {
+	webhookRecord, err := controller.findWebhook(ctx, ctx.Param("webhook_id"))
+	if err != nil {
+		return ctx.String(http.StatusNotFound, "webhook not found.")
+	}
+
+	return ctx.JSON(http.StatusOK, webhookRecord)
+}
+
+func (controller *webhooksController) CreateWebhook(ctx echo.Context) error {
+	var req, err = controller.bindWebhookRequest(ctx)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	boardRecord := currentBoard(ctx)
+	resultWebhook, err := controller.webhookService.CreateWebhook(&boardRecord, req.URL, req.Events)
+	if err != nil {
+		return controller.webhookErrorResponse(ctx, err, "Failed to create webhook.")
+	}
+
+	return ctx.JSON(http.StatusCreated, resultWebhook)
+}
+
+func (controller *webhooksController) UpdateWebhook(ctx echo.Context) error {
+	var req, err = controller.bindWebhookRequest(ctx)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	webhookRecord, err := controller.findWebhook(ctx, req.WebhookID)
+	if err != nil {
+		return ctx.String(http.StatusNotFound, "webhook not found.")
+	}
+
+	resultWebhook, err := controller.webhookService.UpdateWebhook(&webhookRecord, req.URL, req.Events, req.Active)
+	if err != nil {
+		return controller.webhookErrorResponse(ctx, err, "Failed to update webhook.")
+	}
+
+	return ctx.JSON(http.StatusOK, resultWebhook)
+}
+
+func (controller *webhooksController) DeleteWebhook(ctx echo.Context) error {
+	webhookRecord, err := controller.findWebhook(ctx, ctx.Param("webhook_id"))
+	if err != nil {
+		return ctx.String(http.StatusNotFound, "webhook not found.")
+	}
+
+	if err := controller.webhookService.DeleteWebhook(&webhookRecord); err != nil {
+		return ctx.String(http.StatusInternalServerError, "Failed to delete webhook.")
+	}
+
+	return ctx.JSON(http.StatusNoContent, nil)
+}
+
+// GetDeliveries pages through the delivery log of a webhook, newest first by default.
+func (controller *webhooksController) GetDeliveries(ctx echo.Context) error {
+	webhookRecord, err := controller.findWebhook(ctx, ctx.Param("webhook_id"))
+	if err != nil {
+		return ctx.String(http.StatusNotFound, "webhook not found.")
+	}
+
+	page, err := bindPage(ctx, deliverySorts, "-created")
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "bad request")
+	}
+
+	results, nextCursor, err := controller.webhookService.GetDeliveriesPage(&webhookRecord, page)
+	if err != nil {
+		return pageErrorResponse(ctx, err, "webhook deliveries")
+	}
+
+	setNextPage(ctx, nextCursor)
+	return ctx.JSON(http.StatusOK, results)
+}
+
+// findWebhook loads a webhook of the current board.
+func (controller *webhooksController) findWebhook(ctx echo.Context, id string) (model.Webhook, error) {
+	webhookRecord, err := controller.webhookService.FindWebhookById(id)
+	if err != nil {
+		return webhookRecord, err
+	}
+
+	if webhookRecord.BoardID != currentBoard(ctx).ID {
+		return webhookRecord, errWebhookNotOnBoard
+	}
+
+	return webhookRecord, nil
+}
+
+func (controller *webhooksController) webhookErrorResponse(ctx echo.Context, err error, failure string) error {
+	switch err {
+	case service.ErrInvalidWebhookURL, service.ErrInvalidWebhookEvents:
+		return ctx.String(http.StatusBadRequest, err.Error()+".")
+	}
+	return ctx.String(http.StatusInternalServerError, failure)
+}
+
+func (controller *webhooksController) bindWebhookRequest(ctx echo.Context) (*model.WebhookRequest, error) {
+	var req model.WebhookRequest
+
+	err := ctx.Bind(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
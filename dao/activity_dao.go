@@ -0,0 +1,77 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+	"todo/data"
+	"todo/model"
+)
+
+type activityDao struct {
+	databaseProvider data.MongoDBProviderInterface
+}
+
+type ActivityDaoInterface interface {
+	CreateActivity(activity *model.Activity) error
+	DeleteActivitiesByBoardIds(boardIds []primitive.ObjectID) error
+	GetActivitiesPage(boardId primitive.ObjectID, page *model.Page) ([]model.Activity, string, error)
+}
+
+func ActivityDao(databaseProvider data.MongoDBProviderInterface) *activityDao {
+	return &activityDao{databaseProvider}
+}
+
+func (dao *activityDao) CreateActivity(activity *model.Activity) error {
+	_, err := dao.databaseProvider.GetActivitiesCollection().InsertOne(dao.databaseProvider.GetContext(), activity)
+	return err
+}
+
+func (dao *activityDao) DeleteActivitiesByBoardIds(boardIds []primitive.ObjectID) error {
+	if len(boardIds) == 0 {
+		return nil
+	}
+
+	_, err := dao.databaseProvider.GetActivitiesCollection().DeleteMany(dao.databaseProvider.GetContext(), bson.M{"board_id": bson.M{"$in": boardIds}})
+	return err
+}
+
+// GetActivitiesPage returns one page of the activity of a board along with the cursor of
+// the next page, which is empty on the last page.
+func (dao *activityDao) GetActivitiesPage(boardId primitive.ObjectID, page *model.Page) ([]model.Activity, string, error) {
+	filter, opts, err := paginate(bson.M{"board_id": boardId}, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	results, err := dao.findActivities(filter, opts)
+	if err != nil || len(results) <= page.Limit {
+		return results, "", err
+	}
+
+	results = results[:page.Limit]
+	cursor, err := nextPageCursor(dao.databaseProvider.GetActivitiesCollection(), page, results[len(results)-1].ID)
+	return results, cursor, err
+}
+
+func (dao *activityDao) findActivities(filter bson.M, opts ...*options.FindOptions) ([]model.Activity, error) {
+	var results []model.Activity
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	cursor, err := dao.databaseProvider.GetActivitiesCollection().Find(ctx, filter, opts...)
+	if err != nil {
+		fmt.Println("Finding all activities ERROR:", err)
+		return results, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
 	"time"
 	"todo/data"
@@ -18,10 +19,21 @@ type boardDao struct {
 type BoardDaoInterface interface {
 	CreateBoard(board *model.Board) (*model.Board, error)
 	DeleteBoard(board *model.Board) error
+	DeleteBoardsByIds(boardIds []primitive.ObjectID) error
+	TrashBoard(board *model.Board, deletedTS time.Time) error
+	RestoreBoard(board *model.Board) error
+	RemoveMemberFromBoards(userId primitive.ObjectID) error
+	PatchBoard(board *model.Board, patch *model.Patch) (*model.Board, error)
 	UpdateBoard(board *model.Board) (*model.Board, error)
 	FindBoardById(id string) (model.Board, error)
+	FindTrashedBoardById(id string) (model.Board, error)
 	FindBoardByUserId(username string) (model.Board, error)
 	GetBoards(userId string) ([]model.Board, error)
+	GetBoardsPage(userId string, page *model.Page) ([]model.Board, string, error)
+	GetTrashedBoards(userId string) ([]model.Board, error)
+	GetBoardIdsByOwnerId(userId primitive.ObjectID) ([]primitive.ObjectID, error)
+	GetTrashedBoardIds(before time.Time) ([]primitive.ObjectID, error)
+	SearchBoards(boardIds []primitive.ObjectID, text string, limit int) ([]model.SearchHit, error)
 }
 
 func BoardDao(databaseProvider data.MongoDBProviderInterface) *boardDao {
@@ -39,19 +51,71 @@ func (dao *boardDao) DeleteBoard(board *model.Board) error {
 	return err
 }
 
+func (dao *boardDao) DeleteBoardsByIds(boardIds []primitive.ObjectID) error {
+	if len(boardIds) == 0 {
+		return nil
+	}
+
+	_, err := dao.databaseProvider.GetBoardsCollection().DeleteMany(dao.databaseProvider.GetContext(), bson.M{"_id": bson.M{"$in": boardIds}})
+	return err
+}
+
+func (dao *boardDao) TrashBoard(board *model.Board, deletedTS time.Time) error {
+	_, err := dao.databaseProvider.GetBoardsCollection().UpdateOne(dao.databaseProvider.GetContext(),
+		notTrashed(bson.M{"_id": board.ID}), trashUpdate(deletedTS))
+	return err
+}
+
+func (dao *boardDao) RestoreBoard(board *model.Board) error {
+	_, err := dao.databaseProvider.GetBoardsCollection().UpdateOne(dao.databaseProvider.GetContext(),
+		bson.M{"_id": board.ID}, restoreUpdate())
+	return err
+}
+
+func (dao *boardDao) RemoveMemberFromBoards(userId primitive.ObjectID) error {
+	_, err := dao.databaseProvider.GetBoardsCollection().UpdateMany(dao.databaseProvider.GetContext(),
+		bson.M{"members.user_id": userId},
+		incrementVersion(bson.M{"$pull": bson.M{"members": bson.M{"user_id": userId}}}))
+	return err
+}
+
+// PatchBoard applies the patch to the version of the record that was read by the caller.
+func (dao *boardDao) PatchBoard(board *model.Board, patch *model.Patch) (*model.Board, error) {
+	updateResult, err := dao.databaseProvider.GetBoardsCollection().UpdateOne(context.Background(),
+		versioned(notTrashed(bson.M{"_id": board.ID}), board.Version), incrementVersion(patchUpdate(patch)))
+	if err == nil && updateResult.MatchedCount == 0 {
+		err = ErrVersionConflict
+	}
+	result, _ := dao.FindBoardById(board.ID.Hex())
+	return &result, err
+}
+
 func (dao *boardDao) UpdateBoard(board *model.Board) (*model.Board, error) {
-	_, err := dao.databaseProvider.GetBoardsCollection().ReplaceOne(context.Background(), bson.M{"_id": board.ID}, board)
+	expectedVersion := board.Version
+	board.Version = expectedVersion + 1
+	updateResult, err := dao.databaseProvider.GetBoardsCollection().ReplaceOne(context.Background(), versioned(notTrashed(bson.M{"_id": board.ID}), expectedVersion), board)
+	if err == nil && updateResult.MatchedCount == 0 {
+		err = ErrVersionConflict
+	}
 	result, _ := dao.FindBoardById(board.ID.Hex())
 	return &result, err
 }
 
 func (dao *boardDao) FindBoardById(id string) (model.Board, error) {
+	return dao.findBoard(id, notTrashed)
+}
+
+func (dao *boardDao) FindTrashedBoardById(id string) (model.Board, error) {
+	return dao.findBoard(id, trashed)
+}
+
+func (dao *boardDao) findBoard(id string, scope func(bson.M) bson.M) (model.Board, error) {
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		log.Println("Invalid id")
 	}
 
-	result := dao.databaseProvider.GetBoardsCollection().FindOne(context.Background(), bson.M{"_id": objectId})
+	result := dao.databaseProvider.GetBoardsCollection().FindOne(context.Background(), scope(bson.M{"_id": objectId}))
 	resultBoard := model.Board{}
 	err = result.Decode(&resultBoard)
 	if err != nil {
@@ -62,7 +126,7 @@ func (dao *boardDao) FindBoardById(id string) (model.Board, error) {
 }
 
 func (dao *boardDao) FindBoardByUserId(userId string) (model.Board, error) {
-	result := dao.databaseProvider.GetBoardsCollection().FindOne(context.Background(), bson.M{"owner_id": userId})
+	result := dao.databaseProvider.GetBoardsCollection().FindOne(context.Background(), notTrashed(bson.M{"owner_id": userId}))
 	resultBoard := model.Board{}
 	err := result.Decode(&resultBoard)
 	if err != nil {
@@ -73,25 +137,110 @@ func (dao *boardDao) FindBoardByUserId(userId string) (model.Board, error) {
 }
 
 func (dao *boardDao) GetBoards(userId string) ([]model.Board, error) {
-	fmt.Println("Finding boards owned by " + userId)
+	fmt.Println("Finding boards shared with " + userId)
+	return dao.findBoards(notTrashed(memberFilter(userId)))
+}
+
+func (dao *boardDao) GetTrashedBoards(userId string) ([]model.Board, error) {
+	return dao.findBoards(trashed(memberFilter(userId)))
+}
+
+func memberFilter(userId string) bson.M {
 	objectId, err := primitive.ObjectIDFromHex(userId)
 	if err != nil {
 		log.Println("Invalid id")
 	}
 
+	return bson.M{"$or": bson.A{
+		bson.M{"owner_id": objectId},
+		bson.M{"members.user_id": objectId},
+	}}
+}
+
+func (dao *boardDao) findBoards(filter bson.M, opts ...*options.FindOptions) ([]model.Board, error) {
 	var results []model.Board
-	ctx, _ := context.WithTimeout(context.Background(), 15*time.Second)
-	cursor, err := dao.databaseProvider.GetBoardsCollection().Find(context.Background(), bson.M{"owner_id": objectId})
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	cursor, err := dao.databaseProvider.GetBoardsCollection().Find(ctx, filter, opts...)
 	if err != nil {
 		fmt.Println("Finding all boards ERROR:", err)
-		defer cursor.Close(ctx)
+		return results, err
 	}
+	defer cursor.Close(ctx)
 
 	err = cursor.All(ctx, &results)
 	if err != nil {
-		defer cursor.Close(ctx)
 		return results, err
 	}
 
 	return results, nil
 }
+
+// GetBoardIdsByOwnerId returns the ids of every board owned by the user, trashed or not.
+func (dao *boardDao) GetBoardIdsByOwnerId(userId primitive.ObjectID) ([]primitive.ObjectID, error) {
+	values, err := dao.databaseProvider.GetBoardsCollection().Distinct(dao.databaseProvider.GetContext(), "_id", bson.M{"owner_id": userId})
+	if err != nil {
+		return nil, err
+	}
+
+	return toObjectIds(values), nil
+}
+
+func (dao *boardDao) GetTrashedBoardIds(before time.Time) ([]primitive.ObjectID, error) {
+	values, err := dao.databaseProvider.GetBoardsCollection().Distinct(dao.databaseProvider.GetContext(), "_id", bson.M{"deleted_ts": bson.M{"$lt": before}})
+	if err != nil {
+		return nil, err
+	}
+
+	return toObjectIds(values), nil
+}
+
+// GetBoardsPage returns one page of boards along with the cursor of the next page, which
+// is empty on the last page.
+func (dao *boardDao) GetBoardsPage(userId string, page *model.Page) ([]model.Board, string, error) {
+	filter, opts, err := paginate(notTrashed(memberFilter(userId)), page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	results, err := dao.findBoards(filter, opts)
+	if err != nil || len(results) <= page.Limit {
+		return results, "", err
+	}
+
+	results = results[:page.Limit]
+	cursor, err := nextPageCursor(dao.databaseProvider.GetBoardsCollection(), page, results[len(results)-1].ID)
+	return results, cursor, err
+}
+
+type scoredBoard struct {
+	model.Board `bson:",inline"`
+	Score       float64 `bson:"score"`
+}
+
+// SearchBoards returns the active boards of the given boards matching the text, best match first.
+func (dao *boardDao) SearchBoards(boardIds []primitive.ObjectID, text string, limit int) ([]model.SearchHit, error) {
+	if len(boardIds) == 0 {
+		return nil, nil
+	}
+
+	filter, opts := textSearch(bson.M{"_id": bson.M{"$in": boardIds}}, text, limit)
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	cursor, err := dao.databaseProvider.GetBoardsCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []scoredBoard
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	hits := make([]model.SearchHit, 0, len(results))
+	for _, result := range results {
+		hits = append(hits, model.SearchHit{Type: model.SearchHitBoard, ID: result.ID, Name: result.Name, Score: result.Score, BoardID: result.ID})
+	}
+	return hits, nil
+}
@@ -0,0 +1,101 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+	"todo/data"
+	"todo/model"
+)
+
+type commentDao struct {
+	databaseProvider data.MongoDBProviderInterface
+}
+
+type CommentDaoInterface interface {
+	CreateComment(comment *model.Comment) (*model.Comment, error)
+	DeleteComment(comment *model.Comment) error
+	DeleteCommentsByTaskIds(taskIds []primitive.ObjectID) error
+	UpdateComment(comment *model.Comment) (*model.Comment, error)
+	FindCommentById(id string) (model.Comment, error)
+	GetComments(taskId primitive.ObjectID) ([]model.Comment, error)
+}
+
+func CommentDao(databaseProvider data.MongoDBProviderInterface) *commentDao {
+	return &commentDao{databaseProvider}
+}
+
+func (dao *commentDao) CreateComment(comment *model.Comment) (*model.Comment, error) {
+	insertResult, err := dao.databaseProvider.GetCommentsCollection().InsertOne(dao.databaseProvider.GetContext(), comment)
+	if err != nil {
+		return nil, err
+	}
+	result, err := dao.FindCommentById(insertResult.InsertedID.(primitive.ObjectID).Hex())
+	return &result, err
+}
+
+func (dao *commentDao) DeleteComment(comment *model.Comment) error {
+	_, err := dao.databaseProvider.GetCommentsCollection().DeleteOne(dao.databaseProvider.GetContext(), bson.M{"_id": comment.ID})
+	return err
+}
+
+func (dao *commentDao) DeleteCommentsByTaskIds(taskIds []primitive.ObjectID) error {
+	if len(taskIds) == 0 {
+		return nil
+	}
+
+	_, err := dao.databaseProvider.GetCommentsCollection().DeleteMany(dao.databaseProvider.GetContext(), bson.M{"task_id": bson.M{"$in": taskIds}})
+	return err
+}
+
+func (dao *commentDao) UpdateComment(comment *model.Comment) (*model.Comment, error) {
+	expectedVersion := comment.Version
+	comment.Version = expectedVersion + 1
+	updateResult, err := dao.databaseProvider.GetCommentsCollection().ReplaceOne(context.Background(), versioned(bson.M{"_id": comment.ID}, expectedVersion), comment)
+	if err == nil && updateResult.MatchedCount == 0 {
+		err = ErrVersionConflict
+	}
+	result, _ := dao.FindCommentById(comment.ID.Hex())
+	return &result, err
+}
+
+func (dao *commentDao) FindCommentById(id string) (model.Comment, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Println("Invalid id")
+	}
+
+	result := dao.databaseProvider.GetCommentsCollection().FindOne(context.Background(), bson.M{"_id": objectId})
+	resultComment := model.Comment{}
+	err = result.Decode(&resultComment)
+	if err != nil {
+		fmt.Println(err)
+		return resultComment, fmt.Errorf("an error occurred while decoding record : %v", err)
+	}
+	return resultComment, nil
+}
+
+// GetComments returns the comments of the task, oldest first.
+func (dao *commentDao) GetComments(taskId primitive.ObjectID) ([]model.Comment, error) {
+	var results []model.Comment
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "created_ts", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := dao.databaseProvider.GetCommentsCollection().Find(ctx, bson.M{"task_id": taskId}, opts)
+	if err != nil {
+		fmt.Println("Finding all comments ERROR:", err)
+		return results, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,44 @@
+package dao
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+	"todo/data"
+)
+
+type leaderLockDao struct {
+	databaseProvider data.MongoDBProviderInterface
+}
+
+type LeaderLockDaoInterface interface {
+	AcquireLock(name string, owner string, now time.Time, ttl time.Duration) (bool, error)
+}
+
+func LeaderLockDao(databaseProvider data.MongoDBProviderInterface) *leaderLockDao {
+	return &leaderLockDao{databaseProvider}
+}
+
+// AcquireLock takes the named lock for the owner, or extends it when the owner already
+// holds it, until now plus the ttl. It reports false while another owner holds the lock.
+// A lock that has expired may be taken by anyone.
+func (dao *leaderLockDao) AcquireLock(name string, owner string, now time.Time, ttl time.Duration) (bool, error) {
+	filter := bson.M{"_id": name, "$or": bson.A{
+		bson.M{"owner": owner},
+		bson.M{"expires_ts": bson.M{"$lte": now}},
+	}}
+	update := bson.M{"$set": bson.M{"owner": owner, "expires_ts": now.Add(ttl)}}
+
+	// When the lock is held by someone else the filter misses and the upsert collides
+	// with the existing lock document.
+	_, err := dao.databaseProvider.GetLocksCollection().UpdateOne(dao.databaseProvider.GetContext(), filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
 	"time"
 	"todo/data"
@@ -18,9 +20,26 @@ type listDao struct {
 type ListDaoInterface interface {
 	CreateList(board *model.BoardList) (*model.BoardList, error)
 	DeleteList(board *model.BoardList) error
+	DeleteListsByIds(listIds []primitive.ObjectID) error
+	DeleteListsByBoardIds(boardIds []primitive.ObjectID) error
+	TrashList(boardList *model.BoardList, deletedTS time.Time) error
+	TrashListsByBoardIds(boardIds []primitive.ObjectID, deletedTS time.Time) error
+	RestoreList(boardList *model.BoardList) error
+	RestoreListsByBoardIds(boardIds []primitive.ObjectID, deletedTS time.Time) error
+	PatchList(boardList *model.BoardList, patch *model.Patch) (*model.BoardList, error)
 	UpdateList(board *model.BoardList) (*model.BoardList, error)
+	UpdateListPositions(boardLists []model.BoardList) error
 	FindListById(id string) (model.BoardList, error)
+	FindTrashedListById(id string) (model.BoardList, error)
 	GetLists(boardId string) ([]model.BoardList, error)
+	GetListsPage(boardId string, page *model.Page) ([]model.BoardList, string, error)
+	GetListsByBoardIds(boardIds []primitive.ObjectID) ([]model.BoardList, error)
+	GetTrashedLists(boardIds []primitive.ObjectID) ([]model.BoardList, error)
+	GetListsTrashedWith(boardIds []primitive.ObjectID, deletedTS time.Time) ([]model.BoardList, error)
+	GetBoardIdsByListIds(listIds []primitive.ObjectID) (map[primitive.ObjectID]primitive.ObjectID, error)
+	GetListIdsByBoardIds(boardIds []primitive.ObjectID) ([]primitive.ObjectID, error)
+	GetTrashedListIds(before time.Time) ([]primitive.ObjectID, error)
+	SearchLists(boardIds []primitive.ObjectID, text string, limit int) ([]model.SearchHit, error)
 }
 
 func ListDao(databaseProvider data.MongoDBProviderInterface) *listDao {
@@ -38,19 +57,117 @@ func (dao *listDao) DeleteList(boardList *model.BoardList) error {
 	return err
 }
 
+func (dao *listDao) DeleteListsByIds(listIds []primitive.ObjectID) error {
+	if len(listIds) == 0 {
+		return nil
+	}
+
+	_, err := dao.databaseProvider.GetListsCollection().DeleteMany(dao.databaseProvider.GetContext(), bson.M{"_id": bson.M{"$in": listIds}})
+	return err
+}
+
+func (dao *listDao) DeleteListsByBoardIds(boardIds []primitive.ObjectID) error {
+	if len(boardIds) == 0 {
+		return nil
+	}
+
+	_, err := dao.databaseProvider.GetListsCollection().DeleteMany(dao.databaseProvider.GetContext(), bson.M{"board_id": bson.M{"$in": boardIds}})
+	return err
+}
+
+func (dao *listDao) TrashList(boardList *model.BoardList, deletedTS time.Time) error {
+	_, err := dao.databaseProvider.GetListsCollection().UpdateOne(dao.databaseProvider.GetContext(),
+		notTrashed(bson.M{"_id": boardList.ID}), trashUpdate(deletedTS))
+	return err
+}
+
+func (dao *listDao) TrashListsByBoardIds(boardIds []primitive.ObjectID, deletedTS time.Time) error {
+	if len(boardIds) == 0 {
+		return nil
+	}
+
+	_, err := dao.databaseProvider.GetListsCollection().UpdateMany(dao.databaseProvider.GetContext(),
+		notTrashed(bson.M{"board_id": bson.M{"$in": boardIds}}), trashUpdate(deletedTS))
+	return err
+}
+
+func (dao *listDao) RestoreList(boardList *model.BoardList) error {
+	_, err := dao.databaseProvider.GetListsCollection().UpdateOne(dao.databaseProvider.GetContext(),
+		bson.M{"_id": boardList.ID}, restoreUpdate())
+	return err
+}
+
+// RestoreListsByBoardIds restores the lists that were trashed together with their board,
+// which share the board's deleted_ts.
+func (dao *listDao) RestoreListsByBoardIds(boardIds []primitive.ObjectID, deletedTS time.Time) error {
+	if len(boardIds) == 0 {
+		return nil
+	}
+
+	_, err := dao.databaseProvider.GetListsCollection().UpdateMany(dao.databaseProvider.GetContext(),
+		bson.M{"board_id": bson.M{"$in": boardIds}, "deleted_ts": deletedTS}, restoreUpdate())
+	return err
+}
+
+// PatchList applies the patch to the version of the record that was read by the caller.
+func (dao *listDao) PatchList(boardList *model.BoardList, patch *model.Patch) (*model.BoardList, error) {
+	updateResult, err := dao.databaseProvider.GetListsCollection().UpdateOne(context.Background(),
+		versioned(notTrashed(bson.M{"_id": boardList.ID}), boardList.Version), incrementVersion(patchUpdate(patch)))
+	if err == nil && updateResult.MatchedCount == 0 {
+		err = ErrVersionConflict
+	}
+	result, _ := dao.FindListById(boardList.ID.Hex())
+	return &result, err
+}
+
 func (dao *listDao) UpdateList(boardList *model.BoardList) (*model.BoardList, error) {
-	_, err := dao.databaseProvider.GetListsCollection().ReplaceOne(context.Background(), bson.M{"_id": boardList.ID}, boardList)
+	expectedVersion := boardList.Version
+	boardList.Version = expectedVersion + 1
+	updateResult, err := dao.databaseProvider.GetListsCollection().ReplaceOne(context.Background(), versioned(notTrashed(bson.M{"_id": boardList.ID}), expectedVersion), boardList)
+	if err == nil && updateResult.MatchedCount == 0 {
+		err = ErrVersionConflict
+	}
 	result, _ := dao.FindListById(boardList.ID.Hex())
 	return &result, err
 }
 
+// UpdateListPositions writes the order and modified time of each list in a single bulk
+// request.
+func (dao *listDao) UpdateListPositions(boardLists []model.BoardList) error {
+	if len(boardLists) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(boardLists))
+	for _, boardList := range boardLists {
+		fields := bson.M{"order": boardList.Order}
+		if !boardList.ModifiedTS.IsZero() {
+			fields["modified_ts"] = boardList.ModifiedTS
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(notTrashed(bson.M{"_id": boardList.ID})).
+			SetUpdate(incrementVersion(bson.M{"$set": fields})))
+	}
+
+	_, err := dao.databaseProvider.GetListsCollection().BulkWrite(dao.databaseProvider.GetContext(), writes)
+	return err
+}
+
 func (dao *listDao) FindListById(id string) (model.BoardList, error) {
+	return dao.findList(id, notTrashed)
+}
+
+func (dao *listDao) FindTrashedListById(id string) (model.BoardList, error) {
+	return dao.findList(id, trashed)
+}
+
+func (dao *listDao) findList(id string, scope func(bson.M) bson.M) (model.BoardList, error) {
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		log.Println("Invalid id")
 	}
 
-	result := dao.databaseProvider.GetListsCollection().FindOne(context.Background(), bson.M{"_id": objectId})
+	result := dao.databaseProvider.GetListsCollection().FindOne(context.Background(), scope(bson.M{"_id": objectId}))
 	resultList := model.BoardList{}
 	err = result.Decode(&resultList)
 	if err != nil {
@@ -66,19 +183,130 @@ func (dao *listDao) GetLists(boardId string) ([]model.BoardList, error) {
 		log.Println("Invalid board id")
 	}
 
+	return dao.findLists(notTrashed(bson.M{"board_id": boardObjectId}), options.Find().SetSort(bson.D{{Key: "order", Value: 1}, {Key: "_id", Value: 1}}))
+}
+
+func (dao *listDao) GetListsByBoardIds(boardIds []primitive.ObjectID) ([]model.BoardList, error) {
+	return dao.findLists(notTrashed(bson.M{"board_id": bson.M{"$in": boardIds}}))
+}
+
+func (dao *listDao) GetTrashedLists(boardIds []primitive.ObjectID) ([]model.BoardList, error) {
+	return dao.findLists(trashed(bson.M{"board_id": bson.M{"$in": boardIds}}))
+}
+
+// GetListsTrashedWith returns the lists that were trashed together with their board, which
+// share the board's deleted_ts.
+func (dao *listDao) GetListsTrashedWith(boardIds []primitive.ObjectID, deletedTS time.Time) ([]model.BoardList, error) {
+	return dao.findLists(bson.M{"board_id": bson.M{"$in": boardIds}, "deleted_ts": deletedTS})
+}
+
+// GetBoardIdsByListIds maps the lists, trashed or not, to the ids of their boards.
+func (dao *listDao) GetBoardIdsByListIds(listIds []primitive.ObjectID) (map[primitive.ObjectID]primitive.ObjectID, error) {
+	lists, err := dao.findLists(bson.M{"_id": bson.M{"$in": listIds}}, options.Find().SetProjection(bson.M{"board_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	boardIds := make(map[primitive.ObjectID]primitive.ObjectID, len(lists))
+	for _, list := range lists {
+		boardIds[list.ID] = list.BoardID
+	}
+	return boardIds, nil
+}
+
+func (dao *listDao) findLists(filter bson.M, opts ...*options.FindOptions) ([]model.BoardList, error) {
 	var results []model.BoardList
-	ctx, _ := context.WithTimeout(context.Background(), 15*time.Second)
-	cursor, err := dao.databaseProvider.GetListsCollection().Find(context.Background(), bson.M{"board_id": boardObjectId})
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	cursor, err := dao.databaseProvider.GetListsCollection().Find(ctx, filter, opts...)
 	if err != nil {
 		fmt.Println("Finding all lists ERROR:", err)
-		defer cursor.Close(ctx)
+		return results, err
 	}
+	defer cursor.Close(ctx)
 
 	err = cursor.All(ctx, &results)
 	if err != nil {
-		defer cursor.Close(ctx)
 		return results, err
 	}
 
 	return results, nil
 }
+
+// GetListIdsByBoardIds returns the ids of every list of the boards, trashed or not.
+func (dao *listDao) GetListIdsByBoardIds(boardIds []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	if len(boardIds) == 0 {
+		return nil, nil
+	}
+
+	values, err := dao.databaseProvider.GetListsCollection().Distinct(dao.databaseProvider.GetContext(), "_id", bson.M{"board_id": bson.M{"$in": boardIds}})
+	if err != nil {
+		return nil, err
+	}
+
+	return toObjectIds(values), nil
+}
+
+func (dao *listDao) GetTrashedListIds(before time.Time) ([]primitive.ObjectID, error) {
+	values, err := dao.databaseProvider.GetListsCollection().Distinct(dao.databaseProvider.GetContext(), "_id", bson.M{"deleted_ts": bson.M{"$lt": before}})
+	if err != nil {
+		return nil, err
+	}
+
+	return toObjectIds(values), nil
+}
+
+// GetListsPage returns one page of lists along with the cursor of the next page, which
+// is empty on the last page.
+func (dao *listDao) GetListsPage(boardId string, page *model.Page) ([]model.BoardList, string, error) {
+	boardObjectId, err := primitive.ObjectIDFromHex(boardId)
+	if err != nil {
+		log.Println("Invalid board id")
+	}
+
+	filter, opts, err := paginate(notTrashed(bson.M{"board_id": boardObjectId}), page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	results, err := dao.findLists(filter, opts)
+	if err != nil || len(results) <= page.Limit {
+		return results, "", err
+	}
+
+	results = results[:page.Limit]
+	cursor, err := nextPageCursor(dao.databaseProvider.GetListsCollection(), page, results[len(results)-1].ID)
+	return results, cursor, err
+}
+
+type scoredList struct {
+	model.BoardList `bson:",inline"`
+	Score           float64 `bson:"score"`
+}
+
+// SearchLists returns the active lists of the given boards matching the text, best match first.
+func (dao *listDao) SearchLists(boardIds []primitive.ObjectID, text string, limit int) ([]model.SearchHit, error) {
+	if len(boardIds) == 0 {
+		return nil, nil
+	}
+
+	filter, opts := textSearch(bson.M{"board_id": bson.M{"$in": boardIds}}, text, limit)
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	cursor, err := dao.databaseProvider.GetListsCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []scoredList
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	hits := make([]model.SearchHit, 0, len(results))
+	for _, result := range results {
+		hits = append(hits, model.SearchHit{Type: model.SearchHitList, ID: result.ID, Name: result.Name, Score: result.Score, BoardID: result.BoardID})
+	}
+	return hits, nil
+}
@@ -0,0 +1,67 @@
+package dao
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sync"
+	"todo/model"
+)
+
+// Events buffered per subscriber before further events to it are dropped.
+const eventBufferSize = 64
+
+type EventBusInterface interface {
+	Publish(event *model.BoardEvent) error
+	Subscribe(boardId primitive.ObjectID) (<-chan model.BoardEvent, func())
+}
+
+// memoryEventBus delivers events to the subscribers of this instance only.
+type memoryEventBus struct {
+	mutex       sync.RWMutex
+	subscribers map[primitive.ObjectID]map[chan model.BoardEvent]struct{}
+}
+
+func MemoryEventBus() *memoryEventBus {
+	return &memoryEventBus{subscribers: map[primitive.ObjectID]map[chan model.BoardEvent]struct{}{}}
+}
+
+// Publish never blocks on a slow subscriber, whose buffer being full means the event is
+// dropped for it.
+func (bus *memoryEventBus) Publish(event *model.BoardEvent) error {
+	bus.mutex.RLock()
+	defer bus.mutex.RUnlock()
+
+	for subscriber := range bus.subscribers[event.BoardID] {
+		select {
+		case subscriber <- *event:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe returns the events of the board along with the function that ends the
+// subscription and closes the channel.
+func (bus *memoryEventBus) Subscribe(boardId primitive.ObjectID) (<-chan model.BoardEvent, func()) {
+	subscriber := make(chan model.BoardEvent, eventBufferSize)
+
+	bus.mutex.Lock()
+	if bus.subscribers[boardId] == nil {
+		bus.subscribers[boardId] = map[chan model.BoardEvent]struct{}{}
+	}
+	bus.subscribers[boardId][subscriber] = struct{}{}
+	bus.mutex.Unlock()
+
+	var once sync.Once
+	return subscriber, func() {
+		once.Do(func() {
+			bus.mutex.Lock()
+			defer bus.mutex.Unlock()
+
+			delete(bus.subscribers[boardId], subscriber)
+			if len(bus.subscribers[boardId]) == 0 {
+				delete(bus.subscribers, boardId)
+			}
+			close(subscriber)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package dao
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryTokenRevocationDao keeps revoked token ids in process memory. It is meant for
+// tests and single instance setups without redis.
+type memoryTokenRevocationDao struct {
+	mutex         sync.Mutex
+	revokedTokens map[string]time.Time
+}
+
+func MemoryTokenRevocationDao() *memoryTokenRevocationDao {
+	return &memoryTokenRevocationDao{revokedTokens: make(map[string]time.Time)}
+}
+
+func (dao *memoryTokenRevocationDao) RevokeToken(tokenId string, expiresAt time.Time) error {
+	_, err := dao.ClaimToken(tokenId, expiresAt)
+	return err
+}
+
+func (dao *memoryTokenRevocationDao) ClaimToken(tokenId string, expiresAt time.Time) (bool, error) {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+
+	now := time.Now()
+	for id, expiration := range dao.revokedTokens {
+		if !expiration.After(now) {
+			delete(dao.revokedTokens, id)
+		}
+	}
+
+	if _, revoked := dao.revokedTokens[tokenId]; revoked || !expiresAt.After(now) {
+		return false, nil
+	}
+
+	dao.revokedTokens[tokenId] = expiresAt
+	return true, nil
+}
+
+func (dao *memoryTokenRevocationDao) IsTokenRevoked(tokenId string) (bool, error) {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+
+	expiration, ok := dao.revokedTokens[tokenId]
+	return ok && expiration.After(time.Now()), nil
+}
@@ -0,0 +1,14 @@
+package dao
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// toObjectIds converts the result of a Distinct call on an ObjectID field.
+func toObjectIds(values []interface{}) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,160 @@
+package dao
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
+	"strings"
+	"time"
+	"todo/model"
+)
+
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// pageCursor is encoded as bson rather than json so that sort values such as dates keep
+// their type when they come back in the next request.
+// Absent records that the sort field is missing or null in the record the cursor points
+// at, which sorts differently from any value, zero values included.
+type pageCursor struct {
+	SortField  string             `bson:"s"`
+	Descending bool               `bson:"d"`
+	Value      interface{}        `bson:"v"`
+	Absent     bool               `bson:"a,omitempty"`
+	ID         primitive.ObjectID `bson:"id"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	raw, err := bson.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// nextPageCursor returns the cursor of the page following the record with the given id.
+// The sort field is read back as stored rather than from the decoded record, whose zero
+// values can not tell a field that is 0 or empty from one that was left out.
+func nextPageCursor(collection *mongo.Collection, page *model.Page, id primitive.ObjectID) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	var record bson.Raw
+	opts := options.FindOne().SetProjection(bson.M{page.SortField: 1})
+	if err := collection.FindOne(ctx, bson.M{"_id": id}, opts).Decode(&record); err != nil {
+		return "", err
+	}
+
+	cursor := pageCursor{SortField: page.SortField, Descending: page.Descending, ID: id}
+	value, err := record.LookupErr(strings.Split(page.SortField, ".")...)
+	if err != nil || value.Type == bsontype.Null {
+		cursor.Absent = true
+	} else if err := value.Unmarshal(&cursor.Value); err != nil {
+		return "", err
+	}
+
+	return encodeCursor(cursor), nil
+}
+
+func decodeCursor(page *model.Page) (*pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(page.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor pageCursor
+	if err := bson.Unmarshal(raw, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if cursor.SortField != page.SortField || cursor.Descending != page.Descending {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// paginate adds the page's filters and the position of its cursor to filter, and returns
+// find options sorting by the page's field with the id as tie breaker. One record more
+// than the limit is fetched to tell whether there is a next page.
+func paginate(filter bson.M, page *model.Page) (bson.M, *options.FindOptions, error) {
+	conditions := bson.A{filter}
+
+	if page.NameContains != "" {
+		conditions = append(conditions, bson.M{"name": primitive.Regex{Pattern: regexp.QuoteMeta(page.NameContains), Options: "i"}})
+	}
+
+	if !page.ModifiedSince.IsZero() {
+		conditions = append(conditions, bson.M{"modified_ts": bson.M{"$gte": page.ModifiedSince}})
+	}
+
+	if len(page.LabelIDs) > 0 {
+		conditions = append(conditions, bson.M{"label_ids": bson.M{"$all": page.LabelIDs}})
+	}
+
+	if len(page.AssigneeIDs) > 0 {
+		conditions = append(conditions, bson.M{"assignee_ids": bson.M{"$all": page.AssigneeIDs}})
+	}
+
+	if page.Completed != nil {
+		if *page.Completed {
+			conditions = append(conditions, bson.M{"completed": true})
+		} else {
+			conditions = append(conditions, bson.M{"completed": bson.M{"$ne": true}})
+		}
+	}
+
+	if page.Cursor != "" {
+		cursor, err := decodeCursor(page)
+		if err != nil {
+			return nil, nil, err
+		}
+		conditions = append(conditions, afterCursor(cursor))
+	}
+
+	direction := 1
+	if page.Descending {
+		direction = -1
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: page.SortField, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(int64(page.Limit + 1))
+
+	return bson.M{"$and": conditions}, opts, nil
+}
+
+// afterCursor matches the records sorted after the cursor. Fields left out of documents
+// because of omitempty sort as null, which comes before every other value ascending and
+// after every other value descending.
+func afterCursor(cursor *pageCursor) bson.M {
+	field := cursor.SortField
+	compare, idCompare := "$gt", "$gt"
+	if cursor.Descending {
+		compare, idCompare = "$lt", "$lt"
+	}
+
+	if cursor.Absent {
+		if cursor.Descending {
+			return bson.M{field: nil, "_id": bson.M{idCompare: cursor.ID}}
+		}
+		return bson.M{"$or": bson.A{
+			bson.M{field: nil, "_id": bson.M{idCompare: cursor.ID}},
+			bson.M{field: bson.M{"$ne": nil}},
+		}}
+	}
+
+	branches := bson.A{
+		bson.M{field: bson.M{compare: cursor.Value}},
+		bson.M{field: cursor.Value, "_id": bson.M{idCompare: cursor.ID}},
+	}
+	if cursor.Descending {
+		branches = append(branches, bson.M{field: nil})
+	}
+	return bson.M{"$or": branches}
+}
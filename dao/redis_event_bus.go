@@ -0,0 +1,58 @@
+package dao
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"todo/data"
+	"todo/model"
+)
+
+const (
+	boardEventsChannel = "board-events"
+)
+
+// redisEventBus fans events out to every instance through redis pub/sub. Events published
+// here come back through the subscription like those of other instances, so local
+// subscribers see each event exactly once.
+type redisEventBus struct {
+	redisProvider data.RedisProviderInterface
+	local         *memoryEventBus
+}
+
+func RedisEventBus(redisProvider data.RedisProviderInterface) *redisEventBus {
+	bus := &redisEventBus{redisProvider, MemoryEventBus()}
+
+	pubsub := redisProvider.GetClient().Subscribe(redisProvider.GetContext(), boardEventsChannel)
+	if _, err := pubsub.Receive(redisProvider.GetContext()); err != nil {
+		panic(err)
+	}
+	go bus.relay(pubsub.Channel())
+
+	return bus
+}
+
+func (bus *redisEventBus) Publish(event *model.BoardEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return bus.redisProvider.GetClient().Publish(bus.redisProvider.GetContext(), boardEventsChannel, payload).Err()
+}
+
+func (bus *redisEventBus) Subscribe(boardId primitive.ObjectID) (<-chan model.BoardEvent, func()) {
+	return bus.local.Subscribe(boardId)
+}
+
+func (bus *redisEventBus) relay(messages <-chan *redis.Message) {
+	for message := range messages {
+		var event model.BoardEvent
+		if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+			fmt.Println("Decoding board event ERROR:", err)
+			continue
+		}
+		bus.local.Publish(&event)
+	}
+}
@@ -0,0 +1,17 @@
+package dao
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// textSearch restricts filter to the records matching the text index of the collection,
+// and returns find options that rank them by relevance.
+func textSearch(filter bson.M, text string, limit int) (bson.M, *options.FindOptions) {
+	score := bson.M{"$meta": "textScore"}
+	filter["$text"] = bson.M{"$search": text}
+	return notTrashed(filter), options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.M{"score": score}).
+		SetLimit(int64(limit))
+}
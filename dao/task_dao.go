@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
 	"time"
 	"todo/data"
@@ -18,9 +20,37 @@ type taskDao struct {
 type TaskDaoInterface interface {
 	CreateTask(task *model.Task) (*model.Task, error)
 	DeleteTask(task *model.Task) error
+	DeleteTasksByListIds(listIds []primitive.ObjectID) error
+	DeleteTasksByIds(taskIds []primitive.ObjectID) error
+	GetTaskIdsByListIds(listIds []primitive.ObjectID) ([]primitive.ObjectID, error)
+	GetTrashedTaskIds(before time.Time) ([]primitive.ObjectID, error)
+	GetAttachmentIdsByTaskIds(taskIds []primitive.ObjectID) ([]primitive.ObjectID, error)
+	TrashTask(task *model.Task, deletedTS time.Time) error
+	TrashTasksByListIds(listIds []primitive.ObjectID, deletedTS time.Time) error
+	RestoreTask(task *model.Task) error
+	RestoreTasksByListIds(listIds []primitive.ObjectID, deletedTS time.Time) error
+	PatchTask(task *model.Task, patch *model.Patch) (*model.Task, error)
 	UpdateTask(task *model.Task) (*model.Task, error)
+	UpdateTaskPositions(tasks []model.Task) error
+	RemoveLabelFromTasks(listIds []primitive.ObjectID, labelId primitive.ObjectID) error
+	GetTasksByListIds(listIds []primitive.ObjectID) ([]model.Task, error)
+	GetTasksTrashedWith(listIds []primitive.ObjectID, deletedTS time.Time) ([]model.Task, error)
+	GetTasksWithLabel(listIds []primitive.ObjectID, labelId primitive.ObjectID) ([]model.Task, error)
+	GetTasksWithAssignee(listIds []primitive.ObjectID, userId primitive.ObjectID) ([]model.Task, error)
+	GetDueTasks(listIds []primitive.ObjectID, dueBefore *time.Time, limit int) ([]model.Task, error)
+	GetAssignedTasks(listIds []primitive.ObjectID, userId primitive.ObjectID, limit int) ([]model.Task, error)
+	GetDueTasksSince(listIds []primitive.ObjectID, since time.Time, limit int) ([]model.Task, error)
+	GetDueRecurringTasks(now time.Time, excludedIds []primitive.ObjectID, limit int) ([]model.Task, error)
+	ClaimRecurrence(task *model.Task, nextTaskId primitive.ObjectID, spawnedTS time.Time) (bool, error)
+	ReleaseRecurrence(task *model.Task) error
+	RemoveAssigneeFromTasks(listIds []primitive.ObjectID, userId primitive.ObjectID) error
+	RemoveAssigneeFromAllTasks(userId primitive.ObjectID) error
 	FindTaskById(id string) (model.Task, error)
+	FindTrashedTaskById(id string) (model.Task, error)
 	GetTasks(listId string) ([]model.Task, error)
+	GetTasksPage(listId string, page *model.Page) ([]model.Task, string, error)
+	GetTrashedTasks(listIds []primitive.ObjectID) ([]model.Task, error)
+	SearchTasks(listIds []primitive.ObjectID, text string, limit int) ([]model.SearchHit, error)
 }
 
 func TaskDao(databaseProvider data.MongoDBProviderInterface) *taskDao {
@@ -38,25 +68,196 @@ func (dao *taskDao) DeleteTask(task *model.Task) error {
 	return err
 }
 
+func (dao *taskDao) DeleteTasksByListIds(listIds []primitive.ObjectID) error {
+	if len(listIds) == 0 {
+		return nil
+	}
+
+	_, err := dao.databaseProvider.GetTasksCollection().DeleteMany(dao.databaseProvider.GetContext(), bson.M{"list_id": bson.M{"$in": listIds}})
+	return err
+}
+
+func (dao *taskDao) DeleteTasksByIds(taskIds []primitive.ObjectID) error {
+	if len(taskIds) == 0 {
+		return nil
+	}
+
+	_, err := dao.databaseProvider.GetTasksCollection().DeleteMany(dao.databaseProvider.GetContext(), bson.M{"_id": bson.M{"$in": taskIds}})
+	return err
+}
+
+// GetTaskIdsByListIds returns the ids of every task of the lists, trashed or not.
+func (dao *taskDao) GetTaskIdsByListIds(listIds []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	if len(listIds) == 0 {
+		return nil, nil
+	}
+
+	values, err := dao.databaseProvider.GetTasksCollection().Distinct(dao.databaseProvider.GetContext(), "_id", bson.M{"list_id": bson.M{"$in": listIds}})
+	if err != nil {
+		return nil, err
+	}
+
+	return toObjectIds(values), nil
+}
+
+func (dao *taskDao) GetTrashedTaskIds(before time.Time) ([]primitive.ObjectID, error) {
+	values, err := dao.databaseProvider.GetTasksCollection().Distinct(dao.databaseProvider.GetContext(), "_id", bson.M{"deleted_ts": bson.M{"$lt": before}})
+	if err != nil {
+		return nil, err
+	}
+
+	return toObjectIds(values), nil
+}
+
+func (dao *taskDao) GetAttachmentIdsByTaskIds(taskIds []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	if len(taskIds) == 0 {
+		return nil, nil
+	}
+
+	values, err := dao.databaseProvider.GetTasksCollection().Distinct(dao.databaseProvider.GetContext(), "attachments.id", bson.M{"_id": bson.M{"$in": taskIds}})
+	if err != nil {
+		return nil, err
+	}
+
+	return toObjectIds(values), nil
+}
+
+func (dao *taskDao) TrashTask(task *model.Task, deletedTS time.Time) error {
+	_, err := dao.databaseProvider.GetTasksCollection().UpdateOne(dao.databaseProvider.GetContext(),
+		notTrashed(bson.M{"_id": task.ID}), trashUpdate(deletedTS))
+	return err
+}
+
+func (dao *taskDao) TrashTasksByListIds(listIds []primitive.ObjectID, deletedTS time.Time) error {
+	if len(listIds) == 0 {
+		return nil
+	}
+
+	_, err := dao.databaseProvider.GetTasksCollection().UpdateMany(dao.databaseProvider.GetContext(),
+		notTrashed(bson.M{"list_id": bson.M{"$in": listIds}}), trashUpdate(deletedTS))
+	return err
+}
+
+func (dao *taskDao) RestoreTask(task *model.Task) error {
+	_, err := dao.databaseProvider.GetTasksCollection().UpdateOne(dao.databaseProvider.GetContext(),
+		bson.M{"_id": task.ID}, restoreUpdate())
+	return err
+}
+
+// RestoreTasksByListIds restores the tasks that were trashed together with their list,
+// which share the list's deleted_ts.
+func (dao *taskDao) RestoreTasksByListIds(listIds []primitive.ObjectID, deletedTS time.Time) error {
+	if len(listIds) == 0 {
+		return nil
+	}
+
+	_, err := dao.databaseProvider.GetTasksCollection().UpdateMany(dao.databaseProvider.GetContext(),
+		bson.M{"list_id": bson.M{"$in": listIds}, "deleted_ts": deletedTS}, restoreUpdate())
+	return err
+}
+
+// PatchTask applies the patch to the version of the record that was read by the caller.
+func (dao *taskDao) PatchTask(task *model.Task, patch *model.Patch) (*model.Task, error) {
+	updateResult, err := dao.databaseProvider.GetTasksCollection().UpdateOne(context.Background(),
+		versioned(notTrashed(bson.M{"_id": task.ID}), task.Version), incrementVersion(patchUpdate(patch)))
+	if err == nil && updateResult.MatchedCount == 0 {
+		err = ErrVersionConflict
+	}
+	result, _ := dao.FindTaskById(task.ID.Hex())
+	return &result, err
+}
+
 func (dao *taskDao) UpdateTask(task *model.Task) (*model.Task, error) {
-	_, err := dao.databaseProvider.GetTasksCollection().ReplaceOne(context.Background(), bson.M{"_id": task.ID}, task)
+	expectedVersion := task.Version
+	task.Version = expectedVersion + 1
+	updateResult, err := dao.databaseProvider.GetTasksCollection().ReplaceOne(context.Background(), versioned(notTrashed(bson.M{"_id": task.ID}), expectedVersion), task)
+	if err == nil && updateResult.MatchedCount == 0 {
+		err = ErrVersionConflict
+	}
 	result, _ := dao.FindTaskById(task.ID.Hex())
 	return &result, err
 }
 
+// UpdateTaskPositions writes the list, order and modified time of each task in a single
+// bulk request, along with the completion of completed tasks.
+func (dao *taskDao) UpdateTaskPositions(tasks []model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(tasks))
+	for _, task := range tasks {
+		fields := bson.M{"list_id": task.ListID, "order": task.Order}
+		if !task.ModifiedTS.IsZero() {
+			fields["modified_ts"] = task.ModifiedTS
+		}
+		if task.Completed {
+			fields["completed"] = true
+			fields["completed_ts"] = task.CompletedTS
+			if !task.CompletedBy.IsZero() {
+				fields["completed_by"] = task.CompletedBy
+			}
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(notTrashed(bson.M{"_id": task.ID})).
+			SetUpdate(incrementVersion(bson.M{"$set": fields})))
+	}
+
+	_, err := dao.databaseProvider.GetTasksCollection().BulkWrite(dao.databaseProvider.GetContext(), writes)
+	return err
+}
+
+// RemoveLabelFromTasks takes the label off every task of the lists, trashed or not.
+func (dao *taskDao) RemoveLabelFromTasks(listIds []primitive.ObjectID, labelId primitive.ObjectID) error {
+	return dao.pullFromTasks(listIds, "label_ids", labelId)
+}
+
+// RemoveAssigneeFromTasks unassigns the user from every task of the lists, trashed or not.
+func (dao *taskDao) RemoveAssigneeFromTasks(listIds []primitive.ObjectID, userId primitive.ObjectID) error {
+	return dao.pullFromTasks(listIds, "assignee_ids", userId)
+}
+
+// RemoveAssigneeFromAllTasks unassigns the user from every task of every board, trashed or not.
+func (dao *taskDao) RemoveAssigneeFromAllTasks(userId primitive.ObjectID) error {
+	_, err := dao.databaseProvider.GetTasksCollection().UpdateMany(dao.databaseProvider.GetContext(),
+		bson.M{"assignee_ids": userId},
+		incrementVersion(bson.M{"$pull": bson.M{"assignee_ids": userId}}))
+	return err
+}
+
+func (dao *taskDao) pullFromTasks(listIds []primitive.ObjectID, field string, id primitive.ObjectID) error {
+	if len(listIds) == 0 {
+		return nil
+	}
+
+	_, err := dao.databaseProvider.GetTasksCollection().UpdateMany(dao.databaseProvider.GetContext(),
+		bson.M{"list_id": bson.M{"$in": listIds}, field: id},
+		incrementVersion(bson.M{"$pull": bson.M{field: id}}))
+	return err
+}
+
 func (dao *taskDao) FindTaskById(id string) (model.Task, error) {
+	return dao.findTask(id, notTrashed)
+}
+
+func (dao *taskDao) FindTrashedTaskById(id string) (model.Task, error) {
+	return dao.findTask(id, trashed)
+}
+
+func (dao *taskDao) findTask(id string, scope func(bson.M) bson.M) (model.Task, error) {
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		log.Println("Invalid id")
 	}
 
-	result := dao.databaseProvider.GetTasksCollection().FindOne(context.Background(), bson.M{"_id": objectId})
+	result := dao.databaseProvider.GetTasksCollection().FindOne(context.Background(), scope(bson.M{"_id": objectId}))
 	resultTask := model.Task{}
 	err = result.Decode(&resultTask)
 	if err != nil {
 		fmt.Println(err)
 		return resultTask, fmt.Errorf("an error occurred while decoding record : %v", err)
 	}
+	resultTask.CountChecklistProgress()
 	return resultTask, nil
 }
 
@@ -66,19 +267,193 @@ func (dao *taskDao) GetTasks(listId string) ([]model.Task, error) {
 		log.Println("Invalid list id")
 	}
 
+	return dao.findTasks(notTrashed(bson.M{"list_id": listObjectId}), options.Find().SetSort(bson.D{{Key: "order", Value: 1}, {Key: "_id", Value: 1}}))
+}
+
+// GetDueTasks returns the open, active tasks of the lists that have a due date, earliest
+// first. When dueBefore is set only the tasks due before it are returned.
+func (dao *taskDao) GetDueTasks(listIds []primitive.ObjectID, dueBefore *time.Time, limit int) ([]model.Task, error) {
+	if len(listIds) == 0 {
+		return nil, nil
+	}
+
+	due := bson.M{"$ne": nil}
+	if dueBefore != nil {
+		due = bson.M{"$lt": *dueBefore}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "due_ts", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	return dao.findTasks(notTrashed(bson.M{"list_id": bson.M{"$in": listIds}, "due_ts": due, "completed": bson.M{"$ne": true}}), opts)
+}
+
+// GetDueTasksSince returns the active tasks of the lists due at or after since, earliest first.
+func (dao *taskDao) GetDueTasksSince(listIds []primitive.ObjectID, since time.Time, limit int) ([]model.Task, error) {
+	if len(listIds) == 0 {
+		return nil, nil
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "due_ts", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	return dao.findTasks(notTrashed(bson.M{"list_id": bson.M{"$in": listIds}, "due_ts": bson.M{"$gte": since}}), opts)
+}
+
+// GetAssignedTasks returns the active tasks of the lists assigned to the user, oldest first.
+func (dao *taskDao) GetAssignedTasks(listIds []primitive.ObjectID, userId primitive.ObjectID, limit int) ([]model.Task, error) {
+	if len(listIds) == 0 {
+		return nil, nil
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	return dao.findTasks(notTrashed(bson.M{"list_id": bson.M{"$in": listIds}, "assignee_ids": userId}), opts)
+}
+
+// GetDueRecurringTasks returns the active recurring tasks that were completed or due by now
+// and have not spawned their next occurrence yet, earliest due first, leaving out the
+// excluded tasks. The recurrence condition matches the partial index of recurring tasks.
+func (dao *taskDao) GetDueRecurringTasks(now time.Time, excludedIds []primitive.ObjectID, limit int) ([]model.Task, error) {
+	filter := bson.M{"recurrence": bson.M{"$exists": true}, "recurrence.spawned_ts": nil, "$or": bson.A{
+		bson.M{"due_ts": bson.M{"$lte": now}},
+		bson.M{"completed": true},
+	}}
+	if len(excludedIds) > 0 {
+		filter["_id"] = bson.M{"$nin": excludedIds}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "due_ts", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	return dao.findTasks(notTrashed(filter), opts)
+}
+
+// ClaimRecurrence marks the recurring task as having spawned its next occurrence, which is
+// the task with nextTaskId unless the series has ended. It reports false when the task was
+// claimed by another instance or its rule changed since it was read.
+func (dao *taskDao) ClaimRecurrence(task *model.Task, nextTaskId primitive.ObjectID, spawnedTS time.Time) (bool, error) {
+	fields := bson.M{"recurrence.spawned_ts": spawnedTS}
+	if !nextTaskId.IsZero() {
+		fields["recurrence.next_task_id"] = nextTaskId
+	}
+
+	filter := bson.M{"_id": task.ID, "recurrence.rule": task.Recurrence.Rule, "recurrence.spawned_ts": nil}
+	updateResult, err := dao.databaseProvider.GetTasksCollection().UpdateOne(dao.databaseProvider.GetContext(),
+		notTrashed(filter), incrementVersion(bson.M{"$set": fields}))
+	if err != nil {
+		return false, err
+	}
+
+	return updateResult.ModifiedCount > 0, nil
+}
+
+// ReleaseRecurrence undoes a claim whose next occurrence could not be created.
+func (dao *taskDao) ReleaseRecurrence(task *model.Task) error {
+	_, err := dao.databaseProvider.GetTasksCollection().UpdateOne(dao.databaseProvider.GetContext(),
+		bson.M{"_id": task.ID}, incrementVersion(bson.M{"$unset": bson.M{"recurrence.spawned_ts": "", "recurrence.next_task_id": ""}}))
+	return err
+}
+
+func (dao *taskDao) GetTrashedTasks(listIds []primitive.ObjectID) ([]model.Task, error) {
+	return dao.findTasks(trashed(bson.M{"list_id": bson.M{"$in": listIds}}))
+}
+
+func (dao *taskDao) GetTasksByListIds(listIds []primitive.ObjectID) ([]model.Task, error) {
+	return dao.findTasks(notTrashed(bson.M{"list_id": bson.M{"$in": listIds}}))
+}
+
+// GetTasksTrashedWith returns the tasks that were trashed together with their list, which
+// share the list's deleted_ts.
+func (dao *taskDao) GetTasksTrashedWith(listIds []primitive.ObjectID, deletedTS time.Time) ([]model.Task, error) {
+	return dao.findTasks(bson.M{"list_id": bson.M{"$in": listIds}, "deleted_ts": deletedTS})
+}
+
+// GetTasksWithLabel returns every task of the lists carrying the label, trashed or not.
+func (dao *taskDao) GetTasksWithLabel(listIds []primitive.ObjectID, labelId primitive.ObjectID) ([]model.Task, error) {
+	return dao.findTasks(bson.M{"list_id": bson.M{"$in": listIds}, "label_ids": labelId})
+}
+
+// GetTasksWithAssignee returns every task of the lists assigned to the user, trashed or not.
+func (dao *taskDao) GetTasksWithAssignee(listIds []primitive.ObjectID, userId primitive.ObjectID) ([]model.Task, error) {
+	return dao.findTasks(bson.M{"list_id": bson.M{"$in": listIds}, "assignee_ids": userId})
+}
+
+func (dao *taskDao) findTasks(filter bson.M, opts ...*options.FindOptions) ([]model.Task, error) {
 	var results []model.Task
-	ctx, _ := context.WithTimeout(context.Background(), 15*time.Second)
-	cursor, err := dao.databaseProvider.GetTasksCollection().Find(context.Background(), bson.M{"list_id": listObjectId})
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	cursor, err := dao.databaseProvider.GetTasksCollection().Find(ctx, filter, opts...)
 	if err != nil {
 		fmt.Println("Finding all tasks ERROR:", err)
-		defer cursor.Close(ctx)
+		return results, err
 	}
+	defer cursor.Close(ctx)
 
 	err = cursor.All(ctx, &results)
 	if err != nil {
-		defer cursor.Close(ctx)
 		return results, err
 	}
 
+	for i := range results {
+		results[i].CountChecklistProgress()
+	}
+
 	return results, nil
 }
+
+// GetTasksPage returns one page of tasks along with the cursor of the next page, which
+// is empty on the last page.
+func (dao *taskDao) GetTasksPage(listId string, page *model.Page) ([]model.Task, string, error) {
+	listObjectId, err := primitive.ObjectIDFromHex(listId)
+	if err != nil {
+		log.Println("Invalid list id")
+	}
+
+	filter, opts, err := paginate(notTrashed(bson.M{"list_id": listObjectId}), page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	results, err := dao.findTasks(filter, opts)
+	if err != nil || len(results) <= page.Limit {
+		return results, "", err
+	}
+
+	results = results[:page.Limit]
+	cursor, err := nextPageCursor(dao.databaseProvider.GetTasksCollection(), page, results[len(results)-1].ID)
+	return results, cursor, err
+}
+
+type scoredTask struct {
+	model.Task `bson:",inline"`
+	Score      float64 `bson:"score"`
+}
+
+// SearchTasks returns the active tasks of the given lists matching the text, best match first.
+func (dao *taskDao) SearchTasks(listIds []primitive.ObjectID, text string, limit int) ([]model.SearchHit, error) {
+	if len(listIds) == 0 {
+		return nil, nil
+	}
+
+	filter, opts := textSearch(bson.M{"list_id": bson.M{"$in": listIds}}, text, limit)
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	cursor, err := dao.databaseProvider.GetTasksCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []scoredTask
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	hits := make([]model.SearchHit, 0, len(results))
+	for _, result := range results {
+		listId := result.ListID
+		hits = append(hits, model.SearchHit{Type: model.SearchHitTask, ID: result.ID, Name: result.Name, Score: result.Score, ListID: &listId})
+	}
+	return hits, nil
+}
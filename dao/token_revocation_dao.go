@@ -0,0 +1,55 @@
+package dao
+
+import (
+	"time"
+	"todo/data"
+)
+
+const (
+	revokedTokenKeyPrefix = "revoked-token:"
+)
+
+type tokenRevocationDao struct {
+	redisProvider data.RedisProviderInterface
+}
+
+type TokenRevocationDaoInterface interface {
+	RevokeToken(tokenId string, expiresAt time.Time) error
+	ClaimToken(tokenId string, expiresAt time.Time) (bool, error)
+	IsTokenRevoked(tokenId string) (bool, error)
+}
+
+func TokenRevocationDao(redisProvider data.RedisProviderInterface) *tokenRevocationDao {
+	return &tokenRevocationDao{redisProvider}
+}
+
+// RevokeToken records the token id until the token would have expired anyway,
+// after which redis drops the key on its own.
+func (dao *tokenRevocationDao) RevokeToken(tokenId string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return dao.redisProvider.GetClient().Set(dao.redisProvider.GetContext(), revokedTokenKeyPrefix+tokenId, 1, ttl).Err()
+}
+
+// ClaimToken revokes the token id unless it already is, and reports whether this call was
+// the one to revoke it. Of concurrent claims of the same token only one succeeds.
+func (dao *tokenRevocationDao) ClaimToken(tokenId string, expiresAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return false, nil
+	}
+
+	return dao.redisProvider.GetClient().SetNX(dao.redisProvider.GetContext(), revokedTokenKeyPrefix+tokenId, 1, ttl).Result()
+}
+
+func (dao *tokenRevocationDao) IsTokenRevoked(tokenId string) (bool, error) {
+	count, err := dao.redisProvider.GetClient().Exists(dao.redisProvider.GetContext(), revokedTokenKeyPrefix+tokenId).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
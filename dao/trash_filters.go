@@ -0,0 +1,26 @@
+package dao
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+)
+
+// notTrashed restricts filter to records that have not been moved to the trash.
+func notTrashed(filter bson.M) bson.M {
+	filter["deleted_ts"] = bson.M{"$exists": false}
+	return filter
+}
+
+// trashed restricts filter to records that are in the trash.
+func trashed(filter bson.M) bson.M {
+	filter["deleted_ts"] = bson.M{"$exists": true}
+	return filter
+}
+
+func trashUpdate(deletedTS time.Time) bson.M {
+	return incrementVersion(bson.M{"$set": bson.M{"deleted_ts": deletedTS}})
+}
+
+func restoreUpdate() bson.M {
+	return incrementVersion(bson.M{"$unset": bson.M{"deleted_ts": ""}})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
 	"time"
 	"todo/data"
@@ -18,9 +19,13 @@ type userDao struct {
 type UserDaoInterface interface {
 	CreateUser(user *model.User) (*model.User, error)
 	DeleteUser(user *model.User) error
+	PatchUser(user *model.User, patch *model.Patch) (*model.User, error)
 	FindUserById(id string) (model.User, error)
 	FindUserByUsername(username string) (model.User, error)
+	FindUserByCalendarTokenHash(hash string) (model.User, error)
 	GetUsers() ([]model.User, error)
+	GetUsersPage(page *model.Page) ([]model.User, string, error)
+	GetUserSummaries(userIds []primitive.ObjectID) ([]model.UserSummary, error)
 }
 
 func UserDao(databaseProvider data.MongoDBProviderInterface) *userDao {
@@ -38,6 +43,12 @@ func (dao *userDao) DeleteUser(user *model.User) error {
 	return err
 }
 
+func (dao *userDao) PatchUser(user *model.User, patch *model.Patch) (*model.User, error) {
+	_, err := dao.databaseProvider.GetUsersCollection().UpdateOne(context.Background(), bson.M{"_id": user.ID}, patchUpdate(patch))
+	result, _ := dao.FindUserById(user.ID.Hex())
+	return &result, err
+}
+
 func (dao *userDao) FindUserById(id string) (model.User, error) {
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -65,18 +76,72 @@ func (dao *userDao) FindUserByUsername(username string) (model.User, error) {
 	return resultUser, nil
 }
 
+func (dao *userDao) FindUserByCalendarTokenHash(hash string) (model.User, error) {
+	result := dao.databaseProvider.GetUsersCollection().FindOne(context.Background(), bson.M{"calendar_token_hash": hash})
+	resultUser := model.User{}
+	err := result.Decode(&resultUser)
+	if err != nil {
+		return resultUser, fmt.Errorf("an error occurred while decoding record : %v", err)
+	}
+	return resultUser, nil
+}
+
 func (dao *userDao) GetUsers() ([]model.User, error) {
+	return dao.findUsers(bson.M{})
+}
+
+// GetUsersPage returns one page of users along with the cursor of the next page, which
+// is empty on the last page.
+func (dao *userDao) GetUsersPage(page *model.Page) ([]model.User, string, error) {
+	filter, opts, err := paginate(bson.M{}, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	results, err := dao.findUsers(filter, opts)
+	if err != nil || len(results) <= page.Limit {
+		return results, "", err
+	}
+
+	results = results[:page.Limit]
+	cursor, err := nextPageCursor(dao.databaseProvider.GetUsersCollection(), page, results[len(results)-1].ID)
+	return results, cursor, err
+}
+
+// GetUserSummaries only reads the fields of model.UserSummary, so that nothing else about
+// the users can leak into responses.
+func (dao *userDao) GetUserSummaries(userIds []primitive.ObjectID) ([]model.UserSummary, error) {
+	var results []model.UserSummary
+	if len(userIds) == 0 {
+		return results, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	opts := options.Find().SetProjection(bson.M{"name": 1, "username": 1})
+	cursor, err := dao.databaseProvider.GetUsersCollection().Find(ctx, bson.M{"_id": bson.M{"$in": userIds}}, opts)
+	if err != nil {
+		return results, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &results)
+	return results, err
+}
+
+func (dao *userDao) findUsers(filter bson.M, opts ...*options.FindOptions) ([]model.User, error) {
 	var results []model.User
-	ctx, _ := context.WithTimeout(context.Background(), 15*time.Second)
-	cursor, err := dao.databaseProvider.GetUsersCollection().Find(context.Background(), bson.D{})
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	cursor, err := dao.databaseProvider.GetUsersCollection().Find(ctx, filter, opts...)
 	if err != nil {
 		fmt.Println("Finding all users ERROR:", err)
-		defer cursor.Close(ctx)
+		return results, err
 	}
+	defer cursor.Close(ctx)
 
 	err = cursor.All(ctx, &results)
 	if err != nil {
-		defer cursor.Close(ctx)
 		return results, err
 	}
 
@@ -0,0 +1,42 @@
+package dao
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"todo/model"
+)
+
+var ErrVersionConflict = errors.New("record was modified by another request")
+
+// versioned restricts filter to the given version of a record. Records written before
+// versions were introduced have no version field and count as version 0.
+func versioned(filter bson.M, version int64) bson.M {
+	if version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	} else {
+		filter["version"] = version
+	}
+	return filter
+}
+
+func incrementVersion(update bson.M) bson.M {
+	update["$inc"] = bson.M{"version": 1}
+	return update
+}
+
+func patchUpdate(patch *model.Patch) bson.M {
+	update := bson.M{}
+	if len(patch.Set) > 0 {
+		update["$set"] = bson.M(patch.Set)
+	}
+
+	if len(patch.Unset) > 0 {
+		unset := bson.M{}
+		for _, field := range patch.Unset {
+			unset[field] = ""
+		}
+		update["$unset"] = unset
+	}
+
+	return update
+}
@@ -0,0 +1,213 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+	"todo/data"
+	"todo/model"
+)
+
+type webhookDao struct {
+	databaseProvider data.MongoDBProviderInterface
+}
+
+type WebhookDaoInterface interface {
+	CreateWebhook(webhook *model.Webhook) (*model.Webhook, error)
+	UpdateWebhook(webhook *model.Webhook) (*model.Webhook, error)
+	DeleteWebhook(webhook *model.Webhook) error
+	DeleteWebhooksByBoardIds(boardIds []primitive.ObjectID) error
+	IncrementWebhookFailures(id primitive.ObjectID) (model.Webhook, error)
+	ResetWebhookFailures(id primitive.ObjectID) error
+	DisableWebhook(id primitive.ObjectID, disabledTS time.Time) error
+	FindWebhookById(id string) (model.Webhook, error)
+	GetWebhooks(boardId primitive.ObjectID) ([]model.Webhook, error)
+	GetActiveWebhooks(boardId primitive.ObjectID) ([]model.Webhook, error)
+	CreateDeliveries(deliveries []model.WebhookDelivery) error
+	ClaimDueDelivery(now time.Time, lease time.Duration) (*model.WebhookDelivery, error)
+	UpdateDelivery(delivery *model.WebhookDelivery) error
+	GetDeliveriesPage(webhookId primitive.ObjectID, page *model.Page) ([]model.WebhookDelivery, string, error)
+}
+
+func WebhookDao(databaseProvider data.MongoDBProviderInterface) *webhookDao {
+	return &webhookDao{databaseProvider}
+}
+
+func (dao *webhookDao) CreateWebhook(webhook *model.Webhook) (*model.Webhook, error) {
+	insertResult, err := dao.databaseProvider.GetWebhooksCollection().InsertOne(dao.databaseProvider.GetContext(), webhook)
+	if err != nil {
+		return nil, err
+	}
+	result, err := dao.FindWebhookById(insertResult.InsertedID.(primitive.ObjectID).Hex())
+	return &result, err
+}
+
+func (dao *webhookDao) UpdateWebhook(webhook *model.Webhook) (*model.Webhook, error) {
+	_, err := dao.databaseProvider.GetWebhooksCollection().ReplaceOne(context.Background(), bson.M{"_id": webhook.ID}, webhook)
+	if err != nil {
+		return nil, err
+	}
+	result, err := dao.FindWebhookById(webhook.ID.Hex())
+	return &result, err
+}
+
+// DeleteWebhook deletes the webhook along with its delivery log and queued deliveries.
+func (dao *webhookDao) DeleteWebhook(webhook *model.Webhook) error {
+	_, err := dao.databaseProvider.GetDeliveriesCollection().DeleteMany(dao.databaseProvider.GetContext(), bson.M{"webhook_id": webhook.ID})
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.databaseProvider.GetWebhooksCollection().DeleteOne(dao.databaseProvider.GetContext(), bson.M{"_id": webhook.ID})
+	return err
+}
+
+func (dao *webhookDao) DeleteWebhooksByBoardIds(boardIds []primitive.ObjectID) error {
+	if len(boardIds) == 0 {
+		return nil
+	}
+
+	filter := bson.M{"board_id": bson.M{"$in": boardIds}}
+	if _, err := dao.databaseProvider.GetDeliveriesCollection().DeleteMany(dao.databaseProvider.GetContext(), filter); err != nil {
+		return err
+	}
+
+	_, err := dao.databaseProvider.GetWebhooksCollection().DeleteMany(dao.databaseProvider.GetContext(), filter)
+	return err
+}
+
+// IncrementWebhookFailures counts one more failed attempt in a row and returns the webhook
+// as updated.
+func (dao *webhookDao) IncrementWebhookFailures(id primitive.ObjectID) (model.Webhook, error) {
+	var result model.Webhook
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := dao.databaseProvider.GetWebhooksCollection().FindOneAndUpdate(dao.databaseProvider.GetContext(),
+		bson.M{"_id": id}, bson.M{"$inc": bson.M{"consecutive_failures": 1}}, opts).Decode(&result)
+	return result, err
+}
+
+func (dao *webhookDao) ResetWebhookFailures(id primitive.ObjectID) error {
+	_, err := dao.databaseProvider.GetWebhooksCollection().UpdateOne(dao.databaseProvider.GetContext(),
+		bson.M{"_id": id, "consecutive_failures": bson.M{"$ne": 0}}, bson.M{"$set": bson.M{"consecutive_failures": 0}})
+	return err
+}
+
+func (dao *webhookDao) DisableWebhook(id primitive.ObjectID, disabledTS time.Time) error {
+	_, err := dao.databaseProvider.GetWebhooksCollection().UpdateOne(dao.databaseProvider.GetContext(),
+		bson.M{"_id": id, "active": true}, bson.M{"$set": bson.M{"active": false, "disabled_ts": disabledTS}})
+	return err
+}
+
+func (dao *webhookDao) FindWebhookById(id string) (model.Webhook, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Println("Invalid id")
+	}
+
+	result := dao.databaseProvider.GetWebhooksCollection().FindOne(context.Background(), bson.M{"_id": objectId})
+	resultWebhook := model.Webhook{}
+	err = result.Decode(&resultWebhook)
+	if err != nil {
+		fmt.Println(err)
+		return resultWebhook, fmt.Errorf("an error occurred while decoding record : %v", err)
+	}
+	return resultWebhook, nil
+}
+
+func (dao *webhookDao) GetWebhooks(boardId primitive.ObjectID) ([]model.Webhook, error) {
+	return dao.findWebhooks(bson.M{"board_id": boardId})
+}
+
+func (dao *webhookDao) GetActiveWebhooks(boardId primitive.ObjectID) ([]model.Webhook, error) {
+	return dao.findWebhooks(bson.M{"board_id": boardId, "active": true})
+}
+
+func (dao *webhookDao) CreateDeliveries(deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		documents = append(documents, delivery)
+	}
+
+	_, err := dao.databaseProvider.GetDeliveriesCollection().InsertMany(dao.databaseProvider.GetContext(), documents)
+	return err
+}
+
+// ClaimDueDelivery takes the pending delivery that has waited longest for its attempt and
+// pushes its next attempt back by the lease, so that other instances leave it alone while
+// it is being sent. It returns nil when no delivery is due.
+func (dao *webhookDao) ClaimDueDelivery(now time.Time, lease time.Duration) (*model.WebhookDelivery, error) {
+	filter := bson.M{"status": model.WebhookDeliveryPending, "next_attempt_ts": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"next_attempt_ts": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "next_attempt_ts", Value: 1}})
+
+	var result model.WebhookDelivery
+	err := dao.databaseProvider.GetDeliveriesCollection().FindOneAndUpdate(dao.databaseProvider.GetContext(), filter, update, opts).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (dao *webhookDao) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	_, err := dao.databaseProvider.GetDeliveriesCollection().ReplaceOne(dao.databaseProvider.GetContext(), bson.M{"_id": delivery.ID}, delivery)
+	return err
+}
+
+// GetDeliveriesPage returns one page of the delivery log of a webhook along with the
+// cursor of the next page, which is empty on the last page.
+func (dao *webhookDao) GetDeliveriesPage(webhookId primitive.ObjectID, page *model.Page) ([]model.WebhookDelivery, string, error) {
+	filter, opts, err := paginate(bson.M{"webhook_id": webhookId}, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var results []model.WebhookDelivery
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	cursor, err := dao.databaseProvider.GetDeliveriesCollection().Find(ctx, filter, opts)
+	if err != nil {
+		fmt.Println("Finding all webhook deliveries ERROR:", err)
+		return nil, "", err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &results); err != nil || len(results) <= page.Limit {
+		return results, "", err
+	}
+
+	results = results[:page.Limit]
+	nextCursor, err := nextPageCursor(dao.databaseProvider.GetDeliveriesCollection(), page, results[len(results)-1].ID)
+	return results, nextCursor, err
+}
+
+func (dao *webhookDao) findWebhooks(filter bson.M) ([]model.Webhook, error) {
+	var results []model.Webhook
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "created_ts", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := dao.databaseProvider.GetWebhooksCollection().Find(ctx, filter, opts)
+	if err != nil {
+		fmt.Println("Finding all webhooks ERROR:", err)
+		return results, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
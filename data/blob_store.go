@@ -0,0 +1,17 @@
+package data
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStoreInterface stores the content of uploaded files by id. Implementations must
+// treat deleting a missing blob as success so that cleanups can be retried.
+type BlobStoreInterface interface {
+	PutBlob(id primitive.ObjectID, content io.Reader) (int64, error)
+	GetBlob(id primitive.ObjectID) (io.ReadCloser, error)
+	DeleteBlob(id primitive.ObjectID) error
+}
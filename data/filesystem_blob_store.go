@@ -0,0 +1,62 @@
+package data
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type filesystemBlobStore struct {
+	dir string
+}
+
+// FilesystemBlobStore keeps every blob in its own file of dir, named after the blob id.
+func FilesystemBlobStore(dir string) *filesystemBlobStore {
+	return &filesystemBlobStore{dir}
+}
+
+func (store *filesystemBlobStore) PutBlob(id primitive.ObjectID, content io.Reader) (int64, error) {
+	if err := os.MkdirAll(store.dir, 0o750); err != nil {
+		return 0, err
+	}
+
+	// Write to a temporary file first so that a failed upload never leaves a partial blob.
+	file, err := os.CreateTemp(store.dir, id.Hex()+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+
+	size, err := io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return size, err
+	}
+
+	return size, os.Rename(file.Name(), store.path(id))
+}
+
+func (store *filesystemBlobStore) GetBlob(id primitive.ObjectID) (io.ReadCloser, error) {
+	file, err := os.Open(store.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return file, err
+}
+
+func (store *filesystemBlobStore) DeleteBlob(id primitive.ObjectID) error {
+	err := os.Remove(store.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (store *filesystemBlobStore) path(id primitive.ObjectID) string {
+	return filepath.Join(store.dir, id.Hex())
+}
@@ -0,0 +1,72 @@
+package data
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+)
+
+const attachmentsBucket = "attachments"
+
+type gridFSBlobStore struct {
+	databaseProvider MongoDBProviderInterface
+}
+
+// GridFSBlobStore keeps blobs in the attachments GridFS bucket of the todo database.
+func GridFSBlobStore(databaseProvider MongoDBProviderInterface) *gridFSBlobStore {
+	return &gridFSBlobStore{databaseProvider}
+}
+
+func (store *gridFSBlobStore) PutBlob(id primitive.ObjectID, content io.Reader) (int64, error) {
+	bucket, err := store.bucket()
+	if err != nil {
+		return 0, err
+	}
+
+	counter := &countingReader{reader: content}
+	err = bucket.UploadFromStreamWithID(id, id.Hex(), counter)
+	return counter.count, err
+}
+
+func (store *gridFSBlobStore) GetBlob(id primitive.ObjectID) (io.ReadCloser, error) {
+	bucket, err := store.bucket()
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := bucket.OpenDownloadStream(id)
+	if err == gridfs.ErrFileNotFound {
+		return nil, ErrBlobNotFound
+	}
+	return stream, err
+}
+
+func (store *gridFSBlobStore) DeleteBlob(id primitive.ObjectID) error {
+	bucket, err := store.bucket()
+	if err != nil {
+		return err
+	}
+
+	err = bucket.Delete(id)
+	if err == gridfs.ErrFileNotFound {
+		return nil
+	}
+	return err
+}
+
+// bucket opens a new bucket for every operation because buckets hold per call deadlines.
+func (store *gridFSBlobStore) bucket() (*gridfs.Bucket, error) {
+	return gridfs.NewBucket(store.databaseProvider.GetDB(), options.GridFSBucket().SetName(attachmentsBucket))
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (counter *countingReader) Read(p []byte) (int, error) {
+	n, err := counter.reader.Read(p)
+	counter.count += int64(n)
+	return n, err
+}
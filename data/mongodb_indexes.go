@@ -0,0 +1,82 @@
+package data
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ensureIndexes creates the indexes backing the paged collection queries and search. The
+// parent id comes first so that a page of one board or list never scans its siblings, and
+// the _id tie breaker makes the sort order of the cursor fully covered.
+func (provider *mongoDBProvider) ensureIndexes() {
+	indexes := map[*mongo.Collection][]mongo.IndexModel{
+		provider.usersCollection: {
+			sortIndex("created_ts"),
+			sortIndex("username"),
+			sortIndex("name"),
+			{Keys: bson.D{{Key: "calendar_token_hash", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+		},
+		provider.boardsCollection: {
+			sortIndex("owner_id", "created_ts"),
+			sortIndex("members.user_id", "created_ts"),
+			textIndex(bson.M{"name": 1}),
+		},
+		provider.listsCollection: {
+			sortIndex("board_id", "order"),
+			sortIndex("board_id", "modified_ts"),
+			textIndex(bson.M{"name": 1}),
+		},
+		provider.tasksCollection: {
+			sortIndex("list_id", "order"),
+			sortIndex("list_id", "modified_ts"),
+			sortIndex("list_id", "due_ts"),
+			sortIndex("due_ts"),
+			sortIndex("assignee_ids"),
+			{
+				Keys:    bson.D{{Key: "recurrence.spawned_ts", Value: 1}, {Key: "due_ts", Value: 1}, {Key: "_id", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{"recurrence": bson.M{"$exists": true}}),
+			},
+			textIndex(bson.M{"name": 10, "content": 1}),
+		},
+		provider.commentsCollection: {
+			sortIndex("task_id", "created_ts"),
+		},
+		provider.activitiesCollection: {
+			sortIndex("board_id", "created_ts"),
+		},
+		provider.webhooksCollection: {
+			sortIndex("board_id", "created_ts"),
+		},
+		provider.deliveriesCollection: {
+			sortIndex("webhook_id", "created_ts"),
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_ts", Value: 1}}},
+		},
+	}
+
+	for collection, models := range indexes {
+		if _, err := collection.Indexes().CreateMany(provider.mongoContext, models); err != nil {
+			fmt.Printf("Creating indexes on %s ERROR: %v\n", collection.Name(), err)
+		}
+	}
+}
+
+func sortIndex(fields ...string) mongo.IndexModel {
+	keys := bson.D{}
+	for _, field := range fields {
+		keys = append(keys, bson.E{Key: field, Value: 1})
+	}
+	keys = append(keys, bson.E{Key: "_id", Value: 1})
+	return mongo.IndexModel{Keys: keys}
+}
+
+// textIndex indexes the weighted fields for $text search. A collection can only have one
+// text index, so it is given a fixed name.
+func textIndex(weights bson.M) mongo.IndexModel {
+	keys := bson.D{}
+	for field := range weights {
+		keys = append(keys, bson.E{Key: field, Value: "text"})
+	}
+	return mongo.IndexModel{Keys: keys, Options: options.Index().SetName("text").SetWeights(weights)}
+}
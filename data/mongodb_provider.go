@@ -10,13 +10,18 @@ import (
 )
 
 type mongoDBProvider struct {
-	mongoContext     context.Context
-	mongoClient      *mongo.Client
-	todoDB           *mongo.Database
-	usersCollection  *mongo.Collection
-	boardsCollection *mongo.Collection
-	listsCollection  *mongo.Collection
-	tasksCollection  *mongo.Collection
+	mongoContext         context.Context
+	mongoClient          *mongo.Client
+	todoDB               *mongo.Database
+	usersCollection      *mongo.Collection
+	boardsCollection     *mongo.Collection
+	listsCollection      *mongo.Collection
+	tasksCollection      *mongo.Collection
+	commentsCollection   *mongo.Collection
+	activitiesCollection *mongo.Collection
+	webhooksCollection   *mongo.Collection
+	deliveriesCollection *mongo.Collection
+	locksCollection      *mongo.Collection
 }
 
 type MongoDBProviderInterface interface {
@@ -27,6 +32,11 @@ type MongoDBProviderInterface interface {
 	GetBoardsCollection() *mongo.Collection
 	GetListsCollection() *mongo.Collection
 	GetTasksCollection() *mongo.Collection
+	GetCommentsCollection() *mongo.Collection
+	GetActivitiesCollection() *mongo.Collection
+	GetWebhooksCollection() *mongo.Collection
+	GetDeliveriesCollection() *mongo.Collection
+	GetLocksCollection() *mongo.Collection
 	Connect(dbURI string)
 }
 
@@ -62,6 +72,26 @@ func (provider *mongoDBProvider) GetTasksCollection() *mongo.Collection {
 	return provider.tasksCollection
 }
 
+func (provider *mongoDBProvider) GetCommentsCollection() *mongo.Collection {
+	return provider.commentsCollection
+}
+
+func (provider *mongoDBProvider) GetActivitiesCollection() *mongo.Collection {
+	return provider.activitiesCollection
+}
+
+func (provider *mongoDBProvider) GetWebhooksCollection() *mongo.Collection {
+	return provider.webhooksCollection
+}
+
+func (provider *mongoDBProvider) GetDeliveriesCollection() *mongo.Collection {
+	return provider.deliveriesCollection
+}
+
+func (provider *mongoDBProvider) GetLocksCollection() *mongo.Collection {
+	return provider.locksCollection
+}
+
 func (provider *mongoDBProvider) Connect(dbURI string) {
 	provider.mongoContext = context.TODO()
 	mongoconn := options.Client().ApplyURI(dbURI)
@@ -81,6 +111,12 @@ func (provider *mongoDBProvider) Connect(dbURI string) {
 	provider.boardsCollection = provider.todoDB.Collection("boards")
 	provider.listsCollection = provider.todoDB.Collection("lists")
 	provider.tasksCollection = provider.todoDB.Collection("tasks")
+	provider.commentsCollection = provider.todoDB.Collection("comments")
+	provider.activitiesCollection = provider.todoDB.Collection("activities")
+	provider.webhooksCollection = provider.todoDB.Collection("webhooks")
+	provider.deliveriesCollection = provider.todoDB.Collection("webhook_deliveries")
+	provider.locksCollection = provider.todoDB.Collection("locks")
+	provider.ensureIndexes()
 
 	fmt.Println("MongoDB successfully connected.")
 }
@@ -0,0 +1,53 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"strings"
+)
+
+type redisProvider struct {
+	redisContext context.Context
+	redisClient  *redis.Client
+}
+
+type RedisProviderInterface interface {
+	GetContext() context.Context
+	GetClient() *redis.Client
+	Connect(redisURI string)
+}
+
+func RedisProvider() *redisProvider {
+	return &redisProvider{}
+}
+
+func (provider *redisProvider) GetContext() context.Context {
+	return provider.redisContext
+}
+
+func (provider *redisProvider) GetClient() *redis.Client {
+	return provider.redisClient
+}
+
+// Connect accepts either a redis:// URL or a plain host:port address.
+func (provider *redisProvider) Connect(redisURI string) {
+	provider.redisContext = context.TODO()
+
+	redisOptions := &redis.Options{Addr: redisURI}
+	if strings.HasPrefix(redisURI, "redis://") || strings.HasPrefix(redisURI, "rediss://") {
+		var parseErr error
+		redisOptions, parseErr = redis.ParseURL(redisURI)
+		if parseErr != nil {
+			panic(parseErr)
+		}
+	}
+
+	provider.redisClient = redis.NewClient(redisOptions)
+
+	if err := provider.redisClient.Ping(provider.redisContext).Err(); err != nil {
+		panic(err)
+	}
+
+	fmt.Println("Redis successfully connected.")
+}
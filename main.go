@@ -8,11 +8,11 @@ import (
 	"github.com/ziflex/lecho/v3"
 	"net/http"
 	"os"
+	"time"
 	"todo/config"
 	"todo/controller"
 	"todo/dao"
 	"todo/data"
-	"todo/model"
 	"todo/service"
 )
 
@@ -30,6 +30,19 @@ func main() {
 	databaseProvider := data.MongoDBProvider()
 	databaseProvider.Connect(conf.DBUri)
 
+	var tokenRevocationDao dao.TokenRevocationDaoInterface
+	var eventBus dao.EventBusInterface
+	if conf.RedisUri != "" {
+		redisProvider := data.RedisProvider()
+		redisProvider.Connect(conf.RedisUri)
+		tokenRevocationDao = dao.TokenRevocationDao(redisProvider)
+		eventBus = dao.RedisEventBus(redisProvider)
+	} else {
+		fmt.Println("No redis configured, keeping revoked tokens in memory and board events local.")
+		tokenRevocationDao = dao.MemoryTokenRevocationDao()
+		eventBus = dao.MemoryEventBus()
+	}
+
 	e := echo.New()
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowCredentials: true,
@@ -45,6 +58,7 @@ func main() {
 	e.Logger = logger
 
 	e.Use(middleware.RequestID())
+	e.Use(controller.RedactCalendarToken)
 	e.Use(lecho.Middleware(lecho.Config{
 		Logger: logger,
 	}))
@@ -55,46 +69,122 @@ func main() {
 	// Routes
 	e.GET("/api/healthcheck", healthcheck)
 
+	var blobStore data.BlobStoreInterface
+	if conf.AttachmentStore == "gridfs" {
+		blobStore = data.GridFSBlobStore(databaseProvider)
+	} else {
+		attachmentDir := conf.AttachmentDir
+		if attachmentDir == "" {
+			attachmentDir = "./attachments"
+		}
+		blobStore = data.FilesystemBlobStore(attachmentDir)
+	}
+
+	maxAttachmentSize := conf.MaxAttachmentSize
+	if maxAttachmentSize <= 0 {
+		maxAttachmentSize = 10 << 20
+	}
+
+	activityDao := dao.ActivityDao(databaseProvider)
+	webhookDao := dao.WebhookDao(databaseProvider)
+	webhookService := service.WebhookService(webhookDao, conf.WebhookAllowedNetworks)
+	activityService := service.ActivityService(activityDao, eventBus, webhookService)
+
 	userDao := dao.UserDao(databaseProvider)
 	listDao := dao.ListDao(databaseProvider)
 	taskDao := dao.TaskDao(databaseProvider)
-	userService := service.UserService(userDao)
-	authService := service.AuthService(userService)
-	tasksService := service.TaskService(taskDao)
-	listsService := service.ListService(listDao, tasksService)
+	commentDao := dao.CommentDao(databaseProvider)
+	commentService := service.CommentService(commentDao, userDao, activityService)
+	tasksService := service.TaskService(taskDao, listDao, commentService, activityService, blobStore)
+	listsService := service.ListService(listDao, tasksService, activityService)
 
 	boardDao := dao.BoardDao(databaseProvider)
-	boardsService := service.BoardService(boardDao, listsService)
-	boardsController := controller.BoardsController(boardsService, authService)
+	boardsService := service.BoardService(boardDao, listsService, activityService, activityDao, webhookDao)
+	userService := service.UserService(userDao, boardsService, tasksService)
+	authService := service.AuthService(userService, tokenRevocationDao)
+	trashService := service.TrashService(boardsService, listsService, tasksService)
+	searchService := service.SearchService(boardsService, listsService, tasksService)
+	checklistService := service.ChecklistService(tasksService)
+	attachmentService := service.AttachmentService(tasksService, blobStore, maxAttachmentSize)
+	boardExportService := service.BoardExportService(boardsService, listsService, tasksService)
+	templateService := service.TemplateService(boardsService, listsService, boardExportService)
+	trelloImportService := service.TrelloImportService(boardsService, listsService, tasksService, commentService)
+	agendaService := service.AgendaService(boardsService, listsService, tasksService, userService)
+	recurrenceScheduler := service.RecurrenceScheduler(tasksService, listsService, boardsService, dao.LeaderLockDao(databaseProvider), service.SystemClock())
+	boardAuthorizer := controller.BoardAuthorizer(authService, boardsService, listsService, tasksService)
+
+	boardsController := controller.BoardsController(boardsService, authService, userService, templateService, boardAuthorizer)
 	boardsController.RegisterBoardsRoutes(e)
 
+	boardExportsController := controller.BoardExportsController(boardExportService, trelloImportService, authService, boardAuthorizer)
+	boardExportsController.RegisterBoardExportsRoutes(e)
+
+	templatesController := controller.TemplatesController(templateService, authService)
+	templatesController.RegisterTemplatesRoutes(e)
+
 	usersController := controller.UsersController(userService, authService)
 	usersController.RegisterUserRoutes(e)
 
 	authController := controller.AuthController(userService, authService)
 	authController.RegisterLoginRoutes(e)
 
-	listsController := controller.ListsController(listsService, authService, boardsService)
+	listsController := controller.ListsController(listsService, boardAuthorizer)
 	listsController.RegisterListsRoutes(e)
 
-	tasksController := controller.TasksController(tasksService, authService, boardsService, listsService)
+	tasksController := controller.TasksController(tasksService, boardsService, listsService, boardAuthorizer)
 	tasksController.RegisterTasksRoutes(e)
 
+	checklistsController := controller.ChecklistsController(checklistService, boardAuthorizer)
+	checklistsController.RegisterChecklistsRoutes(e)
+
+	attachmentsController := controller.AttachmentsController(attachmentService, boardAuthorizer)
+	attachmentsController.RegisterAttachmentsRoutes(e)
+
+	commentsController := controller.CommentsController(commentService, boardAuthorizer)
+	commentsController.RegisterCommentsRoutes(e)
+
+	activitiesController := controller.ActivitiesController(activityService, boardAuthorizer)
+	activitiesController.RegisterActivitiesRoutes(e)
+
+	eventsController := controller.EventsController(activityService, boardsService, authService, conf.WebsocketAllowedOrigins, boardAuthorizer)
+	eventsController.RegisterEventsRoutes(e)
+
+	webhooksController := controller.WebhooksController(webhookService, boardAuthorizer)
+	webhooksController.RegisterWebhooksRoutes(e)
+
+	trashController := controller.TrashController(trashService, authService)
+	trashController.RegisterTrashRoutes(e)
+
+	searchController := controller.SearchController(searchService, authService)
+	searchController.RegisterSearchRoutes(e)
+
+	meController := controller.MeController(agendaService, authService)
+	meController.RegisterMeRoutes(e)
+
+	calendarsController := controller.CalendarsController(agendaService, userService, authService, boardAuthorizer)
+	calendarsController.RegisterCalendarsRoutes(e)
+
+	trashRetention := conf.TrashRetention
+	if trashRetention <= 0 {
+		trashRetention = 30 * 24 * time.Hour
+	}
+	go trashService.PurgeTrashPeriodically(trashRetention, time.Hour)
+	go webhookService.DeliverWebhooksPeriodically(5 * time.Second)
+	go recurrenceScheduler.ScheduleRecurrencesPeriodically(time.Minute)
+
 	e.Use(middleware.JWTWithConfig(middleware.JWTConfig{
-		Claims:                  &model.Claims{},
-		SigningKey:              []byte(authService.GetJWTSecret()),
+		ParseTokenFunc:          authService.ParseAccessToken,
 		TokenLookup:             "cookie:access-token,header:Authorization",
 		ErrorHandlerWithContext: authController.JWTErrorChecker,
 		Skipper: func(c echo.Context) bool {
-			if c.Request().URL.Path == "/login" {
+			switch c.Request().URL.Path {
+			case "/login", "/auth/refresh", "/auth/logout":
 				return true
 			}
-			return false
+			return controller.IsCalendarFeed(c)
 		},
 	}))
 
-	e.Use(authController.TokenRefresherMiddleware)
-
 	// Start server
 	e.Logger.Fatal(e.Start(":1323"))
 }
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	ActivityCreate  = "create"
+	ActivityUpdate  = "update"
+	ActivityDelete  = "delete"
+	ActivityRestore = "restore"
+	ActivityMove    = "move"
+
+	ActivityBoard   = "board"
+	ActivityList    = "list"
+	ActivityTask    = "task"
+	ActivityComment = "comment"
+)
+
+// Activity records one mutation of a board or of a record on it. Changes holds the
+// fields that differ between the record before and after the mutation, keyed by their
+// JSON name.
+type Activity struct {
+	ID         primitive.ObjectID        `bson:"_id,omitempty" json:"id"`
+	BoardID    primitive.ObjectID        `bson:"board_id" json:"board_id"`
+	ActorID    primitive.ObjectID        `bson:"actor_id" json:"actor_id"`
+	EntityType string                    `bson:"entity_type" json:"entity_type"`
+	EntityID   primitive.ObjectID        `bson:"entity_id" json:"entity_id"`
+	Action     string                    `bson:"action" json:"action"`
+	Changes    map[string]ActivityChange `bson:"changes,omitempty" json:"changes,omitempty"`
+	RequestID  string                    `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	CreatedTS  time.Time                 `bson:"created_ts" json:"created_ts"`
+}
+
+type ActivityChange struct {
+	Before interface{} `bson:"before" json:"before"`
+	After  interface{} `bson:"after" json:"after"`
+}
+
+// Actor is who a mutation is attributed to and the request it was made in. The zero Actor
+// stands for the server itself, such as the recurrence scheduler.
+type Actor struct {
+	UserID    primitive.ObjectID
+	RequestID string
+}
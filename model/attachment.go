@@ -0,0 +1,17 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Attachment describes a file uploaded to a task. The content is kept in the blob store
+// under the attachment id.
+type Attachment struct {
+	ID          primitive.ObjectID `bson:"id" json:"id"`
+	Filename    string             `bson:"filename" json:"filename"`
+	ContentType string             `bson:"content_type" json:"content_type"`
+	Size        int64              `bson:"size" json:"size"`
+	UploaderID  primitive.ObjectID `bson:"uploader_id" json:"uploader_id"`
+	CreatedTS   time.Time          `bson:"created_ts" json:"created_ts"`
+}
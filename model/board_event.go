@@ -0,0 +1,20 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// BoardEvent tells the clients watching a board that a list or task on it changed. Type
+// combines the entity type with the past tense of the action, such as "task.moved". Data
+// holds the record after the change and is empty for deletions.
+type BoardEvent struct {
+	Type       string                 `json:"type"`
+	BoardID    primitive.ObjectID     `json:"board_id"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   primitive.ObjectID     `json:"entity_id"`
+	ActorID    primitive.ObjectID     `json:"actor_id"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	CreatedTS  time.Time              `json:"created_ts"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// BoardExport is the portable form of a board, its lists in order and their tasks. The
+// ids of labels only tie tasks to the labels of the board and are replaced on import.
+type BoardExport struct {
+	Name       string       `json:"name"`
+	Labels     []BoardLabel `json:"labels"`
+	Lists      []ListExport `json:"lists"`
+	ExportedTS time.Time    `json:"exported_ts"`
+}
+
+type ListExport struct {
+	Name           string       `json:"name"`
+	CompletesTasks bool         `json:"completes_tasks,omitempty"`
+	Tasks          []TaskExport `json:"tasks"`
+}
+
+type TaskExport struct {
+	Name        string               `json:"name"`
+	Content     string               `json:"content"`
+	LabelIDs    []primitive.ObjectID `json:"label_ids,omitempty"`
+	StartTS     *time.Time           `json:"start_ts,omitempty"`
+	DueTS       *time.Time           `json:"due_ts,omitempty"`
+	Checklists  []Checklist          `json:"checklists,omitempty"`
+	Completed   bool                 `json:"completed,omitempty"`
+	CompletedTS *time.Time           `json:"completed_ts,omitempty"`
+	CompletedBy primitive.ObjectID   `json:"completed_by,omitempty"`
+}
@@ -10,5 +10,10 @@ type Board struct {
 	Name       string             `bson:"name,omitempty" json:"name"`
 	CreatedTS  time.Time          `bson:"created_ts,omitempty" json:"created_ts"`
 	ModifiedTS time.Time          `bson:"modified_ts,omitempty" json:"modified_ts"`
+	DeletedTS  *time.Time         `bson:"deleted_ts,omitempty" json:"deleted_ts,omitempty"`
 	OwnerID    primitive.ObjectID `bson:"owner_id,omitempty" json:"owner_id"`
+	Version    int64              `bson:"version" json:"version"`
+	Members    []BoardMember      `bson:"members,omitempty" json:"members"`
+	Labels     []BoardLabel       `bson:"labels,omitempty" json:"labels"`
+	IsTemplate bool               `bson:"is_template,omitempty" json:"is_template"`
 }
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"regexp"
+)
+
+var labelColorRegex = regexp.MustCompile("^#[0-9a-fA-F]{6}$")
+
+type BoardLabel struct {
+	ID    primitive.ObjectID `bson:"id" json:"id"`
+	Name  string             `bson:"name" json:"name"`
+	Color string             `bson:"color" json:"color"`
+}
+
+// IsValidLabelColor reports whether color is a #rrggbb hex color.
+func IsValidLabelColor(color string) bool {
+	return labelColorRegex.MatchString(color)
+}
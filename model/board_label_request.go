@@ -0,0 +1,8 @@
+package model
+
+type BoardLabelRequest struct {
+	BoardID string `param:"id"`
+	LabelID string `param:"label_id"`
+	Name    string `json:"name"`
+	Color   string `json:"color"`
+}
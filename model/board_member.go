@@ -0,0 +1,34 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	BoardRoleOwner  = "owner"
+	BoardRoleEditor = "editor"
+	BoardRoleViewer = "viewer"
+)
+
+var boardRoleRanks = map[string]int{
+	BoardRoleViewer: 1,
+	BoardRoleEditor: 2,
+	BoardRoleOwner:  3,
+}
+
+type BoardMember struct {
+	UserID  primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Role    string             `bson:"role" json:"role"`
+	AddedTS time.Time          `bson:"added_ts,omitempty" json:"added_ts"`
+}
+
+func IsValidBoardRole(role string) bool {
+	_, ok := boardRoleRanks[role]
+	return ok
+}
+
+// BoardRoleSatisfies reports whether role grants at least the permissions of required.
+func BoardRoleSatisfies(role string, required string) bool {
+	return IsValidBoardRole(role) && boardRoleRanks[role] >= boardRoleRanks[required]
+}
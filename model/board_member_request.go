@@ -0,0 +1,8 @@
+package model
+
+type BoardMemberRequest struct {
+	BoardID  string `param:"id"`
+	UserID   string `param:"user_id" json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
@@ -0,0 +1,11 @@
+package model
+
+// BoardTemplate is an entry of the template catalogue. Built-in templates have fixed
+// string ids, while boards flagged as templates are listed by their board id.
+type BoardTemplate struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	BuiltIn     bool     `json:"built_in"`
+	Lists       []string `json:"lists"`
+}
@@ -0,0 +1,8 @@
+package model
+
+// CalendarToken is a newly issued calendar feed token. It opens the user's feed at URL and
+// the feed of every board they can view at /boards/:id/calendar.ics with the same token.
+type CalendarToken struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
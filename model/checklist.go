@@ -0,0 +1,45 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type Checklist struct {
+	ID    primitive.ObjectID `bson:"id" json:"id"`
+	Name  string             `bson:"name" json:"name"`
+	Items []ChecklistItem    `bson:"items,omitempty" json:"items"`
+}
+
+type ChecklistItem struct {
+	ID     primitive.ObjectID `bson:"id" json:"id"`
+	Name   string             `bson:"name" json:"name"`
+	Done   bool               `bson:"done" json:"done"`
+	DoneTS *time.Time         `bson:"done_ts,omitempty" json:"done_ts,omitempty"`
+}
+
+// ChecklistProgress counts the checked items over all checklists of a task. It is derived
+// on read and never stored.
+type ChecklistProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// CountChecklistProgress sets the progress of a task that has checklists.
+func (task *Task) CountChecklistProgress() {
+	if len(task.Checklists) == 0 {
+		task.Progress = nil
+		return
+	}
+
+	progress := ChecklistProgress{}
+	for _, checklist := range task.Checklists {
+		for _, item := range checklist.Items {
+			progress.Total++
+			if item.Done {
+				progress.Done++
+			}
+		}
+	}
+	task.Progress = &progress
+}
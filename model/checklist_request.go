@@ -0,0 +1,9 @@
+package model
+
+type ChecklistRequest struct {
+	ChecklistID string  `param:"checklist_id"`
+	ItemID      string  `param:"item_id"`
+	Name        *string `json:"name"`
+	Done        *bool   `json:"done"`
+	Position    *int    `json:"position"`
+}
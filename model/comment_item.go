@@ -0,0 +1,30 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type Comment struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	TaskID     primitive.ObjectID `bson:"task_id" json:"task_id"`
+	AuthorID   primitive.ObjectID `bson:"author_id" json:"author_id"`
+	Content    string             `bson:"content" json:"content"`
+	Mentions   []CommentMention   `bson:"mentions,omitempty" json:"mentions,omitempty"`
+	History    []CommentRevision  `bson:"history,omitempty" json:"history,omitempty"`
+	CreatedTS  time.Time          `bson:"created_ts,omitempty" json:"created_ts"`
+	ModifiedTS time.Time          `bson:"modified_ts,omitempty" json:"modified_ts"`
+	Version    int64              `bson:"version" json:"version"`
+}
+
+// CommentMention is a user named with @username in a comment.
+type CommentMention struct {
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Username string             `bson:"username" json:"username"`
+}
+
+// CommentRevision keeps the content a comment had before an edit.
+type CommentRevision struct {
+	Content  string    `bson:"content" json:"content"`
+	EditedTS time.Time `bson:"edited_ts" json:"edited_ts"`
+}
@@ -0,0 +1,6 @@
+package model
+
+type CommentRequest struct {
+	ID      string `param:"id"`
+	Content string `json:"content"`
+}
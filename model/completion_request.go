@@ -0,0 +1,5 @@
+package model
+
+type CompletionRequest struct {
+	Completed *bool `json:"completed"`
+}
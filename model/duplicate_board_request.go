@@ -0,0 +1,7 @@
+package model
+
+type DuplicateBoardRequest struct {
+	BoardID      string `param:"id"`
+	Name         string `json:"name"`
+	IncludeTasks bool   `json:"include_tasks"`
+}
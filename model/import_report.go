@@ -0,0 +1,23 @@
+package model
+
+// ImportReport sums up an import from another tool: the board that was created, how many
+// records of each kind were imported, and every record or field that was left out.
+type ImportReport struct {
+	Board      *Board        `json:"board"`
+	Lists      int           `json:"lists"`
+	Tasks      int           `json:"tasks"`
+	Labels     int           `json:"labels"`
+	Checklists int           `json:"checklists"`
+	Comments   int           `json:"comments"`
+	Skipped    []ImportIssue `json:"skipped"`
+}
+
+// ImportIssue names a record of the source, and the field of it when only that field was
+// left out, along with the reason.
+type ImportIssue struct {
+	Type     string `json:"type"`
+	SourceID string `json:"source_id"`
+	Name     string `json:"name,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Reason   string `json:"reason"`
+}
@@ -0,0 +1,7 @@
+package model
+
+type MoveRequest struct {
+	BoardID  string `json:"board_id"`
+	ListID   string `json:"list_id"`
+	Position *int   `json:"position"`
+}
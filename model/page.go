@@ -0,0 +1,35 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 500
+)
+
+type PageRequest struct {
+	Limit         int    `query:"limit"`
+	Cursor        string `query:"cursor"`
+	Sort          string `query:"sort"`
+	Name          string `query:"name"`
+	ModifiedSince string `query:"modified_since"`
+}
+
+// Page describes one page of a collection query. SortField is a bson field name, Cursor
+// is the opaque continuation token returned with the previous page.
+type Page struct {
+	Limit         int
+	SortField     string
+	Descending    bool
+	Cursor        string
+	NameContains  string
+	ModifiedSince time.Time
+	LabelIDs      []primitive.ObjectID
+	AssigneeIDs   []primitive.ObjectID
+	// Completed keeps only the completed tasks when true and only the open ones when
+	// false.
+	Completed *bool
+}
@@ -0,0 +1,16 @@
+package model
+
+// Patch is a validated JSON merge patch (RFC 7396) keyed by bson field names. Fields the
+// patch sets to null are listed in Unset.
+type Patch struct {
+	Set   map[string]interface{}
+	Unset []string
+}
+
+func NewPatch() *Patch {
+	return &Patch{Set: make(map[string]interface{})}
+}
+
+func (patch *Patch) IsEmpty() bool {
+	return len(patch.Set) == 0 && len(patch.Unset) == 0
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Recurrence repeats a task. Rule is an RFC 5545 RRULE limited to FREQ=DAILY, WEEKLY or
+// MONTHLY with INTERVAL, BYDAY, UNTIL and COUNT. The next occurrence is created in ListID,
+// or in the list of the task when it is not set. Occurrence counts the tasks of the series
+// up to and including this one.
+type Recurrence struct {
+	Rule       string             `bson:"rule" json:"rule"`
+	ListID     primitive.ObjectID `bson:"list_id,omitempty" json:"list_id,omitempty"`
+	Occurrence int                `bson:"occurrence,omitempty" json:"occurrence,omitempty"`
+	SpawnedTS  *time.Time         `bson:"spawned_ts,omitempty" json:"spawned_ts,omitempty"`
+	NextTaskID primitive.ObjectID `bson:"next_task_id,omitempty" json:"next_task_id,omitempty"`
+}
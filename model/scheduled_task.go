@@ -0,0 +1,19 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type DueTasksRequest struct {
+	DueBefore string `query:"due_before"`
+	Overdue   bool   `query:"overdue"`
+	Limit     int    `query:"limit"`
+}
+
+// ScheduledTask is a task listed outside of its board, with the names of the board and
+// list it is on.
+type ScheduledTask struct {
+	Task
+	BoardID   primitive.ObjectID `json:"board_id"`
+	BoardName string             `json:"board_name"`
+	ListName  string             `json:"list_name"`
+	Assignees []UserSummary      `json:"assignees,omitempty"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	SearchHitBoard = "board"
+	SearchHitList  = "list"
+	SearchHitTask  = "task"
+
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 200
+)
+
+type SearchRequest struct {
+	Query string `query:"q"`
+	Limit int    `query:"limit"`
+}
+
+// SearchHit is one record matching a search, with the ids and names of the board and list
+// it belongs to so that clients can link to it. Path is the API path of the record.
+type SearchHit struct {
+	Type      string              `json:"type"`
+	ID        primitive.ObjectID  `json:"id"`
+	Name      string              `json:"name"`
+	Score     float64             `json:"score"`
+	BoardID   primitive.ObjectID  `json:"board_id"`
+	BoardName string              `json:"board_name"`
+	ListID    *primitive.ObjectID `json:"list_id,omitempty"`
+	ListName  string              `json:"list_name,omitempty"`
+	Path      string              `json:"path"`
+}
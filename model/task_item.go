@@ -6,11 +6,24 @@ import (
 )
 
 type Task struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Name       string             `bson:"name,omitempty" json:"name,omitempty"`
-	Order      int32              `bson:"order,omitempty" json:"order,omitempty"`
-	Content    string             `bson:"content,omitempty" json:"content,omitempty"`
-	CreatedTS  time.Time          `bson:"created_ts,omitempty" json:"created_ts"`
-	ModifiedTS time.Time          `bson:"modified_ts,omitempty" json:"modified_ts"`
-	ListID     primitive.ObjectID `bson:"list_id,omitempty" json:"list_id,omitempty"`
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	Name        string               `bson:"name,omitempty" json:"name,omitempty"`
+	Order       int32                `bson:"order,omitempty" json:"order,omitempty"`
+	Content     string               `bson:"content,omitempty" json:"content,omitempty"`
+	CreatedTS   time.Time            `bson:"created_ts,omitempty" json:"created_ts"`
+	ModifiedTS  time.Time            `bson:"modified_ts,omitempty" json:"modified_ts"`
+	DeletedTS   *time.Time           `bson:"deleted_ts,omitempty" json:"deleted_ts,omitempty"`
+	StartTS     *time.Time           `bson:"start_ts,omitempty" json:"start_ts,omitempty"`
+	DueTS       *time.Time           `bson:"due_ts,omitempty" json:"due_ts,omitempty"`
+	ListID      primitive.ObjectID   `bson:"list_id,omitempty" json:"list_id,omitempty"`
+	LabelIDs    []primitive.ObjectID `bson:"label_ids,omitempty" json:"label_ids,omitempty"`
+	AssigneeIDs []primitive.ObjectID `bson:"assignee_ids,omitempty" json:"assignee_ids,omitempty"`
+	Checklists  []Checklist          `bson:"checklists,omitempty" json:"checklists,omitempty"`
+	Attachments []Attachment         `bson:"attachments,omitempty" json:"attachments,omitempty"`
+	Recurrence  *Recurrence          `bson:"recurrence,omitempty" json:"recurrence,omitempty"`
+	Completed   bool                 `bson:"completed,omitempty" json:"completed"`
+	CompletedTS *time.Time           `bson:"completed_ts,omitempty" json:"completed_ts,omitempty"`
+	CompletedBy primitive.ObjectID   `bson:"completed_by,omitempty" json:"completed_by,omitempty"`
+	Progress    *ChecklistProgress   `bson:"-" json:"checklist_progress,omitempty"`
+	Version     int64                `bson:"version" json:"version"`
 }
@@ -1,10 +1,15 @@
 package model
 
+import "time"
+
 type TaskRequest struct {
-	ID      string `param:"id" query:"id"`
-	Name    string `json:"name,omitempty"`
-	Content string `json:"content,omitempty"`
-	Order   int32  `json:"order,omitempty"`
-	ListID  string `param:"list_id" query:"list_id"`
-	BoardID string `param:"board_id" query:"board_id"`
+	ID         string      `param:"id" query:"id"`
+	Name       string      `json:"name,omitempty"`
+	Content    string      `json:"content,omitempty"`
+	Order      int32       `json:"order,omitempty"`
+	StartTS    *time.Time  `json:"start_ts,omitempty"`
+	DueTS      *time.Time  `json:"due_ts,omitempty"`
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+	ListID     string      `param:"list_id" query:"list_id"`
+	BoardID    string      `param:"board_id" query:"board_id"`
 }
@@ -0,0 +1,7 @@
+package model
+
+type Trash struct {
+	Boards []Board     `json:"boards"`
+	Lists  []BoardList `json:"lists"`
+	Tasks  []Task      `json:"tasks"`
+}
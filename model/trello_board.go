@@ -0,0 +1,85 @@
+package model
+
+import "time"
+
+// TrelloBoard holds the parts of a Trello board export that are imported, along with the
+// fields that are only read to report them as not imported.
+type TrelloBoard struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Desc         string            `json:"desc"`
+	Labels       []TrelloLabel     `json:"labels"`
+	Lists        []TrelloList      `json:"lists"`
+	Cards        []TrelloCard      `json:"cards"`
+	Checklists   []TrelloChecklist `json:"checklists"`
+	Actions      []TrelloAction    `json:"actions"`
+	Members      []TrelloMember    `json:"members"`
+	CustomFields []interface{}     `json:"customFields"`
+}
+
+type TrelloLabel struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type TrelloList struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Closed bool    `json:"closed"`
+	Pos    float64 `json:"pos"`
+}
+
+type TrelloCard struct {
+	ID               string        `json:"id"`
+	Name             string        `json:"name"`
+	Desc             string        `json:"desc"`
+	Closed           bool          `json:"closed"`
+	IDList           string        `json:"idList"`
+	Pos              float64       `json:"pos"`
+	Start            *time.Time    `json:"start"`
+	Due              *time.Time    `json:"due"`
+	DueComplete      bool          `json:"dueComplete"`
+	IDLabels         []string      `json:"idLabels"`
+	IDMembers        []string      `json:"idMembers"`
+	Attachments      []interface{} `json:"attachments"`
+	CustomFieldItems []interface{} `json:"customFieldItems"`
+}
+
+type TrelloChecklist struct {
+	ID         string            `json:"id"`
+	IDCard     string            `json:"idCard"`
+	Name       string            `json:"name"`
+	Pos        float64           `json:"pos"`
+	CheckItems []TrelloCheckItem `json:"checkItems"`
+}
+
+type TrelloCheckItem struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	State string  `json:"state"`
+	Pos   float64 `json:"pos"`
+}
+
+// TrelloAction is an entry of the board's action log, of which only comments on cards
+// are imported.
+type TrelloAction struct {
+	ID            string           `json:"id"`
+	Type          string           `json:"type"`
+	Date          time.Time        `json:"date"`
+	Data          TrelloActionData `json:"data"`
+	MemberCreator TrelloMember     `json:"memberCreator"`
+}
+
+type TrelloActionData struct {
+	Text string `json:"text"`
+	Card struct {
+		ID string `json:"id"`
+	} `json:"card"`
+}
+
+type TrelloMember struct {
+	ID       string `json:"id"`
+	FullName string `json:"fullName"`
+	Username string `json:"username"`
+}
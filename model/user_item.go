@@ -6,12 +6,13 @@ import (
 )
 
 type User struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Name        string             `bson:"name,omitempty" json:"name,omitempty"`
-	Username    string             `bson:"username,omitempty" json:"username,omitempty"`
-	Password    string             `bson:"password,omitempty" json:"-"`
-	Email       string             `bson:"email,omitempty" json:"email,omitempty"`
-	CreatedTS   time.Time          `bson:"created_ts,omitempty" json:"created_ts"`
-	LastLoginTS time.Time          `bson:"last_login_ts,omitempty" json:"last_login_ts"`
-	IsAdmin     bool               `bson:"is_admin" json:"is_admin,omitempty"`
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name              string             `bson:"name,omitempty" json:"name,omitempty"`
+	Username          string             `bson:"username,omitempty" json:"username,omitempty"`
+	Password          string             `bson:"password,omitempty" json:"-"`
+	Email             string             `bson:"email,omitempty" json:"email,omitempty"`
+	CreatedTS         time.Time          `bson:"created_ts,omitempty" json:"created_ts"`
+	LastLoginTS       time.Time          `bson:"last_login_ts,omitempty" json:"last_login_ts"`
+	IsAdmin           bool               `bson:"is_admin" json:"is_admin,omitempty"`
+	CalendarTokenHash string             `bson:"calendar_token_hash,omitempty" json:"-"`
 }
@@ -0,0 +1,10 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// UserSummary is the part of a user that is shown to other members of a board.
+type UserSummary struct {
+	ID       primitive.ObjectID `bson:"_id" json:"id"`
+	Name     string             `bson:"name,omitempty" json:"name,omitempty"`
+	Username string             `bson:"username,omitempty" json:"username,omitempty"`
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Webhook posts the board events it subscribes to to an URL. The secret signs every
+// delivery. Webhooks are disabled after too many consecutive failed attempts and stay
+// disabled until they are updated with active set again.
+type Webhook struct {
+	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	BoardID             primitive.ObjectID `bson:"board_id" json:"board_id"`
+	URL                 string             `bson:"url" json:"url"`
+	Events              []string           `bson:"events" json:"events"`
+	Secret              string             `bson:"secret" json:"secret"`
+	Active              bool               `bson:"active" json:"active"`
+	ConsecutiveFailures int                `bson:"consecutive_failures" json:"consecutive_failures"`
+	DisabledTS          *time.Time         `bson:"disabled_ts,omitempty" json:"disabled_ts,omitempty"`
+	CreatedTS           time.Time          `bson:"created_ts" json:"created_ts"`
+	ModifiedTS          time.Time          `bson:"modified_ts" json:"modified_ts"`
+}
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event queued for a webhook, along with the outcome of its last
+// attempt. Pending deliveries are attempted again at NextAttemptTS.
+type WebhookDelivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WebhookID      primitive.ObjectID `bson:"webhook_id" json:"webhook_id"`
+	BoardID        primitive.ObjectID `bson:"board_id" json:"board_id"`
+	Event          string             `bson:"event" json:"event"`
+	Payload        string             `bson:"payload" json:"payload"`
+	Status         string             `bson:"status" json:"status"`
+	Attempts       int                `bson:"attempts" json:"attempts"`
+	ResponseStatus int                `bson:"response_status,omitempty" json:"response_status,omitempty"`
+	Error          string             `bson:"error,omitempty" json:"error,omitempty"`
+	NextAttemptTS  *time.Time         `bson:"next_attempt_ts,omitempty" json:"next_attempt_ts,omitempty"`
+	LastAttemptTS  *time.Time         `bson:"last_attempt_ts,omitempty" json:"last_attempt_ts,omitempty"`
+	CreatedTS      time.Time          `bson:"created_ts" json:"created_ts"`
+}
@@ -0,0 +1,8 @@
+package model
+
+type WebhookRequest struct {
+	WebhookID string   `param:"webhook_id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Active    *bool    `json:"active"`
+}
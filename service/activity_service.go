@@ -0,0 +1,144 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+	"todo/dao"
+	"todo/model"
+)
+
+// Snapshot fields that change on every write and carry nothing worth recording.
+var ignoredActivityFields = map[string]bool{
+	"version":            true,
+	"modified_ts":        true,
+	"checklist_progress": true,
+	"history":            true,
+}
+
+// Past tense of the actions, as used in the type of board events.
+var boardEventActions = map[string]string{
+	model.ActivityCreate:  "created",
+	model.ActivityUpdate:  "updated",
+	model.ActivityDelete:  "deleted",
+	model.ActivityRestore: "restored",
+	model.ActivityMove:    "moved",
+}
+
+type ActivityServiceInterface interface {
+	RecordActivity(activity *model.Activity, before, after map[string]interface{}) error
+	GetActivitiesPage(board *model.Board, page *model.Page) ([]model.Activity, string, error)
+	SubscribeBoardEvents(board *model.Board) (<-chan model.BoardEvent, func())
+}
+
+type activityService struct {
+	activityDao    dao.ActivityDaoInterface
+	eventBus       dao.EventBusInterface
+	webhookService WebhookServiceInterface
+}
+
+func ActivityService(activityDao dao.ActivityDaoInterface, eventBus dao.EventBusInterface, webhookService WebhookServiceInterface) *activityService {
+	return &activityService{activityDao, eventBus, webhookService}
+}
+
+// activitySnapshot captures a record the way clients see it, keyed by JSON field name,
+// so that it can be compared with a later snapshot of the same record. Records that fail
+// to marshal yield a nil snapshot.
+func activitySnapshot(record interface{}) map[string]interface{} {
+	if record == nil || reflect.ValueOf(record).Kind() == reflect.Ptr && reflect.ValueOf(record).IsNil() {
+		return nil
+	}
+
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return nil
+	}
+
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(encoded, &snapshot); err != nil {
+		return nil
+	}
+	return snapshot
+}
+
+// recordActivity attributes the activity to the actor and records it. The change has
+// already been written, so a failure to record it is only reported.
+func recordActivity(activityService ActivityServiceInterface, actor model.Actor, activity model.Activity, before, after map[string]interface{}) {
+	activity.ActorID = actor.UserID
+	activity.RequestID = actor.RequestID
+
+	if err := activityService.RecordActivity(&activity, before, after); err != nil {
+		fmt.Printf("failed to record %s of %s %s. %s\n", activity.Action, activity.EntityType, activity.EntityID.Hex(), err)
+	}
+}
+
+// RecordActivity stores the activity with the fields that differ between the before and
+// after snapshots. Either snapshot is nil for creations and deletions. Updates that leave
+// every recorded field untouched are not stored. Changes of the board, its lists and tasks
+// are also queued for the webhooks of the board, and those of lists and tasks published
+// to the clients watching it.
+func (srv *activityService) RecordActivity(activity *model.Activity, before, after map[string]interface{}) error {
+	activity.Changes = activityChanges(before, after)
+	if activity.Action == model.ActivityUpdate && len(activity.Changes) == 0 {
+		return nil
+	}
+
+	activity.CreatedTS = time.Now()
+	if err := srv.activityDao.CreateActivity(activity); err != nil {
+		return err
+	}
+
+	if activity.EntityType == model.ActivityComment {
+		return nil
+	}
+
+	event := &model.BoardEvent{
+		Type:       activity.EntityType + "." + boardEventActions[activity.Action],
+		BoardID:    activity.BoardID,
+		EntityType: activity.EntityType,
+		EntityID:   activity.EntityID,
+		ActorID:    activity.ActorID,
+		RequestID:  activity.RequestID,
+		Data:       after,
+		CreatedTS:  activity.CreatedTS,
+	}
+
+	if err := srv.webhookService.EnqueueEvent(event); err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries : %v", err)
+	}
+
+	if activity.EntityType == model.ActivityBoard {
+		return nil
+	}
+	return srv.eventBus.Publish(event)
+}
+
+func (srv *activityService) GetActivitiesPage(board *model.Board, page *model.Page) ([]model.Activity, string, error) {
+	return srv.activityDao.GetActivitiesPage(board.ID, page)
+}
+
+func (srv *activityService) SubscribeBoardEvents(board *model.Board) (<-chan model.BoardEvent, func()) {
+	return srv.eventBus.Subscribe(board.ID)
+}
+
+func activityChanges(before, after map[string]interface{}) map[string]model.ActivityChange {
+	changes := map[string]model.ActivityChange{}
+	for field, value := range before {
+		if ignoredActivityFields[field] {
+			continue
+		}
+		if !reflect.DeepEqual(value, after[field]) {
+			changes[field] = model.ActivityChange{Before: value, After: after[field]}
+		}
+	}
+	for field, value := range after {
+		if ignoredActivityFields[field] {
+			continue
+		}
+		if _, ok := before[field]; !ok && value != nil {
+			changes[field] = model.ActivityChange{Before: nil, After: value}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+	"todo/model"
+)
+
+type AgendaServiceInterface interface {
+	GetDueTasks(user *model.User, dueBefore *time.Time, limit int) ([]model.ScheduledTask, error)
+	GetAssignedTasks(user *model.User, limit int) ([]model.ScheduledTask, error)
+	GetCalendarTasks(user *model.User, since time.Time, limit int) ([]model.ScheduledTask, error)
+	GetBoardCalendarTasks(board *model.Board, since time.Time, limit int) ([]model.ScheduledTask, error)
+}
+
+type agendaService struct {
+	boardService BoardServiceInterface
+	listService  ListServiceInterface
+	taskService  TaskServiceInterface
+	userService  UserServiceInterface
+}
+
+func AgendaService(boardService BoardServiceInterface, listService ListServiceInterface, taskService TaskServiceInterface,
+	userService UserServiceInterface) *agendaService {
+	return &agendaService{boardService, listService, taskService, userService}
+}
+
+// GetDueTasks lists the tasks with a due date on every board the user is a member of,
+// earliest due date first.
+func (srv *agendaService) GetDueTasks(user *model.User, dueBefore *time.Time, limit int) ([]model.ScheduledTask, error) {
+	boards, lists, err := srv.accessibleLists(user)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := srv.taskService.GetDueTasks(listIdsOf(lists), dueBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scheduledTasks(tasks, boards, lists), nil
+}
+
+// GetAssignedTasks lists the tasks assigned to the user on every board they are a member
+// of, with a summary of every assignee of each task.
+func (srv *agendaService) GetAssignedTasks(user *model.User, limit int) ([]model.ScheduledTask, error) {
+	boards, lists, err := srv.accessibleLists(user)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := srv.taskService.GetAssignedTasks(listIdsOf(lists), user.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := scheduledTasks(tasks, boards, lists)
+
+	assigneeIds := make([]primitive.ObjectID, 0)
+	seen := make(map[primitive.ObjectID]bool)
+	for _, task := range tasks {
+		for _, id := range task.AssigneeIDs {
+			if !seen[id] {
+				seen[id] = true
+				assigneeIds = append(assigneeIds, id)
+			}
+		}
+	}
+
+	summaries, err := srv.userService.GetUserSummaries(assigneeIds)
+	if err != nil {
+		return nil, err
+	}
+
+	summariesById := make(map[primitive.ObjectID]model.UserSummary, len(summaries))
+	for _, summary := range summaries {
+		summariesById[summary.ID] = summary
+	}
+
+	for i := range results {
+		for _, id := range results[i].AssigneeIDs {
+			if summary, ok := summariesById[id]; ok {
+				results[i].Assignees = append(results[i].Assignees, summary)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// GetCalendarTasks lists the tasks due since the given time on every board the user is a
+// member of, earliest due date first.
+func (srv *agendaService) GetCalendarTasks(user *model.User, since time.Time, limit int) ([]model.ScheduledTask, error) {
+	boards, lists, err := srv.accessibleLists(user)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := srv.taskService.GetDueTasksSince(listIdsOf(lists), since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scheduledTasks(tasks, boards, lists), nil
+}
+
+// GetBoardCalendarTasks lists the tasks of the board due since the given time, earliest due
+// date first.
+func (srv *agendaService) GetBoardCalendarTasks(board *model.Board, since time.Time, limit int) ([]model.ScheduledTask, error) {
+	lists, err := srv.listService.GetListsByBoardIds([]primitive.ObjectID{board.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	listsById := make(map[primitive.ObjectID]model.BoardList, len(lists))
+	for _, list := range lists {
+		listsById[list.ID] = list
+	}
+
+	tasks, err := srv.taskService.GetDueTasksSince(listIdsOf(listsById), since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scheduledTasks(tasks, map[primitive.ObjectID]model.Board{board.ID: *board}, listsById), nil
+}
+
+func listIdsOf(lists map[primitive.ObjectID]model.BoardList) []primitive.ObjectID {
+	listIds := make([]primitive.ObjectID, 0, len(lists))
+	for id := range lists {
+		listIds = append(listIds, id)
+	}
+	return listIds
+}
+
+// accessibleLists returns the boards the user is a member of and their active lists, by id.
+func (srv *agendaService) accessibleLists(user *model.User) (map[primitive.ObjectID]model.Board, map[primitive.ObjectID]model.BoardList, error) {
+	boards, err := srv.boardService.GetBoards(user.ID.Hex())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	boardsById := make(map[primitive.ObjectID]model.Board, len(boards))
+	boardIds := make([]primitive.ObjectID, 0, len(boards))
+	for _, board := range boards {
+		boardsById[board.ID] = board
+		boardIds = append(boardIds, board.ID)
+	}
+
+	lists, err := srv.listService.GetListsByBoardIds(boardIds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	listsById := make(map[primitive.ObjectID]model.BoardList, len(lists))
+	for _, list := range lists {
+		listsById[list.ID] = list
+	}
+
+	return boardsById, listsById, nil
+}
+
+func scheduledTasks(tasks []model.Task, boards map[primitive.ObjectID]model.Board, lists map[primitive.ObjectID]model.BoardList) []model.ScheduledTask {
+	results := make([]model.ScheduledTask, 0, len(tasks))
+	for _, task := range tasks {
+		list := lists[task.ListID]
+		results = append(results, model.ScheduledTask{
+			Task:      task,
+			BoardID:   list.BoardID,
+			BoardName: boards[list.BoardID].Name,
+			ListName:  list.Name,
+		})
+	}
+	return results
+}
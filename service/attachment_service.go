@@ -0,0 +1,128 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+	"todo/data"
+	"todo/model"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+)
+
+type AttachmentServiceInterface interface {
+	AddAttachment(task *model.Task, filename string, content io.Reader, actor model.Actor) (*model.Task, error)
+	OpenAttachment(task *model.Task, attachmentId primitive.ObjectID) (model.Attachment, io.ReadCloser, error)
+	DeleteAttachment(task *model.Task, attachmentId primitive.ObjectID, actor model.Actor) (*model.Task, error)
+	MaxAttachmentSize() int64
+}
+
+type attachmentService struct {
+	taskService       TaskServiceInterface
+	blobStore         data.BlobStoreInterface
+	maxAttachmentSize int64
+}
+
+func AttachmentService(taskService TaskServiceInterface, blobStore data.BlobStoreInterface, maxAttachmentSize int64) *attachmentService {
+	return &attachmentService{taskService, blobStore, maxAttachmentSize}
+}
+
+// AddAttachment stores the content uploaded by the actor and records it on the task. The
+// content type is sniffed from the content rather than trusted from the client. The blob
+// is removed again when the task cannot be updated.
+func (srv *attachmentService) AddAttachment(task *model.Task, filename string, content io.Reader, actor model.Actor) (*model.Task, error) {
+	buffered := bufio.NewReaderSize(content, 512)
+	head, err := buffered.Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	attachment := model.Attachment{
+		ID:          primitive.NewObjectID(),
+		Filename:    filepath.Base(filename),
+		ContentType: http.DetectContentType(head),
+		UploaderID:  actor.UserID,
+		CreatedTS:   time.Now(),
+	}
+
+	// One byte more than the limit is read to tell a file of exactly the limit from a larger one.
+	size, err := srv.blobStore.PutBlob(attachment.ID, io.LimitReader(buffered, srv.maxAttachmentSize+1))
+	if err == nil && size > srv.maxAttachmentSize {
+		err = ErrAttachmentTooLarge
+	}
+	if err != nil {
+		srv.deleteBlob(attachment.ID)
+		return nil, err
+	}
+	attachment.Size = size
+
+	task.Attachments = append(task.Attachments, attachment)
+	result, err := srv.taskService.UpdateTask(task, actor)
+	if err != nil {
+		srv.deleteBlob(attachment.ID)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (srv *attachmentService) OpenAttachment(task *model.Task, attachmentId primitive.ObjectID) (model.Attachment, io.ReadCloser, error) {
+	i := findAttachment(task, attachmentId)
+	if i < 0 {
+		return model.Attachment{}, nil, ErrAttachmentNotFound
+	}
+
+	content, err := srv.blobStore.GetBlob(attachmentId)
+	if err == data.ErrBlobNotFound {
+		return model.Attachment{}, nil, ErrAttachmentNotFound
+	}
+	return task.Attachments[i], content, err
+}
+
+// DeleteAttachment removes the attachment from the task before deleting its blob, so that
+// a task never lists an attachment without content.
+func (srv *attachmentService) DeleteAttachment(task *model.Task, attachmentId primitive.ObjectID, actor model.Actor) (*model.Task, error) {
+	i := findAttachment(task, attachmentId)
+	if i < 0 {
+		return nil, ErrAttachmentNotFound
+	}
+	task.Attachments = append(task.Attachments[:i], task.Attachments[i+1:]...)
+
+	result, err := srv.taskService.UpdateTask(task, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := srv.blobStore.DeleteBlob(attachmentId); err != nil {
+		return nil, fmt.Errorf("failed to delete attachment %s : %v", attachmentId.Hex(), err)
+	}
+
+	return result, nil
+}
+
+func (srv *attachmentService) MaxAttachmentSize() int64 {
+	return srv.maxAttachmentSize
+}
+
+func (srv *attachmentService) deleteBlob(id primitive.ObjectID) {
+	if err := srv.blobStore.DeleteBlob(id); err != nil {
+		fmt.Printf("failed to delete attachment %s. %s\n", id.Hex(), err)
+	}
+}
+
+func findAttachment(task *model.Task, attachmentId primitive.ObjectID) int {
+	for i, attachment := range task.Attachments {
+		if attachment.ID == attachmentId {
+			return i
+		}
+	}
+	return -1
+}
@@ -1,21 +1,32 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"net/http"
 	"time"
 	"todo/config"
+	"todo/dao"
 	"todo/model"
 )
 
 const (
 	accessTokenCookieName  = "access-token"
 	refreshTokenCookieName = "refresh-token"
+	userCookieName         = "user"
+)
+
+var (
+	ErrInvalidToken = errors.New("token is invalid")
+	ErrTokenRevoked = errors.New("token has been revoked")
 )
 
 type authService struct {
-	userService UserServiceInterface
+	userService        UserServiceInterface
+	tokenRevocationDao dao.TokenRevocationDaoInterface
 }
 
 type AuthServiceInterface interface {
@@ -24,11 +35,14 @@ type AuthServiceInterface interface {
 	GetAccessTokenCookieName() string
 	GetRefreshTokenCookieName() string
 	GenerateTokensAndSetCookies(user *model.User, c echo.Context) (string, string, error)
+	ParseAccessToken(auth string, c echo.Context) (interface{}, error)
+	RefreshTokensAndSetCookies(refreshToken string, c echo.Context) (string, string, error)
+	RevokeTokensAndClearCookies(refreshToken string, accessToken string, c echo.Context) error
 	GetCurrentUser(ctx echo.Context) (model.User, error)
 }
 
-func AuthService(userService UserServiceInterface) *authService {
-	return &authService{userService}
+func AuthService(userService UserServiceInterface, tokenRevocationDao dao.TokenRevocationDaoInterface) *authService {
+	return &authService{userService, tokenRevocationDao}
 }
 
 func (srv *authService) GetJWTSecret() string {
@@ -57,6 +71,8 @@ func (srv *authService) generateToken(user *model.User, expirationTime time.Time
 	claims := &model.Claims{
 		Username: user.Username,
 		StandardClaims: jwt.StandardClaims{
+			Id:        primitive.NewObjectID().Hex(),
+			IssuedAt:  time.Now().Unix(),
 			ExpiresAt: expirationTime.Unix(),
 		},
 	}
@@ -95,6 +111,112 @@ func (srv *authService) generateRefreshToken(user *model.User) (string, time.Tim
 	return srv.generateToken(user, expirationTime, []byte(srv.GetRefreshJWTSecret()))
 }
 
+// ParseAccessToken is used as the ParseTokenFunc of the JWT middleware so that revoked
+// access tokens are rejected along with expired or tampered ones.
+func (srv *authService) ParseAccessToken(auth string, c echo.Context) (interface{}, error) {
+	return srv.parseToken(auth, []byte(srv.GetJWTSecret()))
+}
+
+func (srv *authService) parseToken(tokenString string, secret []byte) (*jwt.Token, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &model.Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, fmt.Errorf("unexpected jwt signing method=%v", token.Header["alg"])
+		}
+		return secret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*model.Claims)
+	if !ok || !token.Valid || claims.Id == "" {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := srv.tokenRevocationDao.IsTokenRevoked(claims.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	return token, nil
+}
+
+// RefreshTokensAndSetCookies exchanges a valid refresh token for a new token pair. The
+// presented refresh token is claimed so that it can only be used once, even by concurrent
+// requests.
+func (srv *authService) RefreshTokensAndSetCookies(refreshToken string, c echo.Context) (string, string, error) {
+	token, err := srv.parseToken(refreshToken, []byte(srv.GetRefreshJWTSecret()))
+	if err != nil {
+		return "", "", err
+	}
+
+	claims := token.Claims.(*model.Claims)
+	userResult, err := srv.userService.FindUserByUsername(claims.Username)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := srv.claimToken(claims); err != nil {
+		return "", "", err
+	}
+
+	return srv.GenerateTokensAndSetCookies(&userResult, c)
+}
+
+// RevokeTokensAndClearCookies signs out the holder of a valid refresh token: the refresh
+// token is claimed, the access token is revoked as well when it is still valid and belongs
+// to the same user, and the auth cookies are expired. Logging out does not need a valid
+// access token, so that a session whose access token expired can still end.
+func (srv *authService) RevokeTokensAndClearCookies(refreshToken string, accessToken string, c echo.Context) error {
+	token, err := srv.parseToken(refreshToken, []byte(srv.GetRefreshJWTSecret()))
+	if err != nil {
+		return err
+	}
+
+	claims := token.Claims.(*model.Claims)
+	if err := srv.claimToken(claims); err != nil {
+		return err
+	}
+
+	if accessToken != "" {
+		token, err := srv.parseToken(accessToken, []byte(srv.GetJWTSecret()))
+		if err == nil && token.Claims.(*model.Claims).Username == claims.Username {
+			if err := srv.revokeToken(token.Claims.(*model.Claims)); err != nil {
+				return err
+			}
+		}
+	}
+
+	srv.clearCookie(accessTokenCookieName, true, c)
+	srv.clearCookie(refreshTokenCookieName, true, c)
+	srv.clearCookie(userCookieName, false, c)
+
+	return nil
+}
+
+func (srv *authService) revokeToken(claims *model.Claims) error {
+	return srv.tokenRevocationDao.RevokeToken(claims.Id, time.Unix(claims.ExpiresAt, 0))
+}
+
+// claimToken revokes the token, failing with ErrTokenRevoked when another request got to
+// it first.
+func (srv *authService) claimToken(claims *model.Claims) error {
+	claimed, err := srv.tokenRevocationDao.ClaimToken(claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
+
 func (srv *authService) setTokenCookie(name, token string, expiration time.Time, c echo.Context) {
 	cookie := new(http.Cookie)
 	cookie.Name = name
@@ -108,13 +230,24 @@ func (srv *authService) setTokenCookie(name, token string, expiration time.Time,
 
 func (srv *authService) setUserCookie(user *model.User, expiration time.Time, c echo.Context) {
 	cookie := new(http.Cookie)
-	cookie.Name = "user"
+	cookie.Name = userCookieName
 	cookie.Value = user.Name
 	cookie.Expires = expiration
 	cookie.Path = "/"
 	c.SetCookie(cookie)
 }
 
+func (srv *authService) clearCookie(name string, httpOnly bool, c echo.Context) {
+	cookie := new(http.Cookie)
+	cookie.Name = name
+	cookie.Value = ""
+	cookie.Expires = time.Unix(0, 0)
+	cookie.MaxAge = -1
+	cookie.Path = "/"
+	cookie.HttpOnly = httpOnly
+	c.SetCookie(cookie)
+}
+
 func (srv *authService) GetCurrentUser(ctx echo.Context) (model.User, error) {
 	user := ctx.Get("user").(*jwt.Token)
 	claims := user.Claims.(*model.Claims)
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"todo/config"
+	"todo/dao"
+	"todo/model"
+)
+
+type memoryUserService struct {
+	UserServiceInterface
+	user model.User
+}
+
+func (srv *memoryUserService) FindUserByUsername(username string) (model.User, error) {
+	if username != srv.user.Username {
+		return model.User{}, errors.New("user not found")
+	}
+	return srv.user, nil
+}
+
+func newTestAuthService(t *testing.T) (*authService, *model.User) {
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{JWTSecretKey: "access-secret", JWTRefreshSecretKey: "refresh-secret"}
+	t.Cleanup(func() { config.AppConfig = previous })
+
+	user := model.User{Username: "ada", Name: "Ada"}
+	return AuthService(&memoryUserService{user: user}, dao.MemoryTokenRevocationDao()), &user
+}
+
+func newTestContext() echo.Context {
+	return echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+}
+
+func TestRefreshTokenCanOnlyBeUsedOnce(t *testing.T) {
+	srv, user := newTestAuthService(t)
+	_, refreshToken, err := srv.GenerateTokensAndSetCookies(user, newTestContext())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, rotated, err := srv.RefreshTokensAndSetCookies(refreshToken, newTestContext())
+	if err != nil || rotated == refreshToken {
+		t.Fatalf("first refresh = %v, rotated %v", err, rotated != refreshToken)
+	}
+
+	if _, _, err := srv.RefreshTokensAndSetCookies(refreshToken, newTestContext()); err != ErrTokenRevoked {
+		t.Errorf("second refresh = %v, want ErrTokenRevoked", err)
+	}
+
+	if _, _, err := srv.RefreshTokensAndSetCookies(rotated, newTestContext()); err != nil {
+		t.Errorf("refresh with the rotated token = %v", err)
+	}
+}
+
+func TestConcurrentRefreshesOnlyOneSucceeds(t *testing.T) {
+	srv, user := newTestAuthService(t)
+	_, refreshToken, err := srv.GenerateTokensAndSetCookies(user, newTestContext())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const attempts = 20
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	succeeded := 0
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := srv.RefreshTokensAndSetCookies(refreshToken, newTestContext()); err == nil {
+				mutex.Lock()
+				succeeded++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Errorf("%d of %d concurrent refreshes succeeded, want 1", succeeded, attempts)
+	}
+}
+
+func TestLogoutRevokesBothTokens(t *testing.T) {
+	srv, user := newTestAuthService(t)
+	accessToken, refreshToken, err := srv.GenerateTokensAndSetCookies(user, newTestContext())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := srv.RevokeTokensAndClearCookies(refreshToken, accessToken, newTestContext()); err != nil {
+		t.Fatalf("logout = %v", err)
+	}
+
+	if _, err := srv.ParseAccessToken(accessToken, newTestContext()); err != ErrTokenRevoked {
+		t.Errorf("access token after logout = %v, want ErrTokenRevoked", err)
+	}
+	if _, _, err := srv.RefreshTokensAndSetCookies(refreshToken, newTestContext()); err != ErrTokenRevoked {
+		t.Errorf("refresh after logout = %v, want ErrTokenRevoked", err)
+	}
+	if err := srv.RevokeTokensAndClearCookies(refreshToken, "", newTestContext()); err == nil {
+		t.Error("second logout with the same refresh token succeeded")
+	}
+}
+
+func TestLogoutNeedsAValidRefreshToken(t *testing.T) {
+	srv, user := newTestAuthService(t)
+	accessToken, _, err := srv.GenerateTokensAndSetCookies(user, newTestContext())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An access token is signed with another secret and does not pass for a refresh token.
+	if err := srv.RevokeTokensAndClearCookies(accessToken, accessToken, newTestContext()); err == nil {
+		t.Fatal("logout with an access token as refresh token succeeded")
+	}
+
+	if _, err := srv.ParseAccessToken(accessToken, newTestContext()); err != nil {
+		t.Errorf("access token revoked by a failed logout: %v", err)
+	}
+}
+
+func TestLogoutKeepsAccessTokensOfOtherUsers(t *testing.T) {
+	srv, user := newTestAuthService(t)
+	_, refreshToken, err := srv.GenerateTokensAndSetCookies(user, newTestContext())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := model.User{Username: "grace"}
+	otherAccessToken, _, err := srv.GenerateTokensAndSetCookies(&other, newTestContext())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := srv.RevokeTokensAndClearCookies(refreshToken, otherAccessToken, newTestContext()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := srv.ParseAccessToken(otherAccessToken, newTestContext()); err != nil {
+		t.Errorf("access token of another user revoked: %v", err)
+	}
+}
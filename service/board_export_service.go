@@ -0,0 +1,216 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+	"todo/model"
+)
+
+var ErrInvalidBoardImport = errors.New("invalid board import")
+
+type BoardExportServiceInterface interface {
+	ExportBoard(board *model.Board) (*model.BoardExport, error)
+	ImportBoard(owner *model.User, export *model.BoardExport, actor model.Actor) (*model.Board, error)
+	DuplicateBoard(owner *model.User, board *model.Board, name string, includeTasks bool, actor model.Actor) (*model.Board, error)
+}
+
+type boardExportService struct {
+	boardService BoardServiceInterface
+	listService  ListServiceInterface
+	taskService  TaskServiceInterface
+}
+
+func BoardExportService(boardService BoardServiceInterface, listService ListServiceInterface, taskService TaskServiceInterface) *boardExportService {
+	return &boardExportService{boardService, listService, taskService}
+}
+
+// ExportBoard collects the active lists of the board by order, and the active tasks of
+// each list by order.
+func (srv *boardExportService) ExportBoard(board *model.Board) (*model.BoardExport, error) {
+	export := &model.BoardExport{
+		Name:       board.Name,
+		Labels:     board.Labels,
+		Lists:      []model.ListExport{},
+		ExportedTS: time.Now(),
+	}
+	if export.Labels == nil {
+		export.Labels = []model.BoardLabel{}
+	}
+
+	lists, err := srv.listService.GetLists(board.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lists of board %s : %v", board.ID.Hex(), err)
+	}
+
+	for _, list := range lists {
+		tasks, err := srv.taskService.GetTasks(list.ID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tasks of list %s : %v", list.ID.Hex(), err)
+		}
+
+		listExport := model.ListExport{Name: list.Name, CompletesTasks: list.CompletesTasks, Tasks: make([]model.TaskExport, 0, len(tasks))}
+		for _, task := range tasks {
+			listExport.Tasks = append(listExport.Tasks, model.TaskExport{
+				Name:        task.Name,
+				Content:     task.Content,
+				LabelIDs:    task.LabelIDs,
+				StartTS:     task.StartTS,
+				DueTS:       task.DueTS,
+				Checklists:  task.Checklists,
+				Completed:   task.Completed,
+				CompletedTS: task.CompletedTS,
+				CompletedBy: task.CompletedBy,
+			})
+		}
+		export.Lists = append(export.Lists, listExport)
+	}
+
+	return export, nil
+}
+
+// ImportBoard creates a board owned by the user from an export, with fresh ids for every
+// record, label and checklist. The whole export is validated before anything is written.
+// Should writing fail part way, the partly imported board is moved to the trash. Every
+// record written is logged as created by the actor.
+func (srv *boardExportService) ImportBoard(owner *model.User, export *model.BoardExport, actor model.Actor) (*model.Board, error) {
+	board := model.Board{Name: TruncateName(export.Name), OwnerID: owner.ID}
+
+	labelIds := map[primitive.ObjectID]primitive.ObjectID{}
+	for _, label := range export.Labels {
+		if !isValidBoardLabel(label.Name, label.Color) {
+			return nil, fmt.Errorf("%w : label %q : %v", ErrInvalidBoardImport, label.Name, ErrInvalidBoardLabel)
+		}
+		if findBoardLabelByName(&board, label.Name, primitive.NilObjectID) >= 0 {
+			return nil, fmt.Errorf("%w : label %q : %v", ErrInvalidBoardImport, label.Name, ErrBoardLabelExists)
+		}
+
+		id := primitive.NewObjectID()
+		labelIds[label.ID] = id
+		board.Labels = append(board.Labels, model.BoardLabel{ID: id, Name: label.Name, Color: label.Color})
+	}
+
+	lists := make([][]model.Task, 0, len(export.Lists))
+	for _, listExport := range export.Lists {
+		tasks := make([]model.Task, 0, len(listExport.Tasks))
+		for _, taskExport := range listExport.Tasks {
+			task, err := importedTask(&taskExport, labelIds, actor)
+			if err != nil {
+				return nil, fmt.Errorf("%w : task %q : %v", ErrInvalidBoardImport, taskExport.Name, err)
+			}
+			task.Order = renumberedOrder(len(tasks))
+			tasks = append(tasks, task)
+		}
+		lists = append(lists, tasks)
+	}
+
+	resultBoard, err := srv.boardService.CreateBoard(&board, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, tasks := range lists {
+		if err := srv.importList(resultBoard, &export.Lists[i], i, tasks, actor); err != nil {
+			if deleteErr := srv.boardService.DeleteBoard(resultBoard, actor); deleteErr != nil {
+				fmt.Printf("failed to trash partly imported board %s. %s\n", resultBoard.ID.Hex(), deleteErr)
+			}
+			return nil, err
+		}
+	}
+
+	return resultBoard, nil
+}
+
+func (srv *boardExportService) importList(board *model.Board, listExport *model.ListExport, index int, tasks []model.Task, actor model.Actor) error {
+	list := model.BoardList{BoardID: board.ID, Name: TruncateName(listExport.Name), Order: renumberedOrder(index), CompletesTasks: listExport.CompletesTasks}
+	resultList, err := srv.listService.CreateList(&list, actor)
+	if err != nil {
+		return fmt.Errorf("failed to import list %q : %v", listExport.Name, err)
+	}
+
+	for i := range tasks {
+		tasks[i].ListID = resultList.ID
+		if _, err := srv.taskService.CreateTask(&tasks[i], resultList, actor); err != nil {
+			return fmt.Errorf("failed to import task %q : %v", tasks[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// DuplicateBoard copies the board with its lists, and their tasks when includeTasks is set,
+// into a new board owned by the user. The copy is named after the original unless a name
+// is given, and is never a template itself.
+func (srv *boardExportService) DuplicateBoard(owner *model.User, board *model.Board, name string, includeTasks bool, actor model.Actor) (*model.Board, error) {
+	export, err := srv.ExportBoard(board)
+	if err != nil {
+		return nil, err
+	}
+
+	export.Name = name
+	if export.Name == "" {
+		export.Name = board.Name + " (copy)"
+	}
+
+	if !includeTasks {
+		for i := range export.Lists {
+			export.Lists[i].Tasks = nil
+		}
+	}
+
+	return srv.ImportBoard(owner, export, actor)
+}
+
+// importedTask validates an exported task and maps its labels to their new ids. Completed
+// tasks keep their completion time, but are completed by the actor as the user ids of the
+// export are not to be trusted.
+func importedTask(taskExport *model.TaskExport, labelIds map[primitive.ObjectID]primitive.ObjectID, actor model.Actor) (model.Task, error) {
+	task := model.Task{
+		Name:    TruncateName(taskExport.Name),
+		Content: taskExport.Content,
+		StartTS: taskExport.StartTS,
+		DueTS:   taskExport.DueTS,
+	}
+
+	if taskExport.Completed && taskExport.CompletedTS != nil {
+		task.Completed = true
+		task.CompletedTS = taskExport.CompletedTS
+		task.CompletedBy = actor.UserID
+	}
+
+	if !validTaskDates(task.StartTS, task.DueTS) {
+		return task, ErrInvalidTaskDates
+	}
+
+	for _, labelId := range taskExport.LabelIDs {
+		id, ok := labelIds[labelId]
+		if !ok {
+			return task, ErrBoardLabelNotFound
+		}
+		if !containsId(task.LabelIDs, id) {
+			task.LabelIDs = append(task.LabelIDs, id)
+		}
+	}
+
+	for _, checklist := range taskExport.Checklists {
+		if !validChecklistName(checklist.Name) {
+			return task, ErrInvalidChecklistName
+		}
+
+		imported := model.Checklist{ID: primitive.NewObjectID(), Name: checklist.Name}
+		for _, item := range checklist.Items {
+			if !validChecklistName(item.Name) {
+				return task, ErrInvalidChecklistName
+			}
+			imported.Items = append(imported.Items, model.ChecklistItem{
+				ID:     primitive.NewObjectID(),
+				Name:   item.Name,
+				Done:   item.Done,
+				DoneTS: item.DoneTS,
+			})
+		}
+		task.Checklists = append(task.Checklists, imported)
+	}
+
+	return task, nil
+}
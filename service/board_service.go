@@ -1,61 +1,211 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
 	"time"
 	"todo/dao"
 	"todo/model"
 )
 
+var (
+	ErrVersionConflict = dao.ErrVersionConflict
+	ErrInvalidCursor   = dao.ErrInvalidCursor
+
+	ErrBoardMemberExists   = errors.New("user is already a member of this board")
+	ErrBoardMemberNotFound = errors.New("user is not a member of this board")
+	ErrBoardOwnerImmutable = errors.New("the board owner's membership cannot be changed")
+	ErrInvalidBoardRole    = errors.New("invalid board role")
+	ErrBoardLabelExists    = errors.New("a label with this name already exists on this board")
+	ErrBoardLabelNotFound  = errors.New("label not found on this board")
+	ErrInvalidBoardLabel   = errors.New("label name is required and color must be a #rrggbb hex color")
+)
+
 type BoardServiceInterface interface {
-	CreateBoard(board *model.Board) (*model.Board, error)
-	DeleteBoard(board *model.Board) error
-	UpdateBoard(board *model.Board) (*model.Board, error)
+	CreateBoard(board *model.Board, actor model.Actor) (*model.Board, error)
+	DeleteBoard(board *model.Board, actor model.Actor) error
+	RestoreBoard(board *model.Board, actor model.Actor) error
+	DeleteBoardsOfUser(userId primitive.ObjectID) error
+	PurgeTrashedBoards(before time.Time) error
+	PatchBoard(board *model.Board, patch *model.Patch, actor model.Actor) (*model.Board, error)
+	UpdateBoard(board *model.Board, actor model.Actor) (*model.Board, error)
 	FindBoardById(id string) (model.Board, error)
+	FindTrashedBoardById(id string) (model.Board, error)
 	FindBoardByUserId(userId string) (model.Board, error)
 	GetBoards(userId string) ([]model.Board, error)
+	GetBoardsPage(userId string, page *model.Page) ([]model.Board, string, error)
+	SearchBoards(boardIds []primitive.ObjectID, text string, limit int) ([]model.SearchHit, error)
+	GetTrashedBoards(userId string) ([]model.Board, error)
+	GetBoardRole(board *model.Board, user *model.User) string
+	AddBoardMember(board *model.Board, userId primitive.ObjectID, role string, actor model.Actor) (*model.Board, error)
+	UpdateBoardMember(board *model.Board, userId primitive.ObjectID, role string, actor model.Actor) (*model.Board, error)
+	RemoveBoardMember(board *model.Board, userId primitive.ObjectID, actor model.Actor) (*model.Board, error)
+	AddBoardLabel(board *model.Board, name string, color string, actor model.Actor) (*model.Board, error)
+	UpdateBoardLabel(board *model.Board, labelId primitive.ObjectID, name string, color string, actor model.Actor) (*model.Board, error)
+	RemoveBoardLabel(board *model.Board, labelId primitive.ObjectID, actor model.Actor) (*model.Board, error)
 }
 
+// boardService records every change of a board, including its members and labels, in the
+// activity of the board, attributed to the actor passed along with the change.
 type boardService struct {
-	boardDao    dao.BoardDaoInterface
-	listService ListServiceInterface
+	boardDao        dao.BoardDaoInterface
+	listService     ListServiceInterface
+	activityService ActivityServiceInterface
+	activityDao     dao.ActivityDaoInterface
+	webhookDao      dao.WebhookDaoInterface
 }
 
-func BoardService(boardDao dao.BoardDaoInterface, listService ListServiceInterface) *boardService {
-	return &boardService{boardDao, listService}
+func BoardService(boardDao dao.BoardDaoInterface, listService ListServiceInterface, activityService ActivityServiceInterface,
+	activityDao dao.ActivityDaoInterface, webhookDao dao.WebhookDaoInterface) *boardService {
+	return &boardService{boardDao, listService, activityService, activityDao, webhookDao}
 }
 
-func (srv *boardService) CreateBoard(board *model.Board) (*model.Board, error) {
+func (srv *boardService) CreateBoard(board *model.Board, actor model.Actor) (*model.Board, error) {
 	board.CreatedTS = time.Now()
-	return srv.boardDao.CreateBoard(board)
+	if findBoardMember(board, board.OwnerID) < 0 {
+		board.Members = append(board.Members, model.BoardMember{
+			UserID:  board.OwnerID,
+			Role:    model.BoardRoleOwner,
+			AddedTS: board.CreatedTS,
+		})
+	}
+
+	result, err := srv.boardDao.CreateBoard(board)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.recordBoardActivity(actor, result.ID, model.ActivityCreate, nil, result)
+	return result, nil
 }
 
-func (srv *boardService) UpdateBoard(board *model.Board) (*model.Board, error) {
+func (srv *boardService) PatchBoard(board *model.Board, patch *model.Patch, actor model.Actor) (*model.Board, error) {
+	before := activitySnapshot(board)
+	patch.Set["modified_ts"] = time.Now()
+	result, err := srv.boardDao.PatchBoard(board, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.recordBoardActivity(actor, board.ID, model.ActivityUpdate, before, result)
+	return result, nil
+}
+
+// UpdateBoard replaces the board with the given record. The activity log compares it with
+// the stored board, as callers edit their copy of the board before passing it in.
+func (srv *boardService) UpdateBoard(board *model.Board, actor model.Actor) (*model.Board, error) {
+	stored, err := srv.boardDao.FindBoardById(board.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
 	board.ModifiedTS = time.Now()
-	return srv.boardDao.UpdateBoard(board)
-}
-
-func (srv *boardService) DeleteBoard(board *model.Board) error {
-	boardErr := srv.boardDao.DeleteBoard(board)
-	if boardErr == nil {
-		lists, err := srv.listService.GetLists(board.ID.Hex())
-		if err == nil {
-			for _, list := range lists {
-				listErr := srv.listService.DeleteList(&list)
-				if listErr != nil {
-					fmt.Printf("failed to delete list. %s", listErr)
-				}
-			}
-		}
+	result, err := srv.boardDao.UpdateBoard(board)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.recordBoardActivity(actor, board.ID, model.ActivityUpdate, activitySnapshot(stored), result)
+	return result, nil
+}
+
+// DeleteBoard moves the board to the trash along with its lists and tasks. They all share
+// the board's deleted_ts so that restoring the board brings back exactly what was
+// trashed with it.
+func (srv *boardService) DeleteBoard(board *model.Board, actor model.Actor) error {
+	deletedTS := time.Now()
+	if err := srv.boardDao.TrashBoard(board, deletedTS); err != nil {
+		return err
+	}
+	srv.recordBoardActivity(actor, board.ID, model.ActivityDelete, activitySnapshot(board), nil)
+
+	if err := srv.listService.TrashListsByBoardIds([]primitive.ObjectID{board.ID}, deletedTS, actor); err != nil {
+		return fmt.Errorf("failed to trash lists of board %s : %v", board.ID.Hex(), err)
 	}
 
-	return boardErr
+	return nil
+}
+
+// RestoreBoard restores a trashed board and the lists and tasks that were trashed with it.
+func (srv *boardService) RestoreBoard(board *model.Board, actor model.Actor) error {
+	if board.DeletedTS == nil {
+		return nil
+	}
+
+	if err := srv.listService.RestoreListsByBoardIds([]primitive.ObjectID{board.ID}, *board.DeletedTS, actor); err != nil {
+		return fmt.Errorf("failed to restore lists of board %s : %v", board.ID.Hex(), err)
+	}
+
+	if err := srv.boardDao.RestoreBoard(board); err != nil {
+		return err
+	}
+
+	restored := *board
+	restored.DeletedTS = nil
+	srv.recordBoardActivity(actor, board.ID, model.ActivityRestore, activitySnapshot(board), &restored)
+	return nil
+}
+
+// PurgeTrashedBoards permanently deletes the boards trashed before the given time along
+// with their lists, tasks, activity and webhooks.
+func (srv *boardService) PurgeTrashedBoards(before time.Time) error {
+	boardIds, err := srv.boardDao.GetTrashedBoardIds(before)
+	if err != nil {
+		return fmt.Errorf("failed to find trashed boards : %v", err)
+	}
+
+	if err := srv.listService.PurgeListsByBoardIds(boardIds); err != nil {
+		return fmt.Errorf("failed to delete lists of trashed boards : %v", err)
+	}
+
+	if err := srv.activityDao.DeleteActivitiesByBoardIds(boardIds); err != nil {
+		return fmt.Errorf("failed to delete activity of trashed boards : %v", err)
+	}
+
+	if err := srv.webhookDao.DeleteWebhooksByBoardIds(boardIds); err != nil {
+		return fmt.Errorf("failed to delete webhooks of trashed boards : %v", err)
+	}
+
+	return srv.boardDao.DeleteBoardsByIds(boardIds)
+}
+
+// DeleteBoardsOfUser deletes every board owned by the user along with their lists, tasks,
+// activity and webhooks, and drops the user from the members of boards shared with them.
+func (srv *boardService) DeleteBoardsOfUser(userId primitive.ObjectID) error {
+	boardIds, err := srv.boardDao.GetBoardIdsByOwnerId(userId)
+	if err != nil {
+		return fmt.Errorf("failed to find boards of user %s : %v", userId.Hex(), err)
+	}
+
+	if err := srv.listService.PurgeListsByBoardIds(boardIds); err != nil {
+		return fmt.Errorf("failed to delete lists of user %s : %v", userId.Hex(), err)
+	}
+
+	if err := srv.activityDao.DeleteActivitiesByBoardIds(boardIds); err != nil {
+		return fmt.Errorf("failed to delete activity of user %s : %v", userId.Hex(), err)
+	}
+
+	if err := srv.webhookDao.DeleteWebhooksByBoardIds(boardIds); err != nil {
+		return fmt.Errorf("failed to delete webhooks of user %s : %v", userId.Hex(), err)
+	}
+
+	if err := srv.boardDao.DeleteBoardsByIds(boardIds); err != nil {
+		return fmt.Errorf("failed to delete boards of user %s : %v", userId.Hex(), err)
+	}
+
+	return srv.boardDao.RemoveMemberFromBoards(userId)
 }
 
 func (srv *boardService) FindBoardById(id string) (model.Board, error) {
 	return srv.boardDao.FindBoardById(id)
 }
 
+func (srv *boardService) FindTrashedBoardById(id string) (model.Board, error) {
+	return srv.boardDao.FindTrashedBoardById(id)
+}
+
 func (srv *boardService) FindBoardByUserId(userId string) (model.Board, error) {
 	return srv.boardDao.FindBoardByUserId(userId)
 }
@@ -63,3 +213,208 @@ func (srv *boardService) FindBoardByUserId(userId string) (model.Board, error) {
 func (srv *boardService) GetBoards(userId string) ([]model.Board, error) {
 	return srv.boardDao.GetBoards(userId)
 }
+
+func (srv *boardService) GetBoardsPage(userId string, page *model.Page) ([]model.Board, string, error) {
+	return srv.boardDao.GetBoardsPage(userId, page)
+}
+
+func (srv *boardService) SearchBoards(boardIds []primitive.ObjectID, text string, limit int) ([]model.SearchHit, error) {
+	return srv.boardDao.SearchBoards(boardIds, text, limit)
+}
+
+func (srv *boardService) GetTrashedBoards(userId string) ([]model.Board, error) {
+	return srv.boardDao.GetTrashedBoards(userId)
+}
+
+// GetBoardRole returns the role the user holds on the board, or an empty string when the
+// user has no access to it. Admins and the board's owner always hold the owner role.
+func (srv *boardService) GetBoardRole(board *model.Board, user *model.User) string {
+	if user.IsAdmin || board.OwnerID == user.ID {
+		return model.BoardRoleOwner
+	}
+
+	if i := findBoardMember(board, user.ID); i >= 0 {
+		return board.Members[i].Role
+	}
+
+	return ""
+}
+
+func (srv *boardService) AddBoardMember(board *model.Board, userId primitive.ObjectID, role string, actor model.Actor) (*model.Board, error) {
+	if !model.IsValidBoardRole(role) {
+		return nil, ErrInvalidBoardRole
+	}
+
+	if board.OwnerID == userId || findBoardMember(board, userId) >= 0 {
+		return nil, ErrBoardMemberExists
+	}
+
+	board.Members = append(board.Members, model.BoardMember{
+		UserID:  userId,
+		Role:    role,
+		AddedTS: time.Now(),
+	})
+
+	return srv.UpdateBoard(board, actor)
+}
+
+func (srv *boardService) UpdateBoardMember(board *model.Board, userId primitive.ObjectID, role string, actor model.Actor) (*model.Board, error) {
+	if !model.IsValidBoardRole(role) {
+		return nil, ErrInvalidBoardRole
+	}
+
+	if board.OwnerID == userId {
+		return nil, ErrBoardOwnerImmutable
+	}
+
+	i := findBoardMember(board, userId)
+	if i < 0 {
+		return nil, ErrBoardMemberNotFound
+	}
+	board.Members[i].Role = role
+
+	return srv.UpdateBoard(board, actor)
+}
+
+// RemoveBoardMember also unassigns the user from the tasks of the board.
+func (srv *boardService) RemoveBoardMember(board *model.Board, userId primitive.ObjectID, actor model.Actor) (*model.Board, error) {
+	if board.OwnerID == userId {
+		return nil, ErrBoardOwnerImmutable
+	}
+
+	i := findBoardMember(board, userId)
+	if i < 0 {
+		return nil, ErrBoardMemberNotFound
+	}
+	board.Members = append(board.Members[:i], board.Members[i+1:]...)
+
+	result, err := srv.UpdateBoard(board, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := srv.listService.RemoveAssigneeFromTasks(board.ID, userId, actor); err != nil {
+		return nil, fmt.Errorf("failed to unassign user %s from tasks : %v", userId.Hex(), err)
+	}
+
+	return result, nil
+}
+
+func (srv *boardService) AddBoardLabel(board *model.Board, name string, color string, actor model.Actor) (*model.Board, error) {
+	if !isValidBoardLabel(name, color) {
+		return nil, ErrInvalidBoardLabel
+	}
+
+	if findBoardLabelByName(board, name, primitive.NilObjectID) >= 0 {
+		return nil, ErrBoardLabelExists
+	}
+
+	board.Labels = append(board.Labels, model.BoardLabel{
+		ID:    primitive.NewObjectID(),
+		Name:  name,
+		Color: color,
+	})
+
+	return srv.UpdateBoard(board, actor)
+}
+
+func (srv *boardService) UpdateBoardLabel(board *model.Board, labelId primitive.ObjectID, name string, color string, actor model.Actor) (*model.Board, error) {
+	if !isValidBoardLabel(name, color) {
+		return nil, ErrInvalidBoardLabel
+	}
+
+	i := FindBoardLabel(board, labelId)
+	if i < 0 {
+		return nil, ErrBoardLabelNotFound
+	}
+
+	if findBoardLabelByName(board, name, labelId) >= 0 {
+		return nil, ErrBoardLabelExists
+	}
+	board.Labels[i].Name = name
+	board.Labels[i].Color = color
+
+	return srv.UpdateBoard(board, actor)
+}
+
+// RemoveBoardLabel deletes the label from the board's catalogue and then from the tasks
+// of the board. A failure in the second step only leaves ids on tasks that no longer
+// resolve to a label.
+func (srv *boardService) RemoveBoardLabel(board *model.Board, labelId primitive.ObjectID, actor model.Actor) (*model.Board, error) {
+	i := FindBoardLabel(board, labelId)
+	if i < 0 {
+		return nil, ErrBoardLabelNotFound
+	}
+	board.Labels = append(board.Labels[:i], board.Labels[i+1:]...)
+
+	result, err := srv.UpdateBoard(board, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := srv.listService.RemoveLabelFromTasks(board.ID, labelId, actor); err != nil {
+		return nil, fmt.Errorf("failed to remove label %s from tasks : %v", labelId.Hex(), err)
+	}
+
+	return result, nil
+}
+
+// recordBoardActivity records a change of the board itself, including its members and
+// labels. before is nil when the board was created, and after when it was trashed.
+func (srv *boardService) recordBoardActivity(actor model.Actor, boardId primitive.ObjectID, action string, before map[string]interface{}, after *model.Board) {
+	recordActivity(srv.activityService, actor, model.Activity{
+		BoardID:    boardId,
+		EntityType: model.ActivityBoard,
+		EntityID:   boardId,
+		Action:     action,
+	}, before, activitySnapshot(after))
+}
+
+func isValidBoardLabel(name string, color string) bool {
+	return strings.TrimSpace(name) != "" && len(name) <= 50 && model.IsValidLabelColor(color)
+}
+
+// FindBoardLabel returns the index of the label in the board's catalogue, or -1.
+func FindBoardLabel(board *model.Board, labelId primitive.ObjectID) int {
+	for i, label := range board.Labels {
+		if label.ID == labelId {
+			return i
+		}
+	}
+	return -1
+}
+
+func findBoardLabelByName(board *model.Board, name string, exceptId primitive.ObjectID) int {
+	for i, label := range board.Labels {
+		if label.ID != exceptId && strings.EqualFold(label.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// IsBoardMember reports whether the user owns the board or has been added to it.
+func IsBoardMember(board *model.Board, userId primitive.ObjectID) bool {
+	return board.OwnerID == userId || findBoardMember(board, userId) >= 0
+}
+
+// boardMemberIds keeps the users that are members of the board, so that tasks are not
+// assigned to someone who has left it or never belonged to it.
+func boardMemberIds(board *model.Board, userIds []primitive.ObjectID) []primitive.ObjectID {
+	var memberIds []primitive.ObjectID
+	for _, userId := range userIds {
+		if IsBoardMember(board, userId) {
+			memberIds = append(memberIds, userId)
+		}
+	}
+	return memberIds
+}
+
+func findBoardMember(board *model.Board, userId primitive.ObjectID) int {
+	for i, member := range board.Members {
+		if member.UserID == userId {
+			return i
+		}
+	}
+	return -1
+}
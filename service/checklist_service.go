@@ -0,0 +1,197 @@
+package service
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+	"todo/model"
+)
+
+var (
+	ErrChecklistNotFound     = errors.New("checklist not found")
+	ErrChecklistItemNotFound = errors.New("checklist item not found")
+	ErrInvalidChecklistName  = errors.New("name is required and must be at most 100 characters")
+)
+
+type ChecklistServiceInterface interface {
+	AddChecklist(task *model.Task, name string, position int, actor model.Actor) (*model.Task, error)
+	RenameChecklist(task *model.Task, checklistId primitive.ObjectID, name string, actor model.Actor) (*model.Task, error)
+	MoveChecklist(task *model.Task, checklistId primitive.ObjectID, position int, actor model.Actor) (*model.Task, error)
+	DeleteChecklist(task *model.Task, checklistId primitive.ObjectID, actor model.Actor) (*model.Task, error)
+	AddChecklistItem(task *model.Task, checklistId primitive.ObjectID, name string, position int, actor model.Actor) (*model.Task, error)
+	UpdateChecklistItem(task *model.Task, checklistId primitive.ObjectID, itemId primitive.ObjectID, name *string, done *bool, actor model.Actor) (*model.Task, error)
+	MoveChecklistItem(task *model.Task, checklistId primitive.ObjectID, itemId primitive.ObjectID, position int, actor model.Actor) (*model.Task, error)
+	DeleteChecklistItem(task *model.Task, checklistId primitive.ObjectID, itemId primitive.ObjectID, actor model.Actor) (*model.Task, error)
+}
+
+// checklistService edits the checklists embedded in a task. Every change rewrites the
+// task with UpdateTask, so concurrent edits of the same task fail with ErrVersionConflict.
+type checklistService struct {
+	taskService TaskServiceInterface
+}
+
+func ChecklistService(taskService TaskServiceInterface) *checklistService {
+	return &checklistService{taskService}
+}
+
+func (srv *checklistService) AddChecklist(task *model.Task, name string, position int, actor model.Actor) (*model.Task, error) {
+	if !validChecklistName(name) {
+		return nil, ErrInvalidChecklistName
+	}
+
+	checklist := model.Checklist{ID: primitive.NewObjectID(), Name: name}
+	position = clampPosition(position, len(task.Checklists))
+	task.Checklists = append(task.Checklists[:position:position], append([]model.Checklist{checklist}, task.Checklists[position:]...)...)
+
+	return srv.taskService.UpdateTask(task, actor)
+}
+
+func (srv *checklistService) RenameChecklist(task *model.Task, checklistId primitive.ObjectID, name string, actor model.Actor) (*model.Task, error) {
+	if !validChecklistName(name) {
+		return nil, ErrInvalidChecklistName
+	}
+
+	i := findChecklist(task, checklistId)
+	if i < 0 {
+		return nil, ErrChecklistNotFound
+	}
+	task.Checklists[i].Name = name
+
+	return srv.taskService.UpdateTask(task, actor)
+}
+
+func (srv *checklistService) MoveChecklist(task *model.Task, checklistId primitive.ObjectID, position int, actor model.Actor) (*model.Task, error) {
+	i := findChecklist(task, checklistId)
+	if i < 0 {
+		return nil, ErrChecklistNotFound
+	}
+
+	checklist := task.Checklists[i]
+	others := append(task.Checklists[:i:i], task.Checklists[i+1:]...)
+	position = clampPosition(position, len(others))
+	task.Checklists = append(others[:position:position], append([]model.Checklist{checklist}, others[position:]...)...)
+
+	return srv.taskService.UpdateTask(task, actor)
+}
+
+func (srv *checklistService) DeleteChecklist(task *model.Task, checklistId primitive.ObjectID, actor model.Actor) (*model.Task, error) {
+	i := findChecklist(task, checklistId)
+	if i < 0 {
+		return nil, ErrChecklistNotFound
+	}
+	task.Checklists = append(task.Checklists[:i], task.Checklists[i+1:]...)
+
+	return srv.taskService.UpdateTask(task, actor)
+}
+
+func (srv *checklistService) AddChecklistItem(task *model.Task, checklistId primitive.ObjectID, name string, position int, actor model.Actor) (*model.Task, error) {
+	if !validChecklistName(name) {
+		return nil, ErrInvalidChecklistName
+	}
+
+	i := findChecklist(task, checklistId)
+	if i < 0 {
+		return nil, ErrChecklistNotFound
+	}
+
+	item := model.ChecklistItem{ID: primitive.NewObjectID(), Name: name}
+	items := task.Checklists[i].Items
+	position = clampPosition(position, len(items))
+	task.Checklists[i].Items = append(items[:position:position], append([]model.ChecklistItem{item}, items[position:]...)...)
+
+	return srv.taskService.UpdateTask(task, actor)
+}
+
+// UpdateChecklistItem renames and checks or unchecks an item. Nil arguments are left as they are.
+func (srv *checklistService) UpdateChecklistItem(task *model.Task, checklistId primitive.ObjectID, itemId primitive.ObjectID,
+	name *string, done *bool, actor model.Actor) (*model.Task, error) {
+	if name != nil && !validChecklistName(*name) {
+		return nil, ErrInvalidChecklistName
+	}
+
+	i, j := findChecklistItem(task, checklistId, itemId)
+	if i < 0 {
+		return nil, ErrChecklistNotFound
+	}
+	if j < 0 {
+		return nil, ErrChecklistItemNotFound
+	}
+
+	item := &task.Checklists[i].Items[j]
+	if name != nil {
+		item.Name = *name
+	}
+	if done != nil && *done != item.Done {
+		item.Done = *done
+		item.DoneTS = nil
+		if item.Done {
+			now := time.Now()
+			item.DoneTS = &now
+		}
+	}
+
+	return srv.taskService.UpdateTask(task, actor)
+}
+
+func (srv *checklistService) MoveChecklistItem(task *model.Task, checklistId primitive.ObjectID, itemId primitive.ObjectID, position int, actor model.Actor) (*model.Task, error) {
+	i, j := findChecklistItem(task, checklistId, itemId)
+	if i < 0 {
+		return nil, ErrChecklistNotFound
+	}
+	if j < 0 {
+		return nil, ErrChecklistItemNotFound
+	}
+
+	items := task.Checklists[i].Items
+	item := items[j]
+	others := append(items[:j:j], items[j+1:]...)
+	position = clampPosition(position, len(others))
+	task.Checklists[i].Items = append(others[:position:position], append([]model.ChecklistItem{item}, others[position:]...)...)
+
+	return srv.taskService.UpdateTask(task, actor)
+}
+
+func (srv *checklistService) DeleteChecklistItem(task *model.Task, checklistId primitive.ObjectID, itemId primitive.ObjectID, actor model.Actor) (*model.Task, error) {
+	i, j := findChecklistItem(task, checklistId, itemId)
+	if i < 0 {
+		return nil, ErrChecklistNotFound
+	}
+	if j < 0 {
+		return nil, ErrChecklistItemNotFound
+	}
+
+	items := task.Checklists[i].Items
+	task.Checklists[i].Items = append(items[:j], items[j+1:]...)
+
+	return srv.taskService.UpdateTask(task, actor)
+}
+
+func validChecklistName(name string) bool {
+	return strings.TrimSpace(name) != "" && len(name) <= 100
+}
+
+func findChecklist(task *model.Task, checklistId primitive.ObjectID) int {
+	for i, checklist := range task.Checklists {
+		if checklist.ID == checklistId {
+			return i
+		}
+	}
+	return -1
+}
+
+// findChecklistItem returns the index of the checklist and of the item in it, -1 for
+// whichever was not found.
+func findChecklistItem(task *model.Task, checklistId primitive.ObjectID, itemId primitive.ObjectID) (int, int) {
+	i := findChecklist(task, checklistId)
+	if i < 0 {
+		return -1, -1
+	}
+
+	for j, item := range task.Checklists[i].Items {
+		if item.ID == itemId {
+			return i, j
+		}
+	}
+	return i, -1
+}
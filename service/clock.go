@@ -0,0 +1,19 @@
+package service
+
+import "time"
+
+// Clock tells the time to the work services do on their own schedule, so that tests can
+// control it.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func SystemClock() *systemClock {
+	return &systemClock{}
+}
+
+func (clock *systemClock) Now() time.Time {
+	return time.Now()
+}
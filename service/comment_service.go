@@ -0,0 +1,159 @@
+package service
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"regexp"
+	"strings"
+	"time"
+	"todo/dao"
+	"todo/model"
+)
+
+const maxCommentLength = 10000
+
+var (
+	ErrInvalidComment = errors.New("comment must not be empty or longer than 10000 characters")
+
+	// MENTION_REGEX matches @username where the username follows USERNAME_REGEX_STRING
+	// and is not part of a longer word such as an email address.
+	MENTION_REGEX = regexp.MustCompile(`(?:^|[^a-zA-Z0-9_.-])@([a-zA-Z0-9]+(?:-[a-zA-Z0-9]+)*)`)
+)
+
+type CommentServiceInterface interface {
+	CreateComment(board *model.Board, task *model.Task, content string, actor model.Actor) (*model.Comment, error)
+	UpdateComment(board *model.Board, comment *model.Comment, content string, actor model.Actor) (*model.Comment, error)
+	DeleteComment(board *model.Board, comment *model.Comment, actor model.Actor) error
+	DeleteCommentsByTaskIds(taskIds []primitive.ObjectID) error
+	FindCommentById(id string) (model.Comment, error)
+	GetComments(taskId primitive.ObjectID) ([]model.Comment, error)
+}
+
+// commentService records every change of a comment in the activity of the board of its
+// task, attributed to the actor passed along with the change.
+type commentService struct {
+	commentDao      dao.CommentDaoInterface
+	userDao         dao.UserDaoInterface
+	activityService ActivityServiceInterface
+}
+
+func CommentService(commentDao dao.CommentDaoInterface, userDao dao.UserDaoInterface, activityService ActivityServiceInterface) *commentService {
+	return &commentService{commentDao, userDao, activityService}
+}
+
+// CreateComment adds a comment written by the actor to the task.
+func (srv *commentService) CreateComment(board *model.Board, task *model.Task, content string, actor model.Actor) (*model.Comment, error) {
+	if !validComment(content) {
+		return nil, ErrInvalidComment
+	}
+
+	now := time.Now()
+	comment := model.Comment{
+		TaskID:     task.ID,
+		AuthorID:   actor.UserID,
+		Content:    content,
+		Mentions:   srv.resolveMentions(board, content),
+		CreatedTS:  now,
+		ModifiedTS: now,
+	}
+
+	result, err := srv.commentDao.CreateComment(&comment)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.recordCommentActivity(actor, board.ID, result.ID, model.ActivityCreate, nil, result)
+	return result, nil
+}
+
+// UpdateComment replaces the content of the comment and keeps the previous content in its
+// history. Mentions are resolved again from the new content.
+func (srv *commentService) UpdateComment(board *model.Board, comment *model.Comment, content string, actor model.Actor) (*model.Comment, error) {
+	if !validComment(content) {
+		return nil, ErrInvalidComment
+	}
+
+	if content == comment.Content {
+		return comment, nil
+	}
+
+	before := activitySnapshot(comment)
+	now := time.Now()
+	comment.History = append(comment.History, model.CommentRevision{
+		Content:  comment.Content,
+		EditedTS: now,
+	})
+	comment.Content = content
+	comment.Mentions = srv.resolveMentions(board, content)
+	comment.ModifiedTS = now
+
+	result, err := srv.commentDao.UpdateComment(comment)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.recordCommentActivity(actor, board.ID, comment.ID, model.ActivityUpdate, before, result)
+	return result, nil
+}
+
+func (srv *commentService) DeleteComment(board *model.Board, comment *model.Comment, actor model.Actor) error {
+	if err := srv.commentDao.DeleteComment(comment); err != nil {
+		return err
+	}
+
+	srv.recordCommentActivity(actor, board.ID, comment.ID, model.ActivityDelete, activitySnapshot(comment), nil)
+	return nil
+}
+
+func (srv *commentService) DeleteCommentsByTaskIds(taskIds []primitive.ObjectID) error {
+	return srv.commentDao.DeleteCommentsByTaskIds(taskIds)
+}
+
+func (srv *commentService) FindCommentById(id string) (model.Comment, error) {
+	return srv.commentDao.FindCommentById(id)
+}
+
+func (srv *commentService) GetComments(taskId primitive.ObjectID) ([]model.Comment, error) {
+	return srv.commentDao.GetComments(taskId)
+}
+
+// resolveMentions returns the members of the board named with @username in the content,
+// once each and in order of first mention. Names of unknown users and of users outside
+// the board are left as plain text.
+func (srv *commentService) resolveMentions(board *model.Board, content string) []model.CommentMention {
+	var mentions []model.CommentMention
+	seen := make(map[string]bool)
+
+	for _, match := range MENTION_REGEX.FindAllStringSubmatch(content, -1) {
+		username := strings.ToLower(match[1])
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+
+		user, err := srv.userDao.FindUserByUsername(match[1])
+		if err != nil || !IsBoardMember(board, user.ID) {
+			continue
+		}
+
+		mentions = append(mentions, model.CommentMention{UserID: user.ID, Username: user.Username})
+	}
+
+	return mentions
+}
+
+// recordCommentActivity records a change of a comment on the board of its task. before is
+// nil when the comment was created, and after when it was deleted.
+func (srv *commentService) recordCommentActivity(actor model.Actor, boardId primitive.ObjectID, commentId primitive.ObjectID, action string,
+	before map[string]interface{}, after *model.Comment) {
+	recordActivity(srv.activityService, actor, model.Activity{
+		BoardID:    boardId,
+		EntityType: model.ActivityComment,
+		EntityID:   commentId,
+		Action:     action,
+	}, before, activitySnapshot(after))
+}
+
+func validComment(content string) bool {
+	return strings.TrimSpace(content) != "" && len(content) <= maxCommentLength
+}
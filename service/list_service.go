@@ -2,59 +2,319 @@ package service
 
 import (
 	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"time"
 	"todo/dao"
 	"todo/model"
 )
 
 type ListServiceInterface interface {
-	CreateList(boardList *model.BoardList) (*model.BoardList, error)
-	DeleteList(boardList *model.BoardList) error
-	UpdateList(boardList *model.BoardList) (*model.BoardList, error)
+	CreateList(boardList *model.BoardList, actor model.Actor) (*model.BoardList, error)
+	DeleteList(boardList *model.BoardList, actor model.Actor) error
+	RestoreList(boardList *model.BoardList, actor model.Actor) error
+	TrashListsByBoardIds(boardIds []primitive.ObjectID, deletedTS time.Time, actor model.Actor) error
+	RestoreListsByBoardIds(boardIds []primitive.ObjectID, deletedTS time.Time, actor model.Actor) error
+	PurgeListsByBoardIds(boardIds []primitive.ObjectID) error
+	PurgeTrashedLists(before time.Time) error
+	RemoveLabelFromTasks(boardId primitive.ObjectID, labelId primitive.ObjectID, actor model.Actor) error
+	RemoveAssigneeFromTasks(boardId primitive.ObjectID, userId primitive.ObjectID, actor model.Actor) error
+	PatchList(boardList *model.BoardList, patch *model.Patch, actor model.Actor) (*model.BoardList, error)
+	UpdateList(boardList *model.BoardList, actor model.Actor) (*model.BoardList, error)
+	MoveList(boardList *model.BoardList, position int, actor model.Actor) (*model.BoardList, error)
 	FindListById(id string) (model.BoardList, error)
+	FindTrashedListById(id string) (model.BoardList, error)
 	GetLists(boardId string) ([]model.BoardList, error)
+	GetListsPage(boardId string, page *model.Page) ([]model.BoardList, string, error)
+	SearchLists(boardIds []primitive.ObjectID, text string, limit int) ([]model.SearchHit, error)
+	GetListsByBoardIds(boardIds []primitive.ObjectID) ([]model.BoardList, error)
+	GetTrashedLists(boardIds []primitive.ObjectID) ([]model.BoardList, error)
 }
 
+// listService records every change of a list in the activity of its board, attributed to
+// the actor passed along with the change.
 type listService struct {
-	listDao     dao.ListDaoInterface
-	taskService TaskServiceInterface
+	listDao         dao.ListDaoInterface
+	taskService     TaskServiceInterface
+	activityService ActivityServiceInterface
 }
 
-func ListService(listDao dao.ListDaoInterface, taskService TaskServiceInterface) *listService {
-	return &listService{listDao, taskService}
+func ListService(listDao dao.ListDaoInterface, taskService TaskServiceInterface, activityService ActivityServiceInterface) *listService {
+	return &listService{listDao, taskService, activityService}
 }
 
-func (srv *listService) CreateList(boardList *model.BoardList) (*model.BoardList, error) {
+func (srv *listService) CreateList(boardList *model.BoardList, actor model.Actor) (*model.BoardList, error) {
 	boardList.CreatedTS = time.Now()
-	return srv.listDao.CreateList(boardList)
+	result, err := srv.listDao.CreateList(boardList)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.recordListActivity(actor, result.BoardID, result.ID, model.ActivityCreate, nil, result)
+	return result, nil
+}
+
+func (srv *listService) PatchList(boardList *model.BoardList, patch *model.Patch, actor model.Actor) (*model.BoardList, error) {
+	before := activitySnapshot(boardList)
+	patch.Set["modified_ts"] = time.Now()
+	result, err := srv.listDao.PatchList(boardList, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.recordListActivity(actor, boardList.BoardID, boardList.ID, model.ActivityUpdate, before, result)
+	return result, nil
+}
+
+// UpdateList replaces the list with the given record. The activity log compares it with
+// the stored list, as callers edit their copy of the list before passing it in.
+func (srv *listService) UpdateList(boardList *model.BoardList, actor model.Actor) (*model.BoardList, error) {
+	stored, err := srv.listDao.FindListById(boardList.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	boardList.ModifiedTS = time.Now()
+	result, err := srv.listDao.UpdateList(boardList)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.recordListActivity(actor, stored.BoardID, stored.ID, model.ActivityUpdate, activitySnapshot(stored), result)
+	return result, nil
 }
 
-func (srv *listService) UpdateList(boardList *model.BoardList) (*model.BoardList, error) {
+// MoveList moves the list to the given zero based position of its board. Out of range
+// positions move the list to the end. Only the moved list is written unless its new
+// neighbours leave no room between their orders, in which case all lists of the board
+// are renumbered in one bulk write.
+func (srv *listService) MoveList(boardList *model.BoardList, position int, actor model.Actor) (*model.BoardList, error) {
+	siblings, err := srv.listDao.GetLists(boardList.BoardID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	before := activitySnapshot(boardList)
+
+	others := make([]model.BoardList, 0, len(siblings))
+	orders := make([]int32, 0, len(siblings))
+	for _, sibling := range siblings {
+		if sibling.ID != boardList.ID {
+			others = append(others, sibling)
+			orders = append(orders, sibling.Order)
+		}
+	}
+	position = clampPosition(position, len(others))
+
 	boardList.ModifiedTS = time.Now()
-	return srv.listDao.UpdateList(boardList)
-}
-
-func (srv *listService) DeleteList(boardList *model.BoardList) error {
-	listErr := srv.listDao.DeleteList(boardList)
-	if listErr == nil {
-		tasks, err := srv.taskService.GetTasks(boardList.ID.Hex())
-		if err == nil {
-			for _, task := range tasks {
-				taskErr := srv.taskService.DeleteTask(&task)
-				if taskErr != nil {
-					fmt.Printf("failed to delete task. %s", listErr)
-				}
-			}
+
+	updates := []model.BoardList{*boardList}
+	if order, ok := orderForPosition(orders, position); ok {
+		updates[0].Order = order
+	} else {
+		updates = make([]model.BoardList, 0, len(others)+1)
+		updates = append(updates, others[:position]...)
+		updates = append(updates, *boardList)
+		updates = append(updates, others[position:]...)
+		for i := range updates {
+			updates[i].Order = renumberedOrder(i)
 		}
 	}
 
-	return listErr
+	if err := srv.listDao.UpdateListPositions(updates); err != nil {
+		return nil, err
+	}
+
+	result, err := srv.listDao.FindListById(boardList.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	srv.recordListActivity(actor, boardList.BoardID, boardList.ID, model.ActivityMove, before, &result)
+	return &result, nil
+}
+
+// DeleteList moves the list to the trash along with its tasks. The tasks share the list's
+// deleted_ts so that restoring the list brings back exactly the tasks trashed with it.
+func (srv *listService) DeleteList(boardList *model.BoardList, actor model.Actor) error {
+	deletedTS := time.Now()
+	if err := srv.listDao.TrashList(boardList, deletedTS); err != nil {
+		return err
+	}
+	srv.recordListActivity(actor, boardList.BoardID, boardList.ID, model.ActivityDelete, activitySnapshot(boardList), nil)
+
+	if err := srv.taskService.TrashTasksByListIds([]primitive.ObjectID{boardList.ID}, deletedTS, actor); err != nil {
+		return fmt.Errorf("failed to trash tasks of list %s : %v", boardList.ID.Hex(), err)
+	}
+
+	return nil
+}
+
+// RestoreList restores a trashed list and the tasks that were trashed with it.
+func (srv *listService) RestoreList(boardList *model.BoardList, actor model.Actor) error {
+	if boardList.DeletedTS == nil {
+		return nil
+	}
+
+	if err := srv.taskService.RestoreTasksByListIds([]primitive.ObjectID{boardList.ID}, *boardList.DeletedTS, actor); err != nil {
+		return fmt.Errorf("failed to restore tasks of list %s : %v", boardList.ID.Hex(), err)
+	}
+
+	if err := srv.listDao.RestoreList(boardList); err != nil {
+		return err
+	}
+
+	srv.recordListActivity(actor, boardList.BoardID, boardList.ID, model.ActivityRestore, activitySnapshot(boardList), restoredList(*boardList))
+	return nil
+}
+
+// TrashListsByBoardIds moves the active lists of the boards to the trash along with their
+// tasks, recording each of them as deleted.
+func (srv *listService) TrashListsByBoardIds(boardIds []primitive.ObjectID, deletedTS time.Time, actor model.Actor) error {
+	listIds, err := srv.listDao.GetListIdsByBoardIds(boardIds)
+	if err != nil {
+		return fmt.Errorf("failed to find lists to trash : %v", err)
+	}
+
+	lists, err := srv.listDao.GetListsByBoardIds(boardIds)
+	if err != nil {
+		return fmt.Errorf("failed to find lists to trash : %v", err)
+	}
+
+	if err := srv.listDao.TrashListsByBoardIds(boardIds, deletedTS); err != nil {
+		return err
+	}
+
+	for _, list := range lists {
+		srv.recordListActivity(actor, list.BoardID, list.ID, model.ActivityDelete, activitySnapshot(list), nil)
+	}
+
+	if err := srv.taskService.TrashTasksByListIds(listIds, deletedTS, actor); err != nil {
+		return fmt.Errorf("failed to trash tasks of lists : %v", err)
+	}
+
+	return nil
+}
+
+// RestoreListsByBoardIds restores the lists and tasks trashed together with their board,
+// recording each of them as restored.
+func (srv *listService) RestoreListsByBoardIds(boardIds []primitive.ObjectID, deletedTS time.Time, actor model.Actor) error {
+	listIds, err := srv.listDao.GetListIdsByBoardIds(boardIds)
+	if err != nil {
+		return fmt.Errorf("failed to find lists to restore : %v", err)
+	}
+
+	lists, err := srv.listDao.GetListsTrashedWith(boardIds, deletedTS)
+	if err != nil {
+		return fmt.Errorf("failed to find lists to restore : %v", err)
+	}
+
+	if err := srv.taskService.RestoreTasksByListIds(listIds, deletedTS, actor); err != nil {
+		return fmt.Errorf("failed to restore tasks of lists : %v", err)
+	}
+
+	if err := srv.listDao.RestoreListsByBoardIds(boardIds, deletedTS); err != nil {
+		return err
+	}
+
+	for _, list := range lists {
+		srv.recordListActivity(actor, list.BoardID, list.ID, model.ActivityRestore, activitySnapshot(list), restoredList(list))
+	}
+
+	return nil
+}
+
+// PurgeListsByBoardIds permanently deletes every list of the boards and their tasks.
+// Tasks go first so that a failure part way never leaves tasks without a list.
+func (srv *listService) PurgeListsByBoardIds(boardIds []primitive.ObjectID) error {
+	listIds, err := srv.listDao.GetListIdsByBoardIds(boardIds)
+	if err != nil {
+		return fmt.Errorf("failed to find lists to delete : %v", err)
+	}
+
+	if err := srv.taskService.PurgeTasksByListIds(listIds); err != nil {
+		return fmt.Errorf("failed to delete tasks of lists : %v", err)
+	}
+
+	return srv.listDao.DeleteListsByBoardIds(boardIds)
+}
+
+// PurgeTrashedLists permanently deletes the lists trashed before the given time along
+// with their tasks.
+func (srv *listService) PurgeTrashedLists(before time.Time) error {
+	listIds, err := srv.listDao.GetTrashedListIds(before)
+	if err != nil {
+		return fmt.Errorf("failed to find trashed lists : %v", err)
+	}
+
+	if err := srv.taskService.PurgeTasksByListIds(listIds); err != nil {
+		return fmt.Errorf("failed to delete tasks of trashed lists : %v", err)
+	}
+
+	return srv.listDao.DeleteListsByIds(listIds)
+}
+
+// RemoveLabelFromTasks takes the label off every task of the board, including the tasks
+// in the trash so that they come back without it.
+func (srv *listService) RemoveLabelFromTasks(boardId primitive.ObjectID, labelId primitive.ObjectID, actor model.Actor) error {
+	listIds, err := srv.listDao.GetListIdsByBoardIds([]primitive.ObjectID{boardId})
+	if err != nil {
+		return err
+	}
+
+	return srv.taskService.RemoveLabelFromTasks(listIds, labelId, actor)
+}
+
+// RemoveAssigneeFromTasks unassigns the user from every task of the board, including the
+// tasks in the trash.
+func (srv *listService) RemoveAssigneeFromTasks(boardId primitive.ObjectID, userId primitive.ObjectID, actor model.Actor) error {
+	listIds, err := srv.listDao.GetListIdsByBoardIds([]primitive.ObjectID{boardId})
+	if err != nil {
+		return err
+	}
+
+	return srv.taskService.RemoveAssigneeFromTasks(listIds, userId, actor)
+}
+
+// recordListActivity records a change of the list on its board. before is nil when the
+// list was created, and after when it was trashed.
+func (srv *listService) recordListActivity(actor model.Actor, boardId primitive.ObjectID, listId primitive.ObjectID, action string,
+	before map[string]interface{}, after *model.BoardList) {
+	recordActivity(srv.activityService, actor, model.Activity{
+		BoardID:    boardId,
+		EntityType: model.ActivityList,
+		EntityID:   listId,
+		Action:     action,
+	}, before, activitySnapshot(after))
+}
+
+func restoredList(boardList model.BoardList) *model.BoardList {
+	boardList.DeletedTS = nil
+	return &boardList
 }
 
 func (srv *listService) FindListById(id string) (model.BoardList, error) {
 	return srv.listDao.FindListById(id)
 }
 
+func (srv *listService) FindTrashedListById(id string) (model.BoardList, error) {
+	return srv.listDao.FindTrashedListById(id)
+}
+
 func (srv *listService) GetLists(boardId string) ([]model.BoardList, error) {
 	return srv.listDao.GetLists(boardId)
 }
+
+func (srv *listService) GetListsPage(boardId string, page *model.Page) ([]model.BoardList, string, error) {
+	return srv.listDao.GetListsPage(boardId, page)
+}
+
+func (srv *listService) SearchLists(boardIds []primitive.ObjectID, text string, limit int) ([]model.SearchHit, error) {
+	return srv.listDao.SearchLists(boardIds, text, limit)
+}
+
+func (srv *listService) GetListsByBoardIds(boardIds []primitive.ObjectID) ([]model.BoardList, error) {
+	return srv.listDao.GetListsByBoardIds(boardIds)
+}
+
+func (srv *listService) GetTrashedLists(boardIds []primitive.ObjectID) ([]model.BoardList, error) {
+	return srv.listDao.GetTrashedLists(boardIds)
+}
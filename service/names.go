@@ -0,0 +1,18 @@
+package service
+
+import "unicode/utf8"
+
+const maxNameLength = 100
+
+// TruncateName applies the length rule for the names of boards, lists and tasks, cutting
+// before the character that crosses it so that the name stays valid UTF-8.
+func TruncateName(name string) string {
+	if len(name) <= maxNameLength {
+		return name
+	}
+	cut := maxNameLength
+	for cut > 0 && !utf8.RuneStart(name[cut]) {
+		cut--
+	}
+	return name[:cut]
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateNameKeepsWholeCharacters(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"short", "short"},
+		{strings.Repeat("a", 100), strings.Repeat("a", 100)},
+		{strings.Repeat("a", 101), strings.Repeat("a", 100)},
+		{strings.Repeat("a", 99) + "é", strings.Repeat("a", 99)},
+		{strings.Repeat("€", 40), strings.Repeat("€", 33)},
+	}
+
+	for _, test := range tests {
+		got := TruncateName(test.name)
+		if got != test.want || !utf8.ValidString(got) {
+			t.Errorf("TruncateName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
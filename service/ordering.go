@@ -0,0 +1,55 @@
+package service
+
+import "math"
+
+// orderSpacing is the gap left between the orders of neighbouring lists and tasks, so
+// that an item can usually be moved between two others by updating only itself.
+const orderSpacing int32 = 1024
+
+// orderForPosition returns the order for an item inserted at position among items with
+// the given ascending orders. It returns false when the neighbours leave no room and the
+// items have to be renumbered with renumberedOrder. The order is never 0, which is how
+// stored items without an order read back.
+func orderForPosition(orders []int32, position int) (int32, bool) {
+	spacing := int64(orderSpacing)
+
+	switch {
+	case len(orders) == 0:
+		return orderSpacing, true
+	case position <= 0:
+		order := int64(orders[0]) - spacing
+		if order == 0 {
+			order -= spacing
+		}
+		return int32(order), order >= math.MinInt32
+	case position >= len(orders):
+		order := int64(orders[len(orders)-1]) + spacing
+		if order == 0 {
+			order += spacing
+		}
+		return int32(order), order <= math.MaxInt32
+	default:
+		prev, next := int64(orders[position-1]), int64(orders[position])
+		order := prev + (next-prev)/2
+		if order == 0 {
+			// Step off 0 towards whichever neighbour leaves room.
+			if next > 1 {
+				order = 1
+			} else {
+				order = -1
+			}
+		}
+		return int32(order), prev < order && order < next
+	}
+}
+
+func renumberedOrder(index int) int32 {
+	return int32(index+1) * orderSpacing
+}
+
+func clampPosition(position int, length int) int {
+	if position < 0 || position > length {
+		return length
+	}
+	return position
+}
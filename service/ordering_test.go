@@ -0,0 +1,225 @@
+package service
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"testing"
+	"todo/dao"
+	"todo/model"
+)
+
+func TestOrderForPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		orders   []int32
+		position int
+		want     int32
+		wantOk   bool
+	}{
+		{"empty", nil, 0, 1024, true},
+		{"empty past the end", nil, 3, 1024, true},
+		{"head", []int32{2048, 3072}, 0, 1024, true},
+		{"head skips 0", []int32{1024, 2048}, 0, -1024, true},
+		{"head at the lower limit", []int32{math.MinInt32 + 10}, 0, 0, false},
+		{"tail", []int32{1024, 2048}, 2, 3072, true},
+		{"tail skips 0", []int32{-1024}, 1, 1024, true},
+		{"tail at the upper limit", []int32{math.MaxInt32 - 10}, 1, 0, false},
+		{"between", []int32{1024, 2048}, 1, 1536, true},
+		{"between across 0", []int32{-4, 1}, 1, -2, true},
+		{"between steps off 0", []int32{-2, 2}, 1, 1, true},
+		{"adjacent", []int32{1, 2}, 1, 0, false},
+		{"adjacent around 0", []int32{-1, 1}, 1, 0, false},
+	}
+
+	for _, test := range tests {
+		order, ok := orderForPosition(test.orders, test.position)
+		if ok != test.wantOk {
+			t.Errorf("%s: ok %v, want %v", test.name, ok, test.wantOk)
+			continue
+		}
+		if ok && order != test.want {
+			t.Errorf("%s: order %d, want %d", test.name, order, test.want)
+		}
+		if ok && order == 0 {
+			t.Errorf("%s: handed out order 0", test.name)
+		}
+	}
+}
+
+func TestClampPosition(t *testing.T) {
+	tests := []struct {
+		position, length, want int
+	}{
+		{0, 0, 0},
+		{-1, 0, 0},
+		{-1, 3, 3},
+		{0, 3, 0},
+		{3, 3, 3},
+		{4, 3, 3},
+	}
+
+	for _, test := range tests {
+		if got := clampPosition(test.position, test.length); got != test.want {
+			t.Errorf("clampPosition(%d, %d) = %d, want %d", test.position, test.length, got, test.want)
+		}
+	}
+}
+
+// memoryListDao keeps the lists of one board in memory and implements what MoveList and
+// MoveTask use.
+type memoryListDao struct {
+	dao.ListDaoInterface
+	lists   []model.BoardList
+	updates []model.BoardList
+}
+
+func (listDao *memoryListDao) GetLists(boardId string) ([]model.BoardList, error) {
+	return append([]model.BoardList{}, listDao.lists...), nil
+}
+
+func (listDao *memoryListDao) UpdateListPositions(boardLists []model.BoardList) error {
+	listDao.updates = boardLists
+	for _, update := range boardLists {
+		for i := range listDao.lists {
+			if listDao.lists[i].ID == update.ID {
+				listDao.lists[i].Order = update.Order
+			}
+		}
+	}
+	return nil
+}
+
+func (listDao *memoryListDao) FindListById(id string) (model.BoardList, error) {
+	for _, list := range listDao.lists {
+		if list.ID.Hex() == id {
+			return list, nil
+		}
+	}
+	return model.BoardList{}, errors.New("list not found")
+}
+
+func (listDao *memoryListDao) GetBoardIdsByListIds(listIds []primitive.ObjectID) (map[primitive.ObjectID]primitive.ObjectID, error) {
+	boardIds := map[primitive.ObjectID]primitive.ObjectID{}
+	for _, list := range listDao.lists {
+		if containsId(listIds, list.ID) {
+			boardIds[list.ID] = list.BoardID
+		}
+	}
+	return boardIds, nil
+}
+
+type discardActivityService struct {
+	ActivityServiceInterface
+}
+
+func (srv *discardActivityService) RecordActivity(activity *model.Activity, before, after map[string]interface{}) error {
+	return nil
+}
+
+func newMoveListFixture(orders ...int32) (*listService, *memoryListDao) {
+	boardId := primitive.NewObjectID()
+	listDao := &memoryListDao{}
+	for _, order := range orders {
+		listDao.lists = append(listDao.lists, model.BoardList{ID: primitive.NewObjectID(), BoardID: boardId, Order: order})
+	}
+	return ListService(listDao, nil, &discardActivityService{}), listDao
+}
+
+func TestMoveListUpdatesOnlyTheMovedListWhenThereIsRoom(t *testing.T) {
+	srv, listDao := newMoveListFixture(2048, 3072, 4096)
+	moved := listDao.lists[2]
+
+	result, err := srv.MoveList(&moved, 0, model.Actor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(listDao.updates) != 1 || result.Order != 1024 {
+		t.Errorf("%d lists updated, moved list at %d", len(listDao.updates), result.Order)
+	}
+}
+
+func TestMoveListRenumbersWhenOrdersAreAdjacent(t *testing.T) {
+	srv, listDao := newMoveListFixture(1, 2, 3)
+	first, second, moved := listDao.lists[0].ID, listDao.lists[1].ID, listDao.lists[2]
+
+	result, err := srv.MoveList(&moved, 1, model.Actor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(listDao.updates) != 3 {
+		t.Fatalf("%d lists updated, want all 3 renumbered", len(listDao.updates))
+	}
+	want := []primitive.ObjectID{first, moved.ID, second}
+	for i, update := range listDao.updates {
+		if update.ID != want[i] || update.Order != renumberedOrder(i) {
+			t.Errorf("position %d holds order %d, want %d of the expected list", i, update.Order, renumberedOrder(i))
+		}
+	}
+	if result.Order != renumberedOrder(1) {
+		t.Errorf("moved list at %d, want %d", result.Order, renumberedOrder(1))
+	}
+}
+
+// memoryTaskDao keeps the tasks of one list in memory and implements what MoveTask uses.
+type memoryTaskDao struct {
+	dao.TaskDaoInterface
+	tasks   []model.Task
+	updates []model.Task
+}
+
+func (taskDao *memoryTaskDao) GetTasks(listId string) ([]model.Task, error) {
+	return append([]model.Task{}, taskDao.tasks...), nil
+}
+
+func (taskDao *memoryTaskDao) UpdateTaskPositions(tasks []model.Task) error {
+	taskDao.updates = tasks
+	for _, update := range tasks {
+		for i := range taskDao.tasks {
+			if taskDao.tasks[i].ID == update.ID {
+				taskDao.tasks[i].Order = update.Order
+			}
+		}
+	}
+	return nil
+}
+
+func (taskDao *memoryTaskDao) FindTaskById(id string) (model.Task, error) {
+	for _, task := range taskDao.tasks {
+		if task.ID.Hex() == id {
+			return task, nil
+		}
+	}
+	return model.Task{}, errors.New("task not found")
+}
+
+func TestMoveTaskRenumbersWhenOrdersAreAdjacent(t *testing.T) {
+	board := model.Board{ID: primitive.NewObjectID()}
+	list := model.BoardList{ID: primitive.NewObjectID(), BoardID: board.ID}
+	taskDao := &memoryTaskDao{}
+	for _, order := range []int32{1, 2, 3} {
+		taskDao.tasks = append(taskDao.tasks, model.Task{ID: primitive.NewObjectID(), ListID: list.ID, Order: order})
+	}
+	srv := TaskService(taskDao, &memoryListDao{lists: []model.BoardList{list}}, nil, &discardActivityService{}, nil)
+	first, second, moved := taskDao.tasks[0].ID, taskDao.tasks[1].ID, taskDao.tasks[2]
+
+	result, err := srv.MoveTask(&moved, &board, &list, 1, model.Actor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(taskDao.updates) != 3 {
+		t.Fatalf("%d tasks updated, want all 3 renumbered", len(taskDao.updates))
+	}
+	want := []primitive.ObjectID{first, moved.ID, second}
+	for i, update := range taskDao.updates {
+		if update.ID != want[i] || update.Order != renumberedOrder(i) {
+			t.Errorf("position %d holds order %d, want %d of the expected task", i, update.Order, renumberedOrder(i))
+		}
+	}
+	if result.Order != renumberedOrder(1) {
+		t.Errorf("moved task at %d, want %d", result.Order, renumberedOrder(1))
+	}
+}
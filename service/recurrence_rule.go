@@ -0,0 +1,285 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"todo/model"
+)
+
+var ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+
+const (
+	recurrenceDaily   = "DAILY"
+	recurrenceWeekly  = "WEEKLY"
+	recurrenceMonthly = "MONTHLY"
+
+	// maxRecurrenceSteps bounds the search for the next occurrence of rules whose BYDAY
+	// never matches their interval, such as every seventh day on a weekday the series does
+	// not start on.
+	maxRecurrenceSteps = 1000
+)
+
+var recurrenceWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// recurrenceRule is a parsed RRULE. Weeks start on Monday.
+type recurrenceRule struct {
+	freq     string
+	interval int
+	byDay    []recurrenceDay
+	until    *time.Time
+	count    int
+}
+
+// recurrenceDay is a BYDAY entry. An ordinal of 0 stands for every such weekday, others for
+// the nth one of the month, counted from its end when negative.
+type recurrenceDay struct {
+	ordinal int
+	weekday time.Weekday
+}
+
+func validRecurrence(recurrence *model.Recurrence) bool {
+	if recurrence == nil {
+		return true
+	}
+
+	_, err := parseRecurrenceRule(recurrence.Rule)
+	return err == nil
+}
+
+// parseRecurrenceRule parses the supported subset of an RFC 5545 RRULE, with or without
+// its "RRULE:" prefix.
+func parseRecurrenceRule(rule string) (*recurrenceRule, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil, ErrInvalidRecurrence
+	}
+
+	result := &recurrenceRule{interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(name)
+		value = strings.ToUpper(value)
+		if !ok || value == "" || seen[name] {
+			return nil, ErrInvalidRecurrence
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			switch value {
+			case recurrenceDaily, recurrenceWeekly, recurrenceMonthly:
+				result.freq = value
+			default:
+				err = ErrInvalidRecurrence
+			}
+		case "INTERVAL":
+			result.interval, err = parseRecurrenceNumber(value)
+		case "COUNT":
+			result.count, err = parseRecurrenceNumber(value)
+		case "UNTIL":
+			result.until, err = parseRecurrenceUntil(value)
+		case "BYDAY":
+			result.byDay, err = parseRecurrenceDays(value)
+		default:
+			err = ErrInvalidRecurrence
+		}
+		if err != nil {
+			return nil, ErrInvalidRecurrence
+		}
+	}
+
+	// RFC 5545 does not allow a rule to end both by date and by count.
+	if result.freq == "" || (result.until != nil && result.count > 0) {
+		return nil, ErrInvalidRecurrence
+	}
+
+	for _, day := range result.byDay {
+		if day.ordinal != 0 && result.freq != recurrenceMonthly {
+			return nil, ErrInvalidRecurrence
+		}
+	}
+
+	return result, nil
+}
+
+func parseRecurrenceNumber(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > maxRecurrenceSteps {
+		return 0, ErrInvalidRecurrence
+	}
+	return n, nil
+}
+
+// parseRecurrenceUntil reads UNTIL as a UTC or floating date-time, or as a date which then
+// includes the whole day.
+func parseRecurrenceUntil(value string) (*time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405"} {
+		if until, err := time.Parse(layout, value); err == nil {
+			return &until, nil
+		}
+	}
+
+	until, err := time.Parse("20060102", value)
+	if err != nil {
+		return nil, err
+	}
+	until = until.Add(24*time.Hour - time.Nanosecond)
+	return &until, nil
+}
+
+func parseRecurrenceDays(value string) ([]recurrenceDay, error) {
+	var days []recurrenceDay
+	for _, entry := range strings.Split(value, ",") {
+		if len(entry) < 2 {
+			return nil, ErrInvalidRecurrence
+		}
+
+		weekday, ok := recurrenceWeekdays[entry[len(entry)-2:]]
+		if !ok {
+			return nil, ErrInvalidRecurrence
+		}
+
+		day := recurrenceDay{weekday: weekday}
+		if ordinal := entry[:len(entry)-2]; ordinal != "" {
+			n, err := strconv.Atoi(ordinal)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, ErrInvalidRecurrence
+			}
+			day.ordinal = n
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// nextOccurrence returns the first occurrence after the anchor, which is occurrence number
+// occurrence of the series, that also falls after notBefore, along with its number.
+// Occurrences skipped on the way count towards COUNT. ok is false once the series has ended.
+func (rule *recurrenceRule) nextOccurrence(anchor time.Time, occurrence int, notBefore time.Time) (next time.Time, number int, ok bool) {
+	next = anchor
+	number = occurrence
+	for {
+		next, ok = rule.after(next)
+		number++
+		if !ok || (rule.count > 0 && number > rule.count) || (rule.until != nil && next.After(*rule.until)) {
+			return time.Time{}, 0, false
+		}
+
+		if next.After(notBefore) {
+			return next, number, true
+		}
+	}
+}
+
+// after returns the first occurrence of the rule strictly after t, at the time of day of t.
+func (rule *recurrenceRule) after(t time.Time) (time.Time, bool) {
+	switch rule.freq {
+	case recurrenceDaily:
+		for step := 1; step <= maxRecurrenceSteps; step++ {
+			next := t.AddDate(0, 0, step*rule.interval)
+			if rule.onWeekday(next) {
+				return next, true
+			}
+		}
+	case recurrenceWeekly:
+		if len(rule.byDay) == 0 {
+			return t.AddDate(0, 0, 7*rule.interval), true
+		}
+
+		for step := 1; step <= 7*(rule.interval+1); step++ {
+			next := t.AddDate(0, 0, step)
+			if weeksBetween(t, next)%rule.interval == 0 && rule.onWeekday(next) {
+				return next, true
+			}
+		}
+	case recurrenceMonthly:
+		for step := 0; step <= maxRecurrenceSteps; step++ {
+			if next, ok := rule.inMonth(t, step*rule.interval); ok {
+				return next, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func (rule *recurrenceRule) onWeekday(t time.Time) bool {
+	if len(rule.byDay) == 0 {
+		return true
+	}
+
+	for _, day := range rule.byDay {
+		if day.weekday == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// inMonth returns the earliest occurrence after t in the month that lies the given number
+// of months after the month of t. Without BYDAY that is the day of the month of t, which
+// months too short to have it are skipped over.
+func (rule *recurrenceRule) inMonth(t time.Time, months int) (time.Time, bool) {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+
+	var candidates []int
+	if len(rule.byDay) == 0 {
+		candidates = append(candidates, t.Day())
+	}
+	for _, day := range rule.byDay {
+		offset := (int(day.weekday) - int(first.Weekday()) + 7) % 7
+		var days []int
+		for d := 1 + offset; d <= daysInMonth; d += 7 {
+			days = append(days, d)
+		}
+
+		switch {
+		case day.ordinal == 0:
+			candidates = append(candidates, days...)
+		case day.ordinal > 0 && day.ordinal <= len(days):
+			candidates = append(candidates, days[day.ordinal-1])
+		case day.ordinal < 0 && -day.ordinal <= len(days):
+			candidates = append(candidates, days[len(days)+day.ordinal])
+		}
+	}
+	sort.Ints(candidates)
+
+	for _, d := range candidates {
+		if d > daysInMonth {
+			continue
+		}
+
+		next := first.AddDate(0, 0, d-1)
+		if next.After(t) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// weeksBetween counts the Monday to Sunday weeks from the week of a to the week of b.
+func weeksBetween(a time.Time, b time.Time) int {
+	return (civilDay(b) - int(weekdayFromMonday(b)) - civilDay(a) + int(weekdayFromMonday(a))) / 7
+}
+
+func weekdayFromMonday(t time.Time) int {
+	return (int(t.Weekday()) + 6) % 7
+}
+
+// civilDay numbers the calendar day of t, ignoring its time of day and daylight saving.
+func civilDay(t time.Time) int {
+	return int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
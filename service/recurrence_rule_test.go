@@ -0,0 +1,106 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	valid := []string{
+		"FREQ=DAILY",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR",
+		"freq=daily;interval=3",
+		"FREQ=MONTHLY;BYDAY=-1FR",
+		"FREQ=MONTHLY;BYDAY=2TU;COUNT=6",
+		"FREQ=WEEKLY;INTERVAL=2;UNTIL=20261231",
+		"FREQ=DAILY;UNTIL=20260105T120000Z",
+	}
+	for _, rule := range valid {
+		if _, err := parseRecurrenceRule(rule); err != nil {
+			t.Errorf("parseRecurrenceRule(%q) = %v", rule, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"RRULE:",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=x",
+		"FREQ=DAILY;COUNT=2;UNTIL=20260101",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=DAILY;BYMONTH=1",
+		"FREQ=DAILY;INTERVAL",
+	}
+	for _, rule := range invalid {
+		if _, err := parseRecurrenceRule(rule); err != ErrInvalidRecurrence {
+			t.Errorf("parseRecurrenceRule(%q) = %v, want ErrInvalidRecurrence", rule, err)
+		}
+	}
+}
+
+func TestRecurrenceNextOccurrence(t *testing.T) {
+	at := func(value string) time.Time {
+		ts, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+
+	tests := []struct {
+		name       string
+		rule       string
+		anchor     string
+		occurrence int
+		notBefore  string
+		want       string
+		wantNumber int
+	}{
+		{"daily", "FREQ=DAILY", "2026-01-01 09:00", 1, "2026-01-01 09:00", "2026-01-02 09:00", 2},
+		{"daily interval", "FREQ=DAILY;INTERVAL=2", "2026-01-01 09:00", 1, "2026-01-01 09:00", "2026-01-03 09:00", 2},
+		{"weekly", "FREQ=WEEKLY", "2026-01-01 09:00", 1, "2026-01-01 09:00", "2026-01-08 09:00", 2},
+		{"weekly by day", "FREQ=WEEKLY;BYDAY=MO,WE,FR", "2026-01-01 09:00", 1, "2026-01-01 09:00", "2026-01-02 09:00", 2},
+		{"weekly by day wraps", "FREQ=WEEKLY;BYDAY=MO,WE", "2026-01-07 09:00", 1, "2026-01-07 09:00", "2026-01-12 09:00", 2},
+		{"weekly interval by day", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "2026-01-05 09:00", 1, "2026-01-05 09:00", "2026-01-19 09:00", 2},
+		{"monthly", "FREQ=MONTHLY", "2026-01-15 09:00", 1, "2026-01-15 09:00", "2026-02-15 09:00", 2},
+		{"monthly skips short months", "FREQ=MONTHLY", "2026-01-31 09:00", 1, "2026-01-31 09:00", "2026-03-31 09:00", 2},
+		{"monthly interval skips short months", "FREQ=MONTHLY;INTERVAL=3", "2026-11-30 09:00", 1, "2026-11-30 09:00", "2027-05-30 09:00", 2},
+		{"monthly second tuesday", "FREQ=MONTHLY;BYDAY=2TU", "2026-01-13 09:00", 1, "2026-01-13 09:00", "2026-02-10 09:00", 2},
+		{"monthly last friday", "FREQ=MONTHLY;BYDAY=-1FR", "2026-01-30 09:00", 1, "2026-01-30 09:00", "2026-02-27 09:00", 2},
+		{"catches up past now", "FREQ=DAILY", "2026-01-01 09:00", 1, "2026-01-03 10:00", "2026-01-04 09:00", 4},
+		{"count", "FREQ=DAILY;COUNT=3", "2026-01-02 09:00", 2, "2026-01-02 09:00", "2026-01-03 09:00", 3},
+		{"count reached", "FREQ=DAILY;COUNT=3", "2026-01-03 09:00", 3, "2026-01-03 09:00", "", 0},
+		{"count reached while catching up", "FREQ=DAILY;COUNT=3", "2026-01-01 09:00", 1, "2026-01-10 00:00", "", 0},
+		{"until day", "FREQ=DAILY;UNTIL=20260102", "2026-01-01 09:00", 1, "2026-01-01 09:00", "2026-01-02 09:00", 2},
+		{"until passed", "FREQ=DAILY;UNTIL=20260102", "2026-01-02 09:00", 2, "2026-01-02 09:00", "", 0},
+		{"until time", "FREQ=DAILY;UNTIL=20260102T080000Z", "2026-01-01 09:00", 1, "2026-01-01 09:00", "", 0},
+	}
+
+	for _, test := range tests {
+		rule, err := parseRecurrenceRule(test.rule)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		next, number, ok := rule.nextOccurrence(at(test.anchor), test.occurrence, at(test.notBefore))
+		if test.want == "" {
+			if ok {
+				t.Errorf("%s: got %v, want the series to have ended", test.name, next)
+			}
+			continue
+		}
+
+		if !ok || !next.Equal(at(test.want)) {
+			t.Errorf("%s: got %v (ok %v), want %s", test.name, next, ok, test.want)
+		}
+		if number != test.wantNumber {
+			t.Errorf("%s: occurrence %d, want %d", test.name, number, test.wantNumber)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+	"todo/dao"
+	"todo/model"
+)
+
+const (
+	recurrenceLockName  = "recurrence-scheduler"
+	recurrenceBatchSize = 100
+)
+
+type RecurrenceSchedulerInterface interface {
+	SpawnDueOccurrences() error
+	SpawnNextOccurrence(task *model.Task) (*model.Task, error)
+	ScheduleRecurrencesPeriodically(interval time.Duration)
+}
+
+type recurrenceScheduler struct {
+	taskService   TaskServiceInterface
+	listService   ListServiceInterface
+	boardService  BoardServiceInterface
+	leaderLockDao dao.LeaderLockDaoInterface
+	clock         Clock
+	instanceId    string
+}
+
+func RecurrenceScheduler(taskService TaskServiceInterface, listService ListServiceInterface, boardService BoardServiceInterface,
+	leaderLockDao dao.LeaderLockDaoInterface, clock Clock) *recurrenceScheduler {
+	return &recurrenceScheduler{taskService, listService, boardService, leaderLockDao, clock, primitive.NewObjectID().Hex()}
+}
+
+// ScheduleRecurrencesPeriodically spawns the next occurrences of recurring tasks once per
+// interval, as long as this instance holds the scheduler lock. The lock outlasts a couple
+// of intervals, so another instance takes over when the leader stops. It never returns and
+// is meant to run in its own goroutine.
+func (srv *recurrenceScheduler) ScheduleRecurrencesPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		leader, err := srv.leaderLockDao.AcquireLock(recurrenceLockName, srv.instanceId, srv.clock.Now(), 3*interval)
+		if err != nil {
+			fmt.Printf("failed to acquire the recurrence scheduler lock. %s\n", err)
+		} else if leader {
+			if err := srv.SpawnDueOccurrences(); err != nil {
+				fmt.Printf("failed to spawn recurring tasks. %s\n", err)
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// SpawnDueOccurrences creates the next occurrence of recurring tasks that were completed or
+// whose due date has passed, a batch at a time until a batch comes back short. A task that
+// fails is reported, left out of the rest of the run and retried on the next run.
+func (srv *recurrenceScheduler) SpawnDueOccurrences() error {
+	now := srv.clock.Now()
+	var failedIds []primitive.ObjectID
+
+	for {
+		tasks, err := srv.taskService.GetDueRecurringTasks(now, failedIds, recurrenceBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range tasks {
+			if _, err := srv.SpawnNextOccurrence(&tasks[i]); err != nil {
+				fmt.Printf("failed to spawn the next occurrence of task %s. %s\n", tasks[i].ID.Hex(), err)
+				failedIds = append(failedIds, tasks[i].ID)
+			}
+		}
+
+		if len(tasks) < recurrenceBatchSize {
+			return nil
+		}
+	}
+}
+
+// SpawnNextOccurrence creates the task following the recurring task in its series, with its
+// dates moved to the next occurrence of the rule that is still ahead, and marks the task so
+// that this happens only once. It returns nil when the series has ended or the occurrence
+// was already spawned.
+func (srv *recurrenceScheduler) SpawnNextOccurrence(task *model.Task) (*model.Task, error) {
+	if task.Recurrence == nil {
+		return nil, nil
+	}
+
+	list, err := srv.occurrenceList(task)
+	if err != nil {
+		return nil, err
+	}
+
+	board, err := srv.boardService.FindBoardById(list.BoardID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	now := srv.clock.Now()
+	next, ok := nextOccurrenceTask(task, list.ID, now)
+	nextTaskId := primitive.NilObjectID
+	if ok {
+		next.ID = primitive.NewObjectID()
+		next.AssigneeIDs = boardMemberIds(&board, next.AssigneeIDs)
+		nextTaskId = next.ID
+	}
+
+	claimed, err := srv.taskService.ClaimRecurrence(task, nextTaskId, now)
+	if err != nil || !claimed || !ok {
+		return nil, err
+	}
+
+	// Occurrences are created by the server itself, with the zero actor.
+	result, err := srv.taskService.CreateTask(next, &list, model.Actor{})
+	if err != nil {
+		if releaseErr := srv.taskService.ReleaseRecurrence(task); releaseErr != nil {
+			fmt.Printf("failed to release task %s. %s\n", task.ID.Hex(), releaseErr)
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// occurrenceList returns the list the next occurrence of the task goes to. That is the
+// task's own list unless the recurrence names another list of the same board.
+func (srv *recurrenceScheduler) occurrenceList(task *model.Task) (model.BoardList, error) {
+	list, err := srv.listService.FindListById(task.ListID.Hex())
+	if err != nil {
+		return list, err
+	}
+
+	if listId := task.Recurrence.ListID; !listId.IsZero() && listId != task.ListID {
+		target, err := srv.listService.FindListById(listId.Hex())
+		if err == nil && target.BoardID == list.BoardID {
+			return target, nil
+		}
+	}
+	return list, nil
+}
+
+// nextOccurrenceTask copies the task into the first occurrence of its series that falls
+// after now. Its dates keep their distance to the date the rule is anchored on, which is
+// the due date, the start date or the creation time of the task, whichever it has first.
+// Checklists start over. ok is false once the series has ended.
+func nextOccurrenceTask(task *model.Task, listId primitive.ObjectID, now time.Time) (*model.Task, bool) {
+	rule, err := parseRecurrenceRule(task.Recurrence.Rule)
+	if err != nil {
+		return nil, false
+	}
+
+	anchor := task.CreatedTS
+	if task.DueTS != nil {
+		anchor = *task.DueTS
+	} else if task.StartTS != nil {
+		anchor = *task.StartTS
+	}
+
+	occurrence := task.Recurrence.Occurrence
+	if occurrence < 1 {
+		occurrence = 1
+	}
+
+	nextTS, number, ok := rule.nextOccurrence(anchor, occurrence, now)
+	if !ok {
+		return nil, false
+	}
+
+	next := &model.Task{
+		Name:        task.Name,
+		Order:       task.Order,
+		Content:     task.Content,
+		ListID:      listId,
+		LabelIDs:    task.LabelIDs,
+		AssigneeIDs: task.AssigneeIDs,
+		Recurrence: &model.Recurrence{
+			Rule:       task.Recurrence.Rule,
+			ListID:     task.Recurrence.ListID,
+			Occurrence: number,
+		},
+	}
+
+	shift := nextTS.Sub(anchor)
+	if task.StartTS != nil {
+		startTS := task.StartTS.Add(shift)
+		next.StartTS = &startTS
+	}
+	if task.DueTS != nil {
+		dueTS := task.DueTS.Add(shift)
+		next.DueTS = &dueTS
+	}
+
+	for _, checklist := range task.Checklists {
+		copied := model.Checklist{ID: primitive.NewObjectID(), Name: checklist.Name}
+		for _, item := range checklist.Items {
+			copied.Items = append(copied.Items, model.ChecklistItem{ID: primitive.NewObjectID(), Name: item.Name})
+		}
+		next.Checklists = append(next.Checklists, copied)
+	}
+
+	return next, true
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+	"todo/model"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (clock *fixedClock) Now() time.Time {
+	return clock.now
+}
+
+// memoryTaskService keeps tasks in memory and implements what the scheduler uses. The
+// embedded interface is left nil, so anything else panics.
+type memoryTaskService struct {
+	TaskServiceInterface
+	tasks      []*model.Task
+	failCreate map[string]bool
+	dueQueries int
+}
+
+func newMemoryTaskService() *memoryTaskService {
+	return &memoryTaskService{failCreate: map[string]bool{}}
+}
+
+func (srv *memoryTaskService) GetDueRecurringTasks(now time.Time, excludedIds []primitive.ObjectID, limit int) ([]model.Task, error) {
+	srv.dueQueries++
+	var results []model.Task
+	for _, task := range srv.tasks {
+		if len(results) == limit {
+			break
+		}
+		due := task.Completed || (task.DueTS != nil && !task.DueTS.After(now))
+		if task.Recurrence != nil && task.Recurrence.SpawnedTS == nil && due && !containsId(excludedIds, task.ID) {
+			results = append(results, *task)
+		}
+	}
+	return results, nil
+}
+
+func (srv *memoryTaskService) ClaimRecurrence(task *model.Task, nextTaskId primitive.ObjectID, spawnedTS time.Time) (bool, error) {
+	stored := srv.find(task.ID)
+	if stored == nil || stored.Recurrence.SpawnedTS != nil || stored.Recurrence.Rule != task.Recurrence.Rule {
+		return false, nil
+	}
+	stored.Recurrence.SpawnedTS = &spawnedTS
+	stored.Recurrence.NextTaskID = nextTaskId
+	return true, nil
+}
+
+func (srv *memoryTaskService) ReleaseRecurrence(task *model.Task) error {
+	stored := srv.find(task.ID)
+	stored.Recurrence.SpawnedTS = nil
+	stored.Recurrence.NextTaskID = primitive.NilObjectID
+	return nil
+}
+
+func (srv *memoryTaskService) CreateTask(task *model.Task, list *model.BoardList, actor model.Actor) (*model.Task, error) {
+	if srv.failCreate[task.Name] {
+		return nil, errors.New("create failed")
+	}
+	task.CreatedTS = time.Now()
+	srv.tasks = append(srv.tasks, task)
+	return task, nil
+}
+
+func (srv *memoryTaskService) find(id primitive.ObjectID) *model.Task {
+	for _, task := range srv.tasks {
+		if task.ID == id {
+			return task
+		}
+	}
+	return nil
+}
+
+type memoryListService struct {
+	ListServiceInterface
+	lists []model.BoardList
+}
+
+func (srv *memoryListService) FindListById(id string) (model.BoardList, error) {
+	for _, list := range srv.lists {
+		if list.ID.Hex() == id {
+			return list, nil
+		}
+	}
+	return model.BoardList{}, errors.New("list not found")
+}
+
+type memoryBoardService struct {
+	BoardServiceInterface
+	board model.Board
+}
+
+func (srv *memoryBoardService) FindBoardById(id string) (model.Board, error) {
+	if srv.board.ID.Hex() != id {
+		return model.Board{}, errors.New("board not found")
+	}
+	return srv.board, nil
+}
+
+type schedulerFixture struct {
+	scheduler *recurrenceScheduler
+	tasks     *memoryTaskService
+	board     model.Board
+	list      model.BoardList
+	clock     *fixedClock
+}
+
+func newSchedulerFixture() *schedulerFixture {
+	owner := primitive.NewObjectID()
+	board := model.Board{ID: primitive.NewObjectID(), OwnerID: owner}
+	list := model.BoardList{ID: primitive.NewObjectID(), BoardID: board.ID}
+	tasks := newMemoryTaskService()
+	clock := &fixedClock{time.Date(2026, 1, 3, 10, 0, 0, 0, time.UTC)}
+
+	scheduler := RecurrenceScheduler(tasks, &memoryListService{lists: []model.BoardList{list}}, &memoryBoardService{board: board}, nil, clock)
+	return &schedulerFixture{scheduler, tasks, board, list, clock}
+}
+
+func (fixture *schedulerFixture) addRecurringTask(name string, rule string, dueTS time.Time) *model.Task {
+	task := &model.Task{
+		ID:         primitive.NewObjectID(),
+		Name:       name,
+		ListID:     fixture.list.ID,
+		DueTS:      &dueTS,
+		Recurrence: &model.Recurrence{Rule: rule, Occurrence: 1},
+	}
+	fixture.tasks.tasks = append(fixture.tasks.tasks, task)
+	return task
+}
+
+func TestSpawnNextOccurrenceClaimsTheTaskOnce(t *testing.T) {
+	fixture := newSchedulerFixture()
+	task := fixture.addRecurringTask("standup", "FREQ=DAILY", time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+
+	next, err := fixture.scheduler.SpawnNextOccurrence(task)
+	if err != nil || next == nil {
+		t.Fatalf("SpawnNextOccurrence = %v, %v", next, err)
+	}
+
+	if want := time.Date(2026, 1, 4, 9, 0, 0, 0, time.UTC); !next.DueTS.Equal(want) || next.Recurrence.Occurrence != 4 {
+		t.Errorf("next occurrence due %v number %d, want %v number 4", next.DueTS, next.Recurrence.Occurrence, want)
+	}
+	if task.Recurrence.SpawnedTS == nil || !task.Recurrence.SpawnedTS.Equal(fixture.clock.now) || task.Recurrence.NextTaskID != next.ID {
+		t.Errorf("task not claimed: %+v", task.Recurrence)
+	}
+
+	again, err := fixture.scheduler.SpawnNextOccurrence(task)
+	if err != nil || again != nil || len(fixture.tasks.tasks) != 2 {
+		t.Errorf("second spawn = %v, %v with %d tasks", again, err, len(fixture.tasks.tasks))
+	}
+}
+
+func TestSpawnNextOccurrenceReleasesTheClaimWhenCreateFails(t *testing.T) {
+	fixture := newSchedulerFixture()
+	task := fixture.addRecurringTask("report", "FREQ=WEEKLY", time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+	fixture.tasks.failCreate["report"] = true
+
+	if _, err := fixture.scheduler.SpawnNextOccurrence(task); err == nil {
+		t.Fatal("SpawnNextOccurrence succeeded")
+	}
+
+	if task.Recurrence.SpawnedTS != nil || !task.Recurrence.NextTaskID.IsZero() {
+		t.Errorf("claim kept after failure: %+v", task.Recurrence)
+	}
+}
+
+func TestSpawnNextOccurrenceEndsTheSeries(t *testing.T) {
+	fixture := newSchedulerFixture()
+	task := fixture.addRecurringTask("once more", "FREQ=DAILY;COUNT=2", time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+
+	next, err := fixture.scheduler.SpawnNextOccurrence(task)
+	if err != nil || next != nil {
+		t.Fatalf("SpawnNextOccurrence = %v, %v", next, err)
+	}
+
+	if task.Recurrence.SpawnedTS == nil || !task.Recurrence.NextTaskID.IsZero() || len(fixture.tasks.tasks) != 1 {
+		t.Errorf("ended series not claimed or spawned anyway: %+v", task.Recurrence)
+	}
+}
+
+func TestSpawnNextOccurrenceDropsFormerMembers(t *testing.T) {
+	fixture := newSchedulerFixture()
+	member := primitive.NewObjectID()
+	fixture.board.Members = []model.BoardMember{{UserID: member, Role: model.BoardRoleEditor}}
+	fixture.scheduler.boardService = &memoryBoardService{board: fixture.board}
+
+	task := fixture.addRecurringTask("review", "FREQ=DAILY", time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC))
+	task.AssigneeIDs = []primitive.ObjectID{fixture.board.OwnerID, primitive.NewObjectID(), member}
+
+	next, err := fixture.scheduler.SpawnNextOccurrence(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(next.AssigneeIDs) != 2 || next.AssigneeIDs[0] != fixture.board.OwnerID || next.AssigneeIDs[1] != member {
+		t.Errorf("assignees = %v, want the owner and the member", next.AssigneeIDs)
+	}
+}
+
+func TestSpawnDueOccurrencesWorksThroughEveryBatch(t *testing.T) {
+	fixture := newSchedulerFixture()
+	dueTS := time.Date(2026, 1, 3, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 2*recurrenceBatchSize+10; i++ {
+		fixture.addRecurringTask("task", "FREQ=DAILY", dueTS)
+	}
+	failing := fixture.addRecurringTask("failing", "FREQ=DAILY", dueTS)
+	fixture.tasks.failCreate["failing"] = true
+
+	if err := fixture.scheduler.SpawnDueOccurrences(); err != nil {
+		t.Fatal(err)
+	}
+
+	spawned := 0
+	for _, task := range fixture.tasks.tasks {
+		if task.Recurrence.SpawnedTS != nil {
+			spawned++
+		}
+	}
+	if spawned != 2*recurrenceBatchSize+10 || failing.Recurrence.SpawnedTS != nil {
+		t.Errorf("spawned %d occurrences, failing task claimed %v", spawned, failing.Recurrence.SpawnedTS != nil)
+	}
+	if fixture.tasks.dueQueries != 3 {
+		t.Errorf("queried %d batches, want 3", fixture.tasks.dueQueries)
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"todo/model"
+)
+
+type SearchServiceInterface interface {
+	Search(user *model.User, text string, limit int) ([]model.SearchHit, error)
+}
+
+type searchService struct {
+	boardService BoardServiceInterface
+	listService  ListServiceInterface
+	taskService  TaskServiceInterface
+}
+
+func SearchService(boardService BoardServiceInterface, listService ListServiceInterface, taskService TaskServiceInterface) *searchService {
+	return &searchService{boardService, listService, taskService}
+}
+
+// Search looks for the text in the boards the user is a member of, and in the active lists
+// and tasks on them. Hits of all kinds are ranked together by their text score.
+func (srv *searchService) Search(user *model.User, text string, limit int) ([]model.SearchHit, error) {
+	boards, err := srv.boardService.GetBoards(user.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	boardsById := make(map[primitive.ObjectID]model.Board, len(boards))
+	boardIds := make([]primitive.ObjectID, 0, len(boards))
+	for _, board := range boards {
+		boardsById[board.ID] = board
+		boardIds = append(boardIds, board.ID)
+	}
+
+	lists, err := srv.listService.GetListsByBoardIds(boardIds)
+	if err != nil {
+		return nil, err
+	}
+
+	listsById := make(map[primitive.ObjectID]model.BoardList, len(lists))
+	listIds := make([]primitive.ObjectID, 0, len(lists))
+	for _, list := range lists {
+		listsById[list.ID] = list
+		listIds = append(listIds, list.ID)
+	}
+
+	boardHits, err := srv.boardService.SearchBoards(boardIds, text, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	listHits, err := srv.listService.SearchLists(boardIds, text, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	taskHits, err := srv.taskService.SearchTasks(listIds, text, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]model.SearchHit, 0, len(boardHits)+len(listHits)+len(taskHits))
+	for _, hit := range boardHits {
+		hit.BoardName = hit.Name
+		hit.Path = fmt.Sprintf("/boards/%s", hit.ID.Hex())
+		hits = append(hits, hit)
+	}
+
+	for _, hit := range listHits {
+		listId := hit.ID
+		hit.BoardName = boardsById[hit.BoardID].Name
+		hit.ListID = &listId
+		hit.ListName = hit.Name
+		hit.Path = fmt.Sprintf("/boards/%s/lists/%s", hit.BoardID.Hex(), hit.ID.Hex())
+		hits = append(hits, hit)
+	}
+
+	for _, hit := range taskHits {
+		list := listsById[*hit.ListID]
+		hit.BoardID = list.BoardID
+		hit.BoardName = boardsById[list.BoardID].Name
+		hit.ListName = list.Name
+		hit.Path = fmt.Sprintf("/boards/%s/lists/%s/tasks/%s", list.BoardID.Hex(), list.ID.Hex(), hit.ID.Hex())
+		hits = append(hits, hit)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits, nil
+}
@@ -1,45 +1,626 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"time"
 	"todo/dao"
+	"todo/data"
 	"todo/model"
 )
 
+var ErrInvalidTaskDates = errors.New("start date must not be after the due date")
+
 type TaskServiceInterface interface {
-	CreateTask(task *model.Task) (*model.Task, error)
-	DeleteTask(task *model.Task) error
-	UpdateTask(task *model.Task) (*model.Task, error)
+	CreateTask(task *model.Task, list *model.BoardList, actor model.Actor) (*model.Task, error)
+	DeleteTask(task *model.Task, actor model.Actor) error
+	RestoreTask(task *model.Task, actor model.Actor) error
+	TrashTasksByListIds(listIds []primitive.ObjectID, deletedTS time.Time, actor model.Actor) error
+	RestoreTasksByListIds(listIds []primitive.ObjectID, deletedTS time.Time, actor model.Actor) error
+	PurgeTasksByListIds(listIds []primitive.ObjectID) error
+	PurgeTrashedTasks(before time.Time) error
+	PatchTask(task *model.Task, patch *model.Patch, actor model.Actor) (*model.Task, error)
+	UpdateTask(task *model.Task, actor model.Actor) (*model.Task, error)
+	MoveTask(task *model.Task, board *model.Board, list *model.BoardList, position int, actor model.Actor) (*model.Task, error)
+	SetTaskCompletion(task *model.Task, completed bool, actor model.Actor) (*model.Task, error)
+	AddTaskLabel(task *model.Task, labelId primitive.ObjectID, actor model.Actor) (*model.Task, error)
+	RemoveTaskLabel(task *model.Task, labelId primitive.ObjectID, actor model.Actor) (*model.Task, error)
+	RemoveLabelFromTasks(listIds []primitive.ObjectID, labelId primitive.ObjectID, actor model.Actor) error
+	AddTaskAssignee(task *model.Task, userId primitive.ObjectID, actor model.Actor) (*model.Task, error)
+	RemoveTaskAssignee(task *model.Task, userId primitive.ObjectID, actor model.Actor) (*model.Task, error)
+	RemoveAssigneeFromTasks(listIds []primitive.ObjectID, userId primitive.ObjectID, actor model.Actor) error
+	RemoveAssigneeFromAllTasks(userId primitive.ObjectID) error
 	FindTaskById(id string) (model.Task, error)
+	FindTrashedTaskById(id string) (model.Task, error)
 	GetTasks(listId string) ([]model.Task, error)
+	GetTasksPage(listId string, page *model.Page) ([]model.Task, string, error)
+	SearchTasks(listIds []primitive.ObjectID, text string, limit int) ([]model.SearchHit, error)
+	GetTrashedTasks(listIds []primitive.ObjectID) ([]model.Task, error)
+	GetDueTasks(listIds []primitive.ObjectID, dueBefore *time.Time, limit int) ([]model.Task, error)
+	GetAssignedTasks(listIds []primitive.ObjectID, userId primitive.ObjectID, limit int) ([]model.Task, error)
+	GetDueTasksSince(listIds []primitive.ObjectID, since time.Time, limit int) ([]model.Task, error)
+	GetDueRecurringTasks(now time.Time, excludedIds []primitive.ObjectID, limit int) ([]model.Task, error)
+	ClaimRecurrence(task *model.Task, nextTaskId primitive.ObjectID, spawnedTS time.Time) (bool, error)
+	ReleaseRecurrence(task *model.Task) error
 }
 
+// taskService records every change of a task in the activity of the board of its list,
+// attributed to the actor passed along with the change.
 type taskService struct {
-	taskDao dao.TaskDaoInterface
+	taskDao         dao.TaskDaoInterface
+	listDao         dao.ListDaoInterface
+	commentService  CommentServiceInterface
+	activityService ActivityServiceInterface
+	blobStore       data.BlobStoreInterface
 }
 
-func TaskService(taskDao dao.TaskDaoInterface) *taskService {
-	return &taskService{taskDao}
+func TaskService(taskDao dao.TaskDaoInterface, listDao dao.ListDaoInterface, commentService CommentServiceInterface,
+	activityService ActivityServiceInterface, blobStore data.BlobStoreInterface) *taskService {
+	return &taskService{taskDao, listDao, commentService, activityService, blobStore}
 }
 
-func (srv *taskService) CreateTask(task *model.Task) (*model.Task, error) {
+// CreateTask creates the task in the list. Tasks created in a list that completes tasks
+// are completed on behalf of the actor right away.
+func (srv *taskService) CreateTask(task *model.Task, list *model.BoardList, actor model.Actor) (*model.Task, error) {
+	if !validTaskDates(task.StartTS, task.DueTS) {
+		return nil, ErrInvalidTaskDates
+	}
+
+	if !validRecurrence(task.Recurrence) {
+		return nil, ErrInvalidRecurrence
+	}
+
+	if task.Recurrence != nil && task.Recurrence.Occurrence == 0 {
+		task.Recurrence.Occurrence = 1
+	}
+
 	task.CreatedTS = time.Now()
-	return srv.taskDao.CreateTask(task)
+	if list.CompletesTasks {
+		completeTask(task, actor.UserID, task.CreatedTS)
+	}
+
+	result, err := srv.taskDao.CreateTask(task)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.recordTaskActivity(actor, list.BoardID, result.ID, model.ActivityCreate, nil, result)
+	return result, nil
+}
+
+// DeleteTask moves the task to the trash.
+func (srv *taskService) DeleteTask(task *model.Task, actor model.Actor) error {
+	if err := srv.taskDao.TrashTask(task, time.Now()); err != nil {
+		return err
+	}
+
+	srv.recordTaskActivities(actor, model.ActivityDelete, []model.Task{*task}, trashedTask)
+	return nil
+}
+
+func (srv *taskService) RestoreTask(task *model.Task, actor model.Actor) error {
+	if err := srv.taskDao.RestoreTask(task); err != nil {
+		return err
+	}
+
+	srv.recordTaskActivities(actor, model.ActivityRestore, []model.Task{*task}, restoredTask)
+	return nil
+}
+
+// TrashTasksByListIds moves the active tasks of the lists to the trash, recording each of
+// them as deleted.
+func (srv *taskService) TrashTasksByListIds(listIds []primitive.ObjectID, deletedTS time.Time, actor model.Actor) error {
+	tasks, err := srv.taskDao.GetTasksByListIds(listIds)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks to trash : %v", err)
+	}
+
+	if err := srv.taskDao.TrashTasksByListIds(listIds, deletedTS); err != nil {
+		return err
+	}
+
+	srv.recordTaskActivities(actor, model.ActivityDelete, tasks, trashedTask)
+	return nil
+}
+
+// RestoreTasksByListIds restores the tasks trashed together with their list, recording each
+// of them as restored.
+func (srv *taskService) RestoreTasksByListIds(listIds []primitive.ObjectID, deletedTS time.Time, actor model.Actor) error {
+	tasks, err := srv.taskDao.GetTasksTrashedWith(listIds, deletedTS)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks to restore : %v", err)
+	}
+
+	if err := srv.taskDao.RestoreTasksByListIds(listIds, deletedTS); err != nil {
+		return err
+	}
+
+	srv.recordTaskActivities(actor, model.ActivityRestore, tasks, restoredTask)
+	return nil
+}
+
+// PurgeTasksByListIds permanently deletes every task of the lists, trashed or not, along
+// with their comments and attachments.
+func (srv *taskService) PurgeTasksByListIds(listIds []primitive.ObjectID) error {
+	taskIds, err := srv.taskDao.GetTaskIdsByListIds(listIds)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks to delete : %v", err)
+	}
+
+	if err := srv.purgeTaskContent(taskIds); err != nil {
+		return err
+	}
+
+	return srv.taskDao.DeleteTasksByListIds(listIds)
+}
+
+// PurgeTrashedTasks permanently deletes the tasks trashed before the given time along
+// with their comments and attachments.
+func (srv *taskService) PurgeTrashedTasks(before time.Time) error {
+	taskIds, err := srv.taskDao.GetTrashedTaskIds(before)
+	if err != nil {
+		return fmt.Errorf("failed to find trashed tasks : %v", err)
+	}
+
+	if err := srv.purgeTaskContent(taskIds); err != nil {
+		return err
+	}
+
+	return srv.taskDao.DeleteTasksByIds(taskIds)
+}
+
+// purgeTaskContent deletes the comments and attachment blobs of tasks about to be deleted.
+// It runs before the tasks are deleted so that a failure leaves them in place to retry.
+func (srv *taskService) purgeTaskContent(taskIds []primitive.ObjectID) error {
+	if err := srv.commentService.DeleteCommentsByTaskIds(taskIds); err != nil {
+		return fmt.Errorf("failed to delete comments of tasks : %v", err)
+	}
+
+	attachmentIds, err := srv.taskDao.GetAttachmentIdsByTaskIds(taskIds)
+	if err != nil {
+		return fmt.Errorf("failed to find attachments of tasks : %v", err)
+	}
+
+	for _, attachmentId := range attachmentIds {
+		if err := srv.blobStore.DeleteBlob(attachmentId); err != nil {
+			return fmt.Errorf("failed to delete attachment %s : %v", attachmentId.Hex(), err)
+		}
+	}
+
+	return nil
+}
+
+func (srv *taskService) PatchTask(task *model.Task, patch *model.Patch, actor model.Actor) (*model.Task, error) {
+	if !validTaskDates(patchedTime(patch, "start_ts", task.StartTS), patchedTime(patch, "due_ts", task.DueTS)) {
+		return nil, ErrInvalidTaskDates
+	}
+
+	if !validPatchedRecurrence(patch, task) {
+		return nil, ErrInvalidRecurrence
+	}
+
+	patch.Set["modified_ts"] = time.Now()
+	result, err := srv.taskDao.PatchTask(task, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.recordTaskActivities(actor, model.ActivityUpdate, []model.Task{*task}, writtenTask(result))
+	return result, nil
+}
+
+// UpdateTask replaces the task with the given record. The activity log compares it with
+// the stored task, as callers edit their copy of the task before passing it in.
+func (srv *taskService) UpdateTask(task *model.Task, actor model.Actor) (*model.Task, error) {
+	if !validTaskDates(task.StartTS, task.DueTS) {
+		return nil, ErrInvalidTaskDates
+	}
+
+	if !validRecurrence(task.Recurrence) {
+		return nil, ErrInvalidRecurrence
+	}
+
+	stored, err := srv.taskDao.FindTaskById(task.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	task.ModifiedTS = time.Now()
+	result, err := srv.taskDao.UpdateTask(task)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.recordTaskActivities(actor, model.ActivityUpdate, []model.Task{stored}, writtenTask(result))
+	return result, nil
+}
+
+// MoveTask moves the task to the given zero based position of a list, which may be the
+// task's own list. Out of range positions move the task to the end of the list. Only the
+// moved task is written unless its new neighbours leave no room between their orders, in
+// which case the whole list is renumbered in one bulk write. A task moved into a list that
+// completes tasks is completed on behalf of the actor in the same write. The list belongs
+// to the given board, and a task moved from another board loses the labels that board
+// does not have. Such a move shows up in the activity of both boards.
+func (srv *taskService) MoveTask(task *model.Task, board *model.Board, list *model.BoardList, position int, actor model.Actor) (*model.Task, error) {
+	siblings, err := srv.taskDao.GetTasks(list.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	sourceBoardId, err := srv.boardIdOfList(task.ListID)
+	if err != nil {
+		return nil, err
+	}
+	before := activitySnapshot(task)
+
+	others := make([]model.Task, 0, len(siblings))
+	orders := make([]int32, 0, len(siblings))
+	for _, sibling := range siblings {
+		if sibling.ID != task.ID {
+			others = append(others, sibling)
+			orders = append(orders, sibling.Order)
+		}
+	}
+	position = clampPosition(position, len(others))
+
+	task.ListID = list.ID
+	task.ModifiedTS = time.Now()
+	if list.CompletesTasks {
+		completeTask(task, actor.UserID, task.ModifiedTS)
+	}
+
+	updates := []model.Task{*task}
+	if order, ok := orderForPosition(orders, position); ok {
+		updates[0].Order = order
+	} else {
+		updates = make([]model.Task, 0, len(others)+1)
+		updates = append(updates, others[:position]...)
+		updates = append(updates, *task)
+		updates = append(updates, others[position:]...)
+		for i := range updates {
+			updates[i].Order = renumberedOrder(i)
+		}
+	}
+
+	if err := srv.taskDao.UpdateTaskPositions(updates); err != nil {
+		return nil, err
+	}
+
+	stored, err := srv.taskDao.FindTaskById(task.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &stored
+	if sourceBoardId != board.ID {
+		if result, err = srv.retainBoardReferences(result, board); err != nil {
+			return nil, err
+		}
+	}
+
+	srv.recordTaskActivity(actor, sourceBoardId, task.ID, model.ActivityMove, before, result)
+	if sourceBoardId != board.ID {
+		srv.recordTaskActivity(actor, board.ID, task.ID, model.ActivityMove, before, result)
+	}
+
+	return result, nil
 }
 
-func (srv *taskService) DeleteTask(task *model.Task) error {
-	return srv.taskDao.DeleteTask(task)
+// SetTaskCompletion completes the task on behalf of the actor, or opens it again. A task
+// already in the requested state is returned as is.
+func (srv *taskService) SetTaskCompletion(task *model.Task, completed bool, actor model.Actor) (*model.Task, error) {
+	if task.Completed == completed {
+		return task, nil
+	}
+
+	now := time.Now()
+	patch := model.NewPatch()
+	if completed {
+		patch.Set["completed"] = true
+		patch.Set["completed_ts"] = now
+		patch.Set["completed_by"] = actor.UserID
+	} else {
+		patch.Unset = append(patch.Unset, "completed", "completed_ts", "completed_by")
+	}
+	patch.Set["modified_ts"] = now
+
+	result, err := srv.taskDao.PatchTask(task, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.recordTaskActivities(actor, model.ActivityUpdate, []model.Task{*task}, writtenTask(result))
+	return result, nil
+}
+
+// completeTask marks an open task completed by the user. Completed tasks keep who
+// completed them and when.
+func completeTask(task *model.Task, userId primitive.ObjectID, completedTS time.Time) {
+	if task.Completed {
+		return
+	}
+	task.Completed = true
+	task.CompletedTS = &completedTS
+	task.CompletedBy = userId
+}
+
+func (srv *taskService) AddTaskLabel(task *model.Task, labelId primitive.ObjectID, actor model.Actor) (*model.Task, error) {
+	if containsId(task.LabelIDs, labelId) {
+		return task, nil
+	}
+
+	task.LabelIDs = append(task.LabelIDs, labelId)
+	return srv.UpdateTask(task, actor)
+}
+
+func (srv *taskService) RemoveTaskLabel(task *model.Task, labelId primitive.ObjectID, actor model.Actor) (*model.Task, error) {
+	if !containsId(task.LabelIDs, labelId) {
+		return task, nil
+	}
+
+	task.LabelIDs = removeId(task.LabelIDs, labelId)
+
+	return srv.UpdateTask(task, actor)
 }
 
-func (srv *taskService) UpdateTask(task *model.Task) (*model.Task, error) {
+// retainBoardReferences drops the labels of the task that are not in the board's catalogue
+// and the assignees that are not members of the board, which is needed when a task moves
+// to another board. It is recorded as part of the move.
+func (srv *taskService) retainBoardReferences(task *model.Task, board *model.Board) (*model.Task, error) {
+	labelIds := make([]primitive.ObjectID, 0, len(task.LabelIDs))
+	for _, id := range task.LabelIDs {
+		for _, label := range board.Labels {
+			if label.ID == id {
+				labelIds = append(labelIds, id)
+				break
+			}
+		}
+	}
+	assigneeIds := boardMemberIds(board, task.AssigneeIDs)
+
+	if len(labelIds) == len(task.LabelIDs) && len(assigneeIds) == len(task.AssigneeIDs) {
+		return task, nil
+	}
+	task.LabelIDs = labelIds
+	task.AssigneeIDs = assigneeIds
 	task.ModifiedTS = time.Now()
+
 	return srv.taskDao.UpdateTask(task)
 }
 
+// RemoveLabelFromTasks takes the label off every task of the lists, trashed or not,
+// recording each task that carried it as updated.
+func (srv *taskService) RemoveLabelFromTasks(listIds []primitive.ObjectID, labelId primitive.ObjectID, actor model.Actor) error {
+	tasks, err := srv.taskDao.GetTasksWithLabel(listIds, labelId)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks with label %s : %v", labelId.Hex(), err)
+	}
+
+	if err := srv.taskDao.RemoveLabelFromTasks(listIds, labelId); err != nil {
+		return err
+	}
+
+	srv.recordTaskActivities(actor, model.ActivityUpdate, tasks, func(task model.Task) *model.Task {
+		task.LabelIDs = removeId(task.LabelIDs, labelId)
+		return &task
+	})
+	return nil
+}
+
+func (srv *taskService) AddTaskAssignee(task *model.Task, userId primitive.ObjectID, actor model.Actor) (*model.Task, error) {
+	if containsId(task.AssigneeIDs, userId) {
+		return task, nil
+	}
+
+	task.AssigneeIDs = append(task.AssigneeIDs, userId)
+	return srv.UpdateTask(task, actor)
+}
+
+func (srv *taskService) RemoveTaskAssignee(task *model.Task, userId primitive.ObjectID, actor model.Actor) (*model.Task, error) {
+	if !containsId(task.AssigneeIDs, userId) {
+		return task, nil
+	}
+
+	task.AssigneeIDs = removeId(task.AssigneeIDs, userId)
+	return srv.UpdateTask(task, actor)
+}
+
+// RemoveAssigneeFromTasks unassigns the user from every task of the lists, trashed or not,
+// recording each task they were assigned to as updated.
+func (srv *taskService) RemoveAssigneeFromTasks(listIds []primitive.ObjectID, userId primitive.ObjectID, actor model.Actor) error {
+	tasks, err := srv.taskDao.GetTasksWithAssignee(listIds, userId)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks assigned to user %s : %v", userId.Hex(), err)
+	}
+
+	if err := srv.taskDao.RemoveAssigneeFromTasks(listIds, userId); err != nil {
+		return err
+	}
+
+	srv.recordTaskActivities(actor, model.ActivityUpdate, tasks, func(task model.Task) *model.Task {
+		task.AssigneeIDs = removeId(task.AssigneeIDs, userId)
+		return &task
+	})
+	return nil
+}
+
+// RemoveAssigneeFromAllTasks unassigns a deleted user from every task, trashed or not. As
+// with their board memberships this is not recorded in the activity of the boards.
+func (srv *taskService) RemoveAssigneeFromAllTasks(userId primitive.ObjectID) error {
+	return srv.taskDao.RemoveAssigneeFromAllTasks(userId)
+}
+
+// recordTaskActivity records a change of the task on the given board. before is nil when
+// the task was created, and after when it was trashed.
+func (srv *taskService) recordTaskActivity(actor model.Actor, boardId primitive.ObjectID, taskId primitive.ObjectID, action string,
+	before map[string]interface{}, after *model.Task) {
+	recordActivity(srv.activityService, actor, model.Activity{
+		BoardID:    boardId,
+		EntityType: model.ActivityTask,
+		EntityID:   taskId,
+		Action:     action,
+	}, before, activitySnapshot(after))
+}
+
+// recordTaskActivities records the same action for each of the tasks as they were before
+// the write, on the boards of their lists. changed returns what the write made of a task,
+// or nil when it was trashed.
+func (srv *taskService) recordTaskActivities(actor model.Actor, action string, tasks []model.Task, changed func(task model.Task) *model.Task) {
+	if len(tasks) == 0 {
+		return
+	}
+
+	listIds := make([]primitive.ObjectID, 0, len(tasks))
+	for _, task := range tasks {
+		if !containsId(listIds, task.ListID) {
+			listIds = append(listIds, task.ListID)
+		}
+	}
+
+	boardIds, err := srv.listDao.GetBoardIdsByListIds(listIds)
+	if err != nil {
+		fmt.Printf("failed to record %s of %d tasks. %s\n", action, len(tasks), err)
+		return
+	}
+
+	for _, task := range tasks {
+		before := activitySnapshot(task)
+		srv.recordTaskActivity(actor, boardIds[task.ListID], task.ID, action, before, changed(task))
+	}
+}
+
+// boardIdOfList returns the board of the list, trashed or not.
+func (srv *taskService) boardIdOfList(listId primitive.ObjectID) (primitive.ObjectID, error) {
+	boardIds, err := srv.listDao.GetBoardIdsByListIds([]primitive.ObjectID{listId})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	boardId, ok := boardIds[listId]
+	if !ok {
+		return primitive.NilObjectID, fmt.Errorf("list %s not found", listId.Hex())
+	}
+	return boardId, nil
+}
+
+// writtenTask stands for a single task whose write returned the given result.
+func writtenTask(result *model.Task) func(model.Task) *model.Task {
+	return func(model.Task) *model.Task {
+		return result
+	}
+}
+
+func trashedTask(model.Task) *model.Task {
+	return nil
+}
+
+func restoredTask(task model.Task) *model.Task {
+	task.DeletedTS = nil
+	return &task
+}
+
+func validTaskDates(startTS *time.Time, dueTS *time.Time) bool {
+	return startTS == nil || dueTS == nil || !startTS.After(*dueTS)
+}
+
+// patchedTime returns the value a date field of a record will have once the patch is applied.
+func patchedTime(patch *model.Patch, field string, current *time.Time) *time.Time {
+	if value, ok := patch.Set[field].(time.Time); ok {
+		return &value
+	}
+
+	for _, unset := range patch.Unset {
+		if unset == field {
+			return nil
+		}
+	}
+
+	return current
+}
+
+// validPatchedRecurrence checks the rule the task is left with when the patch changes its
+// recurrence.
+func validPatchedRecurrence(patch *model.Patch, task *model.Task) bool {
+	rule, ok := patch.Set["recurrence.rule"].(string)
+	if !ok {
+		if _, listSet := patch.Set["recurrence.list_id"]; !listSet {
+			return true
+		}
+		if task.Recurrence == nil {
+			return false
+		}
+		rule = task.Recurrence.Rule
+	}
+
+	_, err := parseRecurrenceRule(rule)
+	return err == nil
+}
+
+func containsId(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+func removeId(ids []primitive.ObjectID, id primitive.ObjectID) []primitive.ObjectID {
+	result := make([]primitive.ObjectID, 0, len(ids))
+	for _, candidate := range ids {
+		if candidate != id {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
 func (srv *taskService) FindTaskById(id string) (model.Task, error) {
 	return srv.taskDao.FindTaskById(id)
 }
 
+func (srv *taskService) FindTrashedTaskById(id string) (model.Task, error) {
+	return srv.taskDao.FindTrashedTaskById(id)
+}
+
 func (srv *taskService) GetTasks(listId string) ([]model.Task, error) {
 	return srv.taskDao.GetTasks(listId)
 }
+
+func (srv *taskService) GetTasksPage(listId string, page *model.Page) ([]model.Task, string, error) {
+	return srv.taskDao.GetTasksPage(listId, page)
+}
+
+func (srv *taskService) SearchTasks(listIds []primitive.ObjectID, text string, limit int) ([]model.SearchHit, error) {
+	return srv.taskDao.SearchTasks(listIds, text, limit)
+}
+
+func (srv *taskService) GetTrashedTasks(listIds []primitive.ObjectID) ([]model.Task, error) {
+	return srv.taskDao.GetTrashedTasks(listIds)
+}
+
+func (srv *taskService) GetDueTasks(listIds []primitive.ObjectID, dueBefore *time.Time, limit int) ([]model.Task, error) {
+	return srv.taskDao.GetDueTasks(listIds, dueBefore, limit)
+}
+
+func (srv *taskService) GetAssignedTasks(listIds []primitive.ObjectID, userId primitive.ObjectID, limit int) ([]model.Task, error) {
+	return srv.taskDao.GetAssignedTasks(listIds, userId, limit)
+}
+
+func (srv *taskService) GetDueTasksSince(listIds []primitive.ObjectID, since time.Time, limit int) ([]model.Task, error) {
+	return srv.taskDao.GetDueTasksSince(listIds, since, limit)
+}
+
+func (srv *taskService) GetDueRecurringTasks(now time.Time, excludedIds []primitive.ObjectID, limit int) ([]model.Task, error) {
+	return srv.taskDao.GetDueRecurringTasks(now, excludedIds, limit)
+}
+
+func (srv *taskService) ClaimRecurrence(task *model.Task, nextTaskId primitive.ObjectID, spawnedTS time.Time) (bool, error) {
+	return srv.taskDao.ClaimRecurrence(task, nextTaskId, spawnedTS)
+}
+
+func (srv *taskService) ReleaseRecurrence(task *model.Task) error {
+	return srv.taskDao.ReleaseRecurrence(task)
+}
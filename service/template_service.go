@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"todo/model"
+)
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+// builtInTemplates are offered to every user next to the boards they flagged as templates.
+var builtInTemplates = []model.BoardTemplate{
+	{
+		ID:          "kanban",
+		Name:        "Kanban",
+		Description: "Follow work from the backlog through review to done.",
+		BuiltIn:     true,
+		Lists:       []string{"Backlog", "In Progress", "Review", "Done"},
+	},
+	{
+		ID:          "scrum",
+		Name:        "Scrum",
+		Description: "Plan sprints from the product backlog and track them to done.",
+		BuiltIn:     true,
+		Lists:       []string{"Product Backlog", "Sprint Backlog", "In Progress", "Testing", "Done"},
+	},
+	{
+		ID:          "bug-tracking",
+		Name:        "Bug tracking",
+		Description: "Triage reported bugs and follow them until the fix is verified.",
+		BuiltIn:     true,
+		Lists:       []string{"Reported", "Confirmed", "In Progress", "Fixed", "Verified"},
+	},
+	{
+		ID:          "personal",
+		Name:        "Personal",
+		Description: "A simple to do list.",
+		BuiltIn:     true,
+		Lists:       []string{"To Do", "Doing", "Done"},
+	},
+}
+
+type TemplateServiceInterface interface {
+	GetTemplates(user *model.User) ([]model.BoardTemplate, error)
+	InstantiateTemplate(user *model.User, templateId string, name string, actor model.Actor) (*model.Board, error)
+}
+
+type templateService struct {
+	boardService       BoardServiceInterface
+	listService        ListServiceInterface
+	boardExportService BoardExportServiceInterface
+}
+
+func TemplateService(boardService BoardServiceInterface, listService ListServiceInterface, boardExportService BoardExportServiceInterface) *templateService {
+	return &templateService{boardService, listService, boardExportService}
+}
+
+// GetTemplates lists the built-in templates followed by the boards the user has access to
+// that are flagged as templates.
+func (srv *templateService) GetTemplates(user *model.User) ([]model.BoardTemplate, error) {
+	templates := append([]model.BoardTemplate{}, builtInTemplates...)
+
+	boards, err := srv.boardService.GetBoards(user.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, board := range boards {
+		if !board.IsTemplate {
+			continue
+		}
+
+		lists, err := srv.listService.GetLists(board.ID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get lists of template %s : %v", board.ID.Hex(), err)
+		}
+
+		template := model.BoardTemplate{ID: board.ID.Hex(), Name: board.Name, Lists: make([]string, 0, len(lists))}
+		for _, list := range lists {
+			template.Lists = append(template.Lists, list.Name)
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, nil
+}
+
+// InstantiateTemplate creates a board owned by the user from a built-in template, or from
+// a template board the user has access to along with its tasks. The board is named after
+// the template unless a name is given.
+func (srv *templateService) InstantiateTemplate(user *model.User, templateId string, name string, actor model.Actor) (*model.Board, error) {
+	for _, template := range builtInTemplates {
+		if template.ID != templateId {
+			continue
+		}
+
+		export := &model.BoardExport{Name: name}
+		if export.Name == "" {
+			export.Name = template.Name
+		}
+		for _, listName := range template.Lists {
+			export.Lists = append(export.Lists, model.ListExport{Name: listName})
+		}
+		return srv.boardExportService.ImportBoard(user, export, actor)
+	}
+
+	board, err := srv.boardService.FindBoardById(templateId)
+	if err != nil || !board.IsTemplate || srv.boardService.GetBoardRole(&board, user) == "" {
+		return nil, ErrTemplateNotFound
+	}
+
+	if name == "" {
+		name = board.Name
+	}
+	return srv.boardExportService.DuplicateBoard(user, &board, name, true, actor)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+	"todo/model"
+)
+
+type TrashServiceInterface interface {
+	GetTrash(user *model.User) (model.Trash, error)
+	PurgeTrash(before time.Time) error
+	PurgeTrashPeriodically(retention time.Duration, interval time.Duration)
+}
+
+type trashService struct {
+	boardService BoardServiceInterface
+	listService  ListServiceInterface
+	taskService  TaskServiceInterface
+}
+
+func TrashService(boardService BoardServiceInterface, listService ListServiceInterface, taskService TaskServiceInterface) *trashService {
+	return &trashService{boardService, listService, taskService}
+}
+
+// GetTrash lists the trashed boards the user has access to, and the lists and tasks that
+// were trashed on their own from boards and lists that are still active. Records trashed
+// together with their parent are restored through the parent.
+func (srv *trashService) GetTrash(user *model.User) (model.Trash, error) {
+	var trash model.Trash
+	var err error
+
+	trash.Boards, err = srv.boardService.GetTrashedBoards(user.ID.Hex())
+	if err != nil {
+		return trash, err
+	}
+
+	boards, err := srv.boardService.GetBoards(user.ID.Hex())
+	if err != nil {
+		return trash, err
+	}
+
+	boardIds := make([]primitive.ObjectID, 0, len(boards))
+	for _, board := range boards {
+		boardIds = append(boardIds, board.ID)
+	}
+
+	trash.Lists, err = srv.listService.GetTrashedLists(boardIds)
+	if err != nil {
+		return trash, err
+	}
+
+	lists, err := srv.listService.GetListsByBoardIds(boardIds)
+	if err != nil {
+		return trash, err
+	}
+
+	listIds := make([]primitive.ObjectID, 0, len(lists))
+	for _, list := range lists {
+		listIds = append(listIds, list.ID)
+	}
+
+	trash.Tasks, err = srv.taskService.GetTrashedTasks(listIds)
+	return trash, err
+}
+
+// PurgeTrash permanently deletes everything that was moved to the trash before the given time.
+func (srv *trashService) PurgeTrash(before time.Time) error {
+	if err := srv.boardService.PurgeTrashedBoards(before); err != nil {
+		return err
+	}
+
+	if err := srv.listService.PurgeTrashedLists(before); err != nil {
+		return err
+	}
+
+	return srv.taskService.PurgeTrashedTasks(before)
+}
+
+// PurgeTrashPeriodically purges records that have been in the trash for longer than the
+// retention period, once per interval. It never returns and is meant to run in its own
+// goroutine.
+func (srv *trashService) PurgeTrashPeriodically(retention time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := srv.PurgeTrash(time.Now().Add(-retention)); err != nil {
+			fmt.Printf("failed to purge trash. %s\n", err)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"strings"
+	"time"
+	"todo/model"
+)
+
+// Hex values of the Trello label colors. Shades such as "green_dark" use their base color
+// and labels without a color get Trello's gray.
+var trelloLabelColors = map[string]string{
+	"green":  "#61bd4f",
+	"yellow": "#f2d600",
+	"orange": "#ff9f1a",
+	"red":    "#eb5a46",
+	"purple": "#c377e0",
+	"blue":   "#0079bf",
+	"sky":    "#00c2e0",
+	"lime":   "#51e898",
+	"pink":   "#ff78cb",
+	"black":  "#344563",
+}
+
+const trelloDefaultLabelColor = "#b3bac5"
+
+type TrelloImportServiceInterface interface {
+	ImportTrelloBoard(owner *model.User, trello *model.TrelloBoard, actor model.Actor) (*model.ImportReport, error)
+}
+
+type trelloImportService struct {
+	boardService   BoardServiceInterface
+	listService    ListServiceInterface
+	taskService    TaskServiceInterface
+	commentService CommentServiceInterface
+}
+
+func TrelloImportService(boardService BoardServiceInterface, listService ListServiceInterface, taskService TaskServiceInterface,
+	commentService CommentServiceInterface) *trelloImportService {
+	return &trelloImportService{boardService, listService, taskService, commentService}
+}
+
+// trelloListImport is a list planned for import with its cards in order.
+type trelloListImport struct {
+	source *model.TrelloList
+	cards  []trelloCardImport
+}
+
+type trelloCardImport struct {
+	source   *model.TrelloCard
+	task     model.Task
+	comments []string
+}
+
+// ImportTrelloBoard creates a board owned by the user from a Trello board export. Lists
+// and cards keep their order, with Trello's positions renumbered into orders, and archived
+// lists and cards are moved to the trash. Comments are added by the actor with their
+// original author and date at the top. Everything that cannot be mapped is left out and
+// listed in the report. Should writing fail part way, the partly imported board is moved
+// to the trash.
+func (srv *trelloImportService) ImportTrelloBoard(owner *model.User, trello *model.TrelloBoard, actor model.Actor) (*model.ImportReport, error) {
+	report := &model.ImportReport{Skipped: []model.ImportIssue{}}
+	skip := func(kind string, id string, name string, field string, reason string) {
+		report.Skipped = append(report.Skipped, model.ImportIssue{Type: kind, SourceID: id, Name: name, Field: field, Reason: reason})
+	}
+
+	board := model.Board{Name: TruncateName(trello.Name), OwnerID: owner.ID}
+	if trello.Desc != "" {
+		skip("board", trello.ID, trello.Name, "desc", "boards have no description")
+	}
+	if len(trello.Members) > 1 {
+		skip("board", trello.ID, trello.Name, "members", "members are not imported, the board is owned by the importing user")
+	}
+	if len(trello.CustomFields) > 0 {
+		skip("board", trello.ID, trello.Name, "customFields", "custom fields are not supported")
+	}
+
+	labelIds := srv.importLabels(&board, trello.Labels, skip)
+	report.Labels = len(board.Labels)
+
+	lists := make([]trelloListImport, 0, len(trello.Lists))
+	listIndexes := map[string]int{}
+	for _, i := range sortedTrelloLists(trello.Lists) {
+		list := &trello.Lists[i]
+		listIndexes[list.ID] = len(lists)
+		lists = append(lists, trelloListImport{source: list})
+	}
+
+	checklists := map[string][]model.TrelloChecklist{}
+	for _, checklist := range trello.Checklists {
+		checklists[checklist.IDCard] = append(checklists[checklist.IDCard], checklist)
+	}
+
+	cardIndexes := map[string][2]int{}
+	for _, i := range sortedTrelloCards(trello.Cards) {
+		card := &trello.Cards[i]
+		listIndex, ok := listIndexes[card.IDList]
+		if !ok {
+			skip("card", card.ID, card.Name, "", "the list of the card is not in the export")
+			continue
+		}
+
+		task := trelloTask(card, labelIds, checklists[card.ID], actor, skip)
+		report.Checklists += len(task.Checklists)
+		cardIndexes[card.ID] = [2]int{listIndex, len(lists[listIndex].cards)}
+		lists[listIndex].cards = append(lists[listIndex].cards, trelloCardImport{source: card, task: task})
+	}
+
+	// Trello lists the newest actions first.
+	for i := len(trello.Actions) - 1; i >= 0; i-- {
+		action := &trello.Actions[i]
+		if action.Type != "commentCard" {
+			continue
+		}
+
+		index, ok := cardIndexes[action.Data.Card.ID]
+		if !ok {
+			skip("comment", action.ID, "", "", "the card of the comment is not imported")
+			continue
+		}
+
+		content := fmt.Sprintf("%s on %s:\n\n%s", trelloAuthor(&action.MemberCreator), action.Date.UTC().Format("2006-01-02 15:04 MST"), action.Data.Text)
+		if !validComment(content) {
+			skip("comment", action.ID, "", "", ErrInvalidComment.Error())
+			continue
+		}
+		card := &lists[index[0]].cards[index[1]]
+		card.comments = append(card.comments, content)
+	}
+
+	resultBoard, err := srv.boardService.CreateBoard(&board, actor)
+	if err != nil {
+		return nil, err
+	}
+	report.Board = resultBoard
+
+	if err := srv.importLists(resultBoard, lists, report, actor); err != nil {
+		if deleteErr := srv.boardService.DeleteBoard(resultBoard, actor); deleteErr != nil {
+			fmt.Printf("failed to trash partly imported board %s. %s\n", resultBoard.ID.Hex(), deleteErr)
+		}
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// importLabels adds the Trello labels to the board and maps their ids to the new ones.
+// Labels sharing a name are merged into one.
+func (srv *trelloImportService) importLabels(board *model.Board, labels []model.TrelloLabel,
+	skip func(string, string, string, string, string)) map[string]primitive.ObjectID {
+	labelIds := map[string]primitive.ObjectID{}
+
+	for _, label := range labels {
+		color := trelloLabelColor(label.Color)
+		name := strings.TrimSpace(label.Name)
+		if name == "" {
+			name = strings.SplitN(label.Color, "_", 2)[0]
+		}
+		if name == "" {
+			name = "label"
+		}
+
+		if !isValidBoardLabel(name, color) {
+			skip("label", label.ID, label.Name, "", ErrInvalidBoardLabel.Error())
+			continue
+		}
+
+		if i := findBoardLabelByName(board, name, primitive.NilObjectID); i >= 0 {
+			skip("label", label.ID, label.Name, "", "merged with the label of the same name")
+			labelIds[label.ID] = board.Labels[i].ID
+			continue
+		}
+
+		id := primitive.NewObjectID()
+		labelIds[label.ID] = id
+		board.Labels = append(board.Labels, model.BoardLabel{ID: id, Name: name, Color: color})
+	}
+
+	return labelIds
+}
+
+// importLists writes the planned lists with their tasks and comments, then trashes what
+// was archived in Trello.
+func (srv *trelloImportService) importLists(board *model.Board, lists []trelloListImport, report *model.ImportReport, actor model.Actor) error {
+	for i, planned := range lists {
+		list := model.BoardList{BoardID: board.ID, Name: TruncateName(planned.source.Name), Order: renumberedOrder(i)}
+		resultList, err := srv.listService.CreateList(&list, actor)
+		if err != nil {
+			return fmt.Errorf("failed to import list %q : %v", planned.source.Name, err)
+		}
+		report.Lists++
+
+		for j, card := range planned.cards {
+			task := card.task
+			task.ListID = resultList.ID
+			task.Order = renumberedOrder(j)
+			resultTask, err := srv.taskService.CreateTask(&task, resultList, actor)
+			if err != nil {
+				return fmt.Errorf("failed to import card %q : %v", card.source.Name, err)
+			}
+			report.Tasks++
+
+			for _, content := range card.comments {
+				if _, err := srv.commentService.CreateComment(board, resultTask, content, actor); err != nil {
+					return fmt.Errorf("failed to import comment of card %q : %v", card.source.Name, err)
+				}
+				report.Comments++
+			}
+
+			// Archived cards get their own trash time, so restoring an archived list
+			// leaves them in the trash.
+			if card.source.Closed {
+				if err := srv.taskService.DeleteTask(resultTask, actor); err != nil {
+					return fmt.Errorf("failed to trash archived card %q : %v", card.source.Name, err)
+				}
+			}
+		}
+
+		if planned.source.Closed {
+			if err := srv.listService.DeleteList(resultList, actor); err != nil {
+				return fmt.Errorf("failed to trash archived list %q : %v", planned.source.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// trelloTask maps a card to a task, leaving out what has no counterpart. Cards whose due
+// date is marked complete are completed by the actor at the time of the import, as the
+// export does not tell when or by whom.
+func trelloTask(card *model.TrelloCard, labelIds map[string]primitive.ObjectID, checklists []model.TrelloChecklist,
+	actor model.Actor, skip func(string, string, string, string, string)) model.Task {
+	task := model.Task{
+		Name:    TruncateName(card.Name),
+		Content: card.Desc,
+		StartTS: card.Start,
+		DueTS:   card.Due,
+	}
+
+	if !validTaskDates(task.StartTS, task.DueTS) {
+		skip("card", card.ID, card.Name, "start", ErrInvalidTaskDates.Error())
+		task.StartTS = nil
+	}
+
+	for _, trelloLabelId := range card.IDLabels {
+		if id, ok := labelIds[trelloLabelId]; ok && !containsId(task.LabelIDs, id) {
+			task.LabelIDs = append(task.LabelIDs, id)
+		}
+	}
+
+	if card.DueComplete {
+		completeTask(&task, actor.UserID, time.Now())
+	}
+	if len(card.IDMembers) > 0 {
+		skip("card", card.ID, card.Name, "idMembers", "members are not imported")
+	}
+	if len(card.Attachments) > 0 {
+		skip("card", card.ID, card.Name, "attachments", "attachments are not imported")
+	}
+	if len(card.CustomFieldItems) > 0 {
+		skip("card", card.ID, card.Name, "customFieldItems", "custom fields are not supported")
+	}
+
+	sort.SliceStable(checklists, func(i, j int) bool { return checklists[i].Pos < checklists[j].Pos })
+	for _, trelloChecklist := range checklists {
+		checklist := model.Checklist{ID: primitive.NewObjectID(), Name: TruncateName(trelloChecklist.Name)}
+		if !validChecklistName(checklist.Name) {
+			checklist.Name = "Checklist"
+		}
+
+		items := trelloChecklist.CheckItems
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Pos < items[j].Pos })
+		for _, item := range items {
+			name := TruncateName(item.Name)
+			if !validChecklistName(name) {
+				skip("checkItem", item.ID, item.Name, "", ErrInvalidChecklistName.Error())
+				continue
+			}
+			checklist.Items = append(checklist.Items, model.ChecklistItem{
+				ID:   primitive.NewObjectID(),
+				Name: name,
+				Done: item.State == "complete",
+			})
+		}
+		task.Checklists = append(task.Checklists, checklist)
+	}
+
+	return task
+}
+
+// sortedTrelloLists returns the indexes of the lists ordered by position.
+func sortedTrelloLists(lists []model.TrelloList) []int {
+	indexes := make([]int, len(lists))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool { return lists[indexes[i]].Pos < lists[indexes[j]].Pos })
+	return indexes
+}
+
+// sortedTrelloCards returns the indexes of the cards ordered by position.
+func sortedTrelloCards(cards []model.TrelloCard) []int {
+	indexes := make([]int, len(cards))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool { return cards[indexes[i]].Pos < cards[indexes[j]].Pos })
+	return indexes
+}
+
+func trelloLabelColor(color string) string {
+	if hex, ok := trelloLabelColors[strings.SplitN(color, "_", 2)[0]]; ok {
+		return hex
+	}
+	return trelloDefaultLabelColor
+}
+
+func trelloAuthor(member *model.TrelloMember) string {
+	switch {
+	case member.FullName != "":
+		return member.FullName
+	case member.Username != "":
+		return member.Username
+	}
+	return "Unknown Trello member"
+}
@@ -1,6 +1,12 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"regexp"
 	"time"
 	"todo/dao"
@@ -16,6 +22,8 @@ var (
 	USERNAME_REGEX *regexp.Regexp
 )
 
+var ErrInvalidCalendarToken = errors.New("calendar token is not valid")
+
 func init() {
 	USERNAME_REGEX, _ = regexp.Compile(USERNAME_REGEX_STRING)
 }
@@ -23,20 +31,28 @@ func init() {
 type UserServiceInterface interface {
 	CreateUser(user *model.User) (*model.User, error)
 	DeleteUser(user *model.User) error
+	PatchUser(user *model.User, patch *model.Patch) (*model.User, error)
 	FindUserById(id string) (model.User, error)
 	FindUserByUsername(username string) (model.User, error)
+	FindUserByCalendarToken(token string) (model.User, error)
+	CreateCalendarToken(user *model.User) (string, error)
+	RevokeCalendarToken(user *model.User) error
 	GetUsers() ([]model.User, error)
+	GetUsersPage(page *model.Page) ([]model.User, string, error)
+	GetUserSummaries(userIds []primitive.ObjectID) ([]model.UserSummary, error)
 	ValidatePassword(s string) bool
 	ValidateUsername(s string) bool
 	ScrubUserForAPI(u *model.User)
 }
 
 type userService struct {
-	userDao dao.UserDaoInterface
+	userDao      dao.UserDaoInterface
+	boardService BoardServiceInterface
+	taskService  TaskServiceInterface
 }
 
-func UserService(userDao dao.UserDaoInterface) *userService {
-	return &userService{userDao}
+func UserService(userDao dao.UserDaoInterface, boardService BoardServiceInterface, taskService TaskServiceInterface) *userService {
+	return &userService{userDao, boardService, taskService}
 }
 
 func (userService *userService) CreateUser(user *model.User) (*model.User, error) {
@@ -44,10 +60,24 @@ func (userService *userService) CreateUser(user *model.User) (*model.User, error
 	return userService.userDao.CreateUser(user)
 }
 
+// DeleteUser deletes the user's boards first so that no board is left without an owner,
+// and unassigns the user from the tasks of the boards shared with them.
 func (userService *userService) DeleteUser(user *model.User) error {
+	if err := userService.boardService.DeleteBoardsOfUser(user.ID); err != nil {
+		return fmt.Errorf("failed to delete boards of user %s : %v", user.ID.Hex(), err)
+	}
+
+	if err := userService.taskService.RemoveAssigneeFromAllTasks(user.ID); err != nil {
+		return fmt.Errorf("failed to unassign user %s from tasks : %v", user.ID.Hex(), err)
+	}
+
 	return userService.userDao.DeleteUser(user)
 }
 
+func (userService *userService) PatchUser(user *model.User, patch *model.Patch) (*model.User, error) {
+	return userService.userDao.PatchUser(user, patch)
+}
+
 func (userService *userService) FindUserById(id string) (model.User, error) {
 	return userService.userDao.FindUserById(id)
 }
@@ -56,10 +86,56 @@ func (userService *userService) FindUserByUsername(username string) (model.User,
 	return userService.userDao.FindUserByUsername(username)
 }
 
+// FindUserByCalendarToken returns the user whose calendar feeds the token opens.
+func (userService *userService) FindUserByCalendarToken(token string) (model.User, error) {
+	if token == "" {
+		return model.User{}, ErrInvalidCalendarToken
+	}
+
+	user, err := userService.userDao.FindUserByCalendarTokenHash(calendarTokenHash(token))
+	if err != nil {
+		return model.User{}, ErrInvalidCalendarToken
+	}
+	return user, nil
+}
+
+// CreateCalendarToken gives the user a new calendar feed token, which replaces and so
+// revokes the previous one. Only its hash is stored, the token can not be shown again.
+func (userService *userService) CreateCalendarToken(user *model.User) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	patch := model.NewPatch()
+	patch.Set["calendar_token_hash"] = calendarTokenHash(token)
+	if _, err := userService.userDao.PatchUser(user, patch); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (userService *userService) RevokeCalendarToken(user *model.User) error {
+	patch := model.NewPatch()
+	patch.Unset = append(patch.Unset, "calendar_token_hash")
+	_, err := userService.userDao.PatchUser(user, patch)
+	return err
+}
+
+func calendarTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func (userService *userService) GetUsers() ([]model.User, error) {
 	return userService.userDao.GetUsers()
 }
 
+func (userService *userService) GetUsersPage(page *model.Page) ([]model.User, string, error) {
+	return userService.userDao.GetUsersPage(page)
+}
+
 func (userService *userService) ValidatePassword(s string) bool {
 	if len(s) < 8 {
 		return false
@@ -91,6 +167,10 @@ func (userService *userService) ValidateUsername(s string) bool {
 	return true
 }
 
+func (userService *userService) GetUserSummaries(userIds []primitive.ObjectID) ([]model.UserSummary, error) {
+	return userService.userDao.GetUserSummaries(userIds)
+}
+
 func (userService *userService) ScrubUserForAPI(u *model.User) {
 	u.Password = ""
 }
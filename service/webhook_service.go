@@ -0,0 +1,417 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"todo/dao"
+	"todo/model"
+)
+
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+
+	// WebhookAllEvents subscribes a webhook to every event of its board.
+	WebhookAllEvents = "*"
+
+	maxWebhookAttempts      = 8
+	webhookDisableThreshold = 20
+	webhookRetryDelay       = 30 * time.Second
+	webhookDeliveryLease    = 2 * time.Minute
+	webhookTimeout          = 10 * time.Second
+	maxWebhookErrorLength   = 500
+)
+
+var (
+	ErrInvalidWebhookURL    = errors.New("webhook url must be an absolute http or https url to a public address")
+	ErrInvalidWebhookEvents = errors.New("webhook events must name board, list or task events")
+)
+
+type WebhookServiceInterface interface {
+	CreateWebhook(board *model.Board, url string, events []string) (*model.Webhook, error)
+	UpdateWebhook(webhook *model.Webhook, url string, events []string, active *bool) (*model.Webhook, error)
+	DeleteWebhook(webhook *model.Webhook) error
+	FindWebhookById(id string) (model.Webhook, error)
+	GetWebhooks(board *model.Board) ([]model.Webhook, error)
+	GetDeliveriesPage(webhook *model.Webhook, page *model.Page) ([]model.WebhookDelivery, string, error)
+	EnqueueEvent(event *model.BoardEvent) error
+	DeliverDueWebhooks() error
+	DeliverWebhooksPeriodically(interval time.Duration)
+}
+
+type webhookService struct {
+	webhookDao      dao.WebhookDaoInterface
+	allowedNetworks []*net.IPNet
+	client          *http.Client
+	wake            chan struct{}
+}
+
+// WebhookService delivers webhooks to public addresses only. allowedNetworks lists the IPs
+// and CIDR ranges that may be reached even though they are loopback, private or link-local,
+// such as a local test receiver.
+func WebhookService(webhookDao dao.WebhookDaoInterface, allowedNetworks []string) *webhookService {
+	srv := &webhookService{webhookDao: webhookDao, allowedNetworks: parseNetworks(allowedNetworks), wake: make(chan struct{}, 1)}
+
+	// Addresses are checked once resolved, right before connecting, so that a host name
+	// can not resolve to a public address when the url is validated and to an internal
+	// one when it is delivered to. Redirects are not followed for the same reason.
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !srv.reachable(net.ParseIP(host)) {
+				return fmt.Errorf("webhook address %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+	srv.client = &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: webhookTimeout},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return srv
+}
+
+func (srv *webhookService) CreateWebhook(board *model.Board, url string, events []string) (*model.Webhook, error) {
+	if !srv.validWebhookURL(url) {
+		return nil, ErrInvalidWebhookURL
+	}
+	if !validWebhookEvents(events) {
+		return nil, ErrInvalidWebhookEvents
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret : %v", err)
+	}
+
+	now := time.Now()
+	webhook := model.Webhook{
+		BoardID:    board.ID,
+		URL:        url,
+		Events:     events,
+		Secret:     hex.EncodeToString(secret),
+		Active:     true,
+		CreatedTS:  now,
+		ModifiedTS: now,
+	}
+	return srv.webhookDao.CreateWebhook(&webhook)
+}
+
+// UpdateWebhook replaces the url and events of the webhook. Activating a webhook that was
+// disabled after failing gives it a fresh count of failures.
+func (srv *webhookService) UpdateWebhook(webhook *model.Webhook, url string, events []string, active *bool) (*model.Webhook, error) {
+	if !srv.validWebhookURL(url) {
+		return nil, ErrInvalidWebhookURL
+	}
+	if !validWebhookEvents(events) {
+		return nil, ErrInvalidWebhookEvents
+	}
+
+	webhook.URL = url
+	webhook.Events = events
+	if active != nil {
+		if *active && !webhook.Active {
+			webhook.ConsecutiveFailures = 0
+			webhook.DisabledTS = nil
+		}
+		webhook.Active = *active
+	}
+	webhook.ModifiedTS = time.Now()
+	return srv.webhookDao.UpdateWebhook(webhook)
+}
+
+func (srv *webhookService) DeleteWebhook(webhook *model.Webhook) error {
+	return srv.webhookDao.DeleteWebhook(webhook)
+}
+
+func (srv *webhookService) FindWebhookById(id string) (model.Webhook, error) {
+	return srv.webhookDao.FindWebhookById(id)
+}
+
+func (srv *webhookService) GetWebhooks(board *model.Board) ([]model.Webhook, error) {
+	return srv.webhookDao.GetWebhooks(board.ID)
+}
+
+func (srv *webhookService) GetDeliveriesPage(webhook *model.Webhook, page *model.Page) ([]model.WebhookDelivery, string, error) {
+	return srv.webhookDao.GetDeliveriesPage(webhook.ID, page)
+}
+
+// EnqueueEvent queues a delivery of the event for every active webhook of its board that
+// subscribes to it, and wakes up the delivery loop.
+func (srv *webhookService) EnqueueEvent(event *model.BoardEvent) error {
+	webhooks, err := srv.webhookDao.GetActiveWebhooks(event.BoardID)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	var deliveries []model.WebhookDelivery
+	now := time.Now()
+	for _, webhook := range webhooks {
+		if !subscribesTo(&webhook, event.Type) {
+			continue
+		}
+
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return err
+			}
+		}
+
+		deliveries = append(deliveries, model.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			BoardID:       event.BoardID,
+			Event:         event.Type,
+			Payload:       string(payload),
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptTS: &now,
+			CreatedTS:     now,
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := srv.webhookDao.CreateDeliveries(deliveries); err != nil {
+		return err
+	}
+
+	select {
+	case srv.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// DeliverDueWebhooks attempts every delivery whose next attempt is due, one at a time.
+func (srv *webhookService) DeliverDueWebhooks() error {
+	for {
+		delivery, err := srv.webhookDao.ClaimDueDelivery(time.Now(), webhookDeliveryLease)
+		if err != nil {
+			return fmt.Errorf("failed to claim webhook delivery : %v", err)
+		}
+		if delivery == nil {
+			return nil
+		}
+
+		if err := srv.attemptDelivery(delivery); err != nil {
+			return fmt.Errorf("failed to record webhook delivery %s : %v", delivery.ID.Hex(), err)
+		}
+	}
+}
+
+// DeliverWebhooksPeriodically delivers due webhooks once per interval and whenever new
+// deliveries are queued. It never returns and is meant to run in its own goroutine.
+func (srv *webhookService) DeliverWebhooksPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := srv.DeliverDueWebhooks(); err != nil {
+			fmt.Printf("failed to deliver webhooks. %s\n", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-srv.wake:
+		}
+	}
+}
+
+// attemptDelivery posts the delivery and records the outcome. Failed attempts are retried
+// with exponential backoff until maxWebhookAttempts, and count towards disabling the
+// webhook whatever delivery they belong to.
+func (srv *webhookService) attemptDelivery(delivery *model.WebhookDelivery) error {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptTS = &now
+	delivery.NextAttemptTS = nil
+
+	webhook, err := srv.webhookDao.FindWebhookById(delivery.WebhookID.Hex())
+	if err != nil || !webhook.Active {
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.Error = "webhook is disabled or deleted"
+		return srv.webhookDao.UpdateDelivery(delivery)
+	}
+
+	delivery.ResponseStatus, err = srv.post(&webhook, delivery)
+	if err == nil {
+		delivery.Status = model.WebhookDeliverySucceeded
+		delivery.Error = ""
+		if err := srv.webhookDao.ResetWebhookFailures(webhook.ID); err != nil {
+			return err
+		}
+		return srv.webhookDao.UpdateDelivery(delivery)
+	}
+
+	delivery.Error = err.Error()
+	if len(delivery.Error) > maxWebhookErrorLength {
+		delivery.Error = delivery.Error[:maxWebhookErrorLength]
+	}
+
+	if delivery.Attempts >= maxWebhookAttempts {
+		delivery.Status = model.WebhookDeliveryFailed
+	} else {
+		nextAttempt := now.Add(webhookRetryDelay << (delivery.Attempts - 1))
+		delivery.NextAttemptTS = &nextAttempt
+	}
+
+	failed, err := srv.webhookDao.IncrementWebhookFailures(webhook.ID)
+	if err != nil {
+		return err
+	}
+	if failed.ConsecutiveFailures >= webhookDisableThreshold {
+		if err := srv.webhookDao.DisableWebhook(webhook.ID, now); err != nil {
+			return err
+		}
+	}
+
+	return srv.webhookDao.UpdateDelivery(delivery)
+}
+
+// post sends the payload signed with the secret of the webhook. Any response outside the
+// 2xx range counts as a failure.
+func (srv *webhookService) post(webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(WebhookSignatureHeader, "sha256="+WebhookSignature(webhook.Secret, []byte(delivery.Payload)))
+
+	resp, err := srv.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// WebhookSignature is the hex encoded HMAC-SHA256 of the payload, which receivers compute
+// with their copy of the secret to check the X-Webhook-Signature header.
+func WebhookSignature(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func subscribesTo(webhook *model.Webhook, eventType string) bool {
+	for _, subscribed := range webhook.Events {
+		if subscribed == WebhookAllEvents || subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// validWebhookURL rejects urls naming an address that deliveries may not reach. Host names
+// are only resolved when delivering.
+func (srv *webhookService) validWebhookURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return false
+	}
+
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil {
+		return srv.reachable(ip)
+	}
+	return true
+}
+
+// reachable tells whether deliveries may connect to the ip, which they may unless it is
+// internal to the host or its network and not explicitly allowed.
+func (srv *webhookService) reachable(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range srv.allowedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// parseNetworks reads IPs and CIDR ranges. Entries that are neither are reported and left
+// out.
+func parseNetworks(entries []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			fmt.Printf("ignoring invalid webhook network %s. %s\n", entry, err)
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func validWebhookEvents(events []string) bool {
+	if len(events) == 0 {
+		return false
+	}
+
+	for _, event := range events {
+		if event == WebhookAllEvents {
+			continue
+		}
+		valid := false
+		for _, entityType := range []string{model.ActivityBoard, model.ActivityList, model.ActivityTask} {
+			for _, action := range boardEventActions {
+				if event == entityType+"."+action {
+					valid = true
+				}
+			}
+		}
+		if !valid {
+			return false
+		}
+	}
+	return true
+}
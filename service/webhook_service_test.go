@@ -0,0 +1,269 @@
+package service
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo/model"
+)
+
+// memoryWebhookDao keeps one webhook and the deliveries queued for it.
+type memoryWebhookDao struct {
+	webhook    model.Webhook
+	deliveries []*model.WebhookDelivery
+}
+
+func (dao *memoryWebhookDao) CreateWebhook(webhook *model.Webhook) (*model.Webhook, error) {
+	webhook.ID = primitive.NewObjectID()
+	dao.webhook = *webhook
+	return webhook, nil
+}
+
+func (dao *memoryWebhookDao) UpdateWebhook(webhook *model.Webhook) (*model.Webhook, error) {
+	dao.webhook = *webhook
+	return webhook, nil
+}
+
+func (dao *memoryWebhookDao) DeleteWebhook(webhook *model.Webhook) error { return nil }
+
+func (dao *memoryWebhookDao) DeleteWebhooksByBoardIds(boardIds []primitive.ObjectID) error {
+	return nil
+}
+
+func (dao *memoryWebhookDao) IncrementWebhookFailures(id primitive.ObjectID) (model.Webhook, error) {
+	dao.webhook.ConsecutiveFailures++
+	return dao.webhook, nil
+}
+
+func (dao *memoryWebhookDao) ResetWebhookFailures(id primitive.ObjectID) error {
+	dao.webhook.ConsecutiveFailures = 0
+	return nil
+}
+
+func (dao *memoryWebhookDao) DisableWebhook(id primitive.ObjectID, disabledTS time.Time) error {
+	dao.webhook.Active = false
+	dao.webhook.DisabledTS = &disabledTS
+	return nil
+}
+
+func (dao *memoryWebhookDao) FindWebhookById(id string) (model.Webhook, error) {
+	return dao.webhook, nil
+}
+
+func (dao *memoryWebhookDao) GetWebhooks(boardId primitive.ObjectID) ([]model.Webhook, error) {
+	return []model.Webhook{dao.webhook}, nil
+}
+
+func (dao *memoryWebhookDao) GetActiveWebhooks(boardId primitive.ObjectID) ([]model.Webhook, error) {
+	if !dao.webhook.Active {
+		return nil, nil
+	}
+	return []model.Webhook{dao.webhook}, nil
+}
+
+func (dao *memoryWebhookDao) CreateDeliveries(deliveries []model.WebhookDelivery) error {
+	for i := range deliveries {
+		delivery := deliveries[i]
+		delivery.ID = primitive.NewObjectID()
+		dao.deliveries = append(dao.deliveries, &delivery)
+	}
+	return nil
+}
+
+func (dao *memoryWebhookDao) ClaimDueDelivery(now time.Time, lease time.Duration) (*model.WebhookDelivery, error) {
+	for _, delivery := range dao.deliveries {
+		if delivery.Status == model.WebhookDeliveryPending && delivery.NextAttemptTS != nil && !delivery.NextAttemptTS.After(now) {
+			claimed := *delivery
+			leased := now.Add(lease)
+			delivery.NextAttemptTS = &leased
+			return &claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (dao *memoryWebhookDao) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	for i := range dao.deliveries {
+		if dao.deliveries[i].ID == delivery.ID {
+			updated := *delivery
+			dao.deliveries[i] = &updated
+		}
+	}
+	return nil
+}
+
+func (dao *memoryWebhookDao) GetDeliveriesPage(webhookId primitive.ObjectID, page *model.Page) ([]model.WebhookDelivery, string, error) {
+	return nil, "", nil
+}
+
+var localNetworks = []string{"127.0.0.0/8", "::1"}
+
+func newTestWebhook(t *testing.T, srv *webhookService, receiverURL string) *model.Webhook {
+	board := model.Board{ID: primitive.NewObjectID()}
+	webhook, err := srv.CreateWebhook(&board, receiverURL, []string{WebhookAllEvents})
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	return webhook
+}
+
+func enqueueTestEvent(t *testing.T, srv *webhookService, webhook *model.Webhook) {
+	event := model.BoardEvent{Type: "task.created", BoardID: webhook.BoardID, CreatedTS: time.Now()}
+	if err := srv.EnqueueEvent(&event); err != nil {
+		t.Fatalf("EnqueueEvent: %v", err)
+	}
+}
+
+func TestWebhookDeliveryIsSigned(t *testing.T) {
+	var signature, event, body string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		body = string(payload)
+		signature = r.Header.Get(WebhookSignatureHeader)
+		event = r.Header.Get(WebhookEventHeader)
+	}))
+	defer receiver.Close()
+
+	webhookDao := &memoryWebhookDao{}
+	srv := WebhookService(webhookDao, localNetworks)
+	webhook := newTestWebhook(t, srv, receiver.URL)
+	enqueueTestEvent(t, srv, webhook)
+
+	if err := srv.DeliverDueWebhooks(); err != nil {
+		t.Fatalf("DeliverDueWebhooks: %v", err)
+	}
+
+	if want := "sha256=" + WebhookSignature(webhook.Secret, []byte(body)); signature != want {
+		t.Errorf("signature = %q, want %q", signature, want)
+	}
+	if event != "task.created" {
+		t.Errorf("event header = %q", event)
+	}
+	if delivery := webhookDao.deliveries[0]; delivery.Status != model.WebhookDeliverySucceeded || delivery.ResponseStatus != http.StatusOK {
+		t.Errorf("delivery = %+v", delivery)
+	}
+}
+
+func TestWebhookDeliveryRetriesWithBackoff(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	webhookDao := &memoryWebhookDao{}
+	srv := WebhookService(webhookDao, localNetworks)
+	webhook := newTestWebhook(t, srv, receiver.URL)
+	enqueueTestEvent(t, srv, webhook)
+
+	for attempt := 1; attempt <= maxWebhookAttempts; attempt++ {
+		delivery := *webhookDao.deliveries[0]
+		if err := srv.attemptDelivery(&delivery); err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+
+		stored := webhookDao.deliveries[0]
+		if stored.Attempts != attempt || stored.ResponseStatus != http.StatusInternalServerError {
+			t.Fatalf("attempt %d: delivery = %+v", attempt, stored)
+		}
+
+		if attempt == maxWebhookAttempts {
+			if stored.Status != model.WebhookDeliveryFailed || stored.NextAttemptTS != nil {
+				t.Errorf("last attempt: status = %s, next attempt = %v", stored.Status, stored.NextAttemptTS)
+			}
+			continue
+		}
+
+		want := webhookRetryDelay << (attempt - 1)
+		if stored.Status != model.WebhookDeliveryPending || stored.NextAttemptTS.Sub(*stored.LastAttemptTS) != want {
+			t.Errorf("attempt %d: status = %s, retry after %v, want %v", attempt, stored.Status, stored.NextAttemptTS.Sub(*stored.LastAttemptTS), want)
+		}
+	}
+}
+
+func TestWebhookDisabledAfterConsecutiveFailures(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer receiver.Close()
+
+	webhookDao := &memoryWebhookDao{}
+	srv := WebhookService(webhookDao, localNetworks)
+	webhook := newTestWebhook(t, srv, receiver.URL)
+
+	for i := 0; i < webhookDisableThreshold; i++ {
+		if !webhookDao.webhook.Active {
+			t.Fatalf("disabled after %d failures", i)
+		}
+		enqueueTestEvent(t, srv, webhook)
+		delivery := *webhookDao.deliveries[len(webhookDao.deliveries)-1]
+		if err := srv.attemptDelivery(&delivery); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if webhookDao.webhook.Active || webhookDao.webhook.DisabledTS == nil {
+		t.Fatalf("webhook still active after %d failures", webhookDisableThreshold)
+	}
+
+	enqueueTestEvent(t, srv, webhook)
+	if count := len(webhookDao.deliveries); count != webhookDisableThreshold {
+		t.Errorf("disabled webhook got a delivery queued, %d deliveries", count)
+	}
+}
+
+func TestWebhookDeliveryRefusesInternalAddresses(t *testing.T) {
+	received := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+	}))
+	defer receiver.Close()
+
+	webhookDao := &memoryWebhookDao{}
+	srv := WebhookService(webhookDao, nil)
+
+	for _, rawURL := range []string{receiver.URL, "http://10.0.0.1/hook", "http://169.254.169.254/latest", "http://[::1]/", "http://0.0.0.0/"} {
+		board := model.Board{ID: primitive.NewObjectID()}
+		if _, err := srv.CreateWebhook(&board, rawURL, []string{WebhookAllEvents}); err != ErrInvalidWebhookURL {
+			t.Errorf("CreateWebhook(%s) = %v, want ErrInvalidWebhookURL", rawURL, err)
+		}
+	}
+
+	// A host name is only resolved when delivering, where the dialer refuses it.
+	webhookDao.webhook = model.Webhook{ID: primitive.NewObjectID(), URL: strings.Replace(receiver.URL, "127.0.0.1", "localhost", 1), Active: true, Events: []string{WebhookAllEvents}}
+	enqueueTestEvent(t, srv, &webhookDao.webhook)
+	delivery := *webhookDao.deliveries[0]
+	if err := srv.attemptDelivery(&delivery); err != nil {
+		t.Fatal(err)
+	}
+
+	if received || !strings.Contains(webhookDao.deliveries[0].Error, "is not allowed") {
+		t.Errorf("delivery reached the receiver or failed otherwise: %q", webhookDao.deliveries[0].Error)
+	}
+}
+
+func TestWebhookDeliveryDoesNotFollowRedirects(t *testing.T) {
+	followed := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	}))
+	defer target.Close()
+	receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer receiver.Close()
+
+	webhookDao := &memoryWebhookDao{}
+	srv := WebhookService(webhookDao, localNetworks)
+	webhook := newTestWebhook(t, srv, receiver.URL)
+	enqueueTestEvent(t, srv, webhook)
+
+	if err := srv.DeliverDueWebhooks(); err != nil {
+		t.Fatal(err)
+	}
+
+	if followed || webhookDao.deliveries[0].ResponseStatus != http.StatusTemporaryRedirect {
+		t.Errorf("redirect followed = %v, response status = %d", followed, webhookDao.deliveries[0].ResponseStatus)
+	}
+}